@@ -53,6 +53,8 @@ func run(components []*component.Component, configuration *config.Configuration)
 
 	wg.Wait()
 
+	printStatus(components)
+
 	for {
 		select {
 		case exit := <-exitChan:
@@ -100,6 +102,14 @@ func handleStart(current *component.Component, configuration *config.Configurati
 	return nil
 }
 
+func printStatus(components []*component.Component) {
+	fmt.Println("Pod status:")
+
+	for _, c := range components {
+		fmt.Println("  -", c.Status())
+	}
+}
+
 func done(components []*component.Component) {
 	shouldExit = true
 
@@ -147,8 +157,6 @@ func runInit(components []*component.Component, configuration *config.Configurat
 			}
 
 			current.WaitFor(exitChan)
-
-			current.Stop()
 		}()
 
 	waitLoop:
@@ -164,7 +172,8 @@ func runInit(components []*component.Component, configuration *config.Configurat
 					fmt.Println(" Status:", exit.StatusCode)
 
 					if exit.StatusCode == 0 {
-						// this is OK and expected
+						// this is OK and expected, the files to copy on exit are exported by now
+						exit.Component.RemoveExited()
 						break waitLoop
 
 					} else {
@@ -196,22 +205,73 @@ func runInit(components []*component.Component, configuration *config.Configurat
 	return 0
 }
 
+func dryRun(configuration *config.Configuration) {
+	var (
+		cli *controller.Client
+		err error
+	)
+
+	if configuration.InspectFile != "" {
+		cli, err = controller.NewClientFromInspect(configuration.InspectFile)
+	} else {
+		cli, err = controller.NewClient(configuration.RegistryAuthFile)
+	}
+
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize the controller client : %s", err.Error()))
+	}
+	defer cli.Close()
+
+	cli.SetStrict(configuration.Strict)
+
+	if err := cli.PrintCreateRequests(os.Stdout, configuration); err != nil {
+		panic(fmt.Sprintf("failed to prepare the components : %s", err.Error()))
+	}
+}
+
 func main() {
 	configuration := flags.Parse()
 
+	if configuration.DryRun {
+		// print what would be created, then exit
+		dryRun(configuration)
+		return
+	}
+
 	hcServer, err := healthcheck.Serve()
 	if err != nil {
 		panic(fmt.Sprintf("failed to serve the health check information : %s", err.Error()))
 	}
 	defer hcServer.Close()
 
-	cli, err := controller.NewClient()
+	cli, err := controller.NewClient(configuration.RegistryAuthFile)
 	if err != nil {
 		panic(fmt.Sprintf("failed to initialize the controller client : %s", err.Error()))
 	}
 	defer cli.Close()
 
+	cli.SetStrict(configuration.Strict)
+
+	if err := cli.RemoveOrphanedComponents(); err != nil {
+		fmt.Println("[Warning] Failed to remove the orphaned components:", err)
+	}
+
+	if err := cli.CreatePodVolumes(); err != nil {
+		panic(fmt.Sprintf("failed to create the pod volumes : %s", err.Error()))
+	}
+	defer cli.RemovePodVolumes()
+
+	if err := cli.StartDockerProxies(configuration.DockerProxyDir); err != nil {
+		panic(fmt.Sprintf("failed to start the Docker API proxies : %s", err.Error()))
+	}
+	defer cli.StopDockerProxies()
+
+	if configuration.DebugVolumes {
+		cli.DumpVolumeMappings(os.Stdout)
+	}
+
 	go cli.WatchHealthcheckEvents()
+	go cli.CopyFilesFromComponents()
 
 	initComponents, err := cli.GetInitComponents()
 	if err != nil {
@@ -227,12 +287,29 @@ func main() {
 		panic("no components found")
 	}
 
+	if err := cli.CheckCopySources(initComponents, components); err != nil {
+		panic(err.Error())
+	}
+
+	if err := cli.AddExtraHosts(append(initComponents, components...)); err != nil {
+		panic(fmt.Sprintf("failed to add the extra hosts : %s", err.Error()))
+	}
+
+	if err := component.CheckPolicies(append(initComponents, components...), configuration); err != nil {
+		panic(err.Error())
+	}
+
+	if err := component.PrepareImages(append(initComponents, components...), configuration); err != nil {
+		panic(err.Error())
+	}
+
 	if exitCode := runInit(initComponents, configuration); exitCode == 0 {
 		// only run the actual components when
 		// all the init components have successfully finished
 		run(components, configuration)
 
 	} else {
+		cli.RemovePodVolumes()
 		os.Exit(int(exitCode % 0xff))
 
 	}
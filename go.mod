@@ -5,7 +5,7 @@ go 1.13
 require (
 	github.com/Microsoft/go-winio v0.4.7 // indirect
 	github.com/docker/cli v0.0.0-20180601213240-2014e991ee3f
-	github.com/docker/distribution v2.6.0-rc.1.0.20180327202408-83389a148052+incompatible // indirect
+	github.com/docker/distribution v2.6.0-rc.1.0.20180327202408-83389a148052+incompatible
 	github.com/docker/docker v1.4.2-0.20180419201305-e396b27b7f20
 	github.com/docker/go-connections v0.3.0 // indirect
 	github.com/docker/go-units v0.3.3
@@ -17,7 +17,7 @@ require (
 	github.com/mitchellh/mapstructure v0.0.0-20180511142126-bb74f1db0675
 	github.com/onsi/ginkgo v1.16.5 // indirect
 	github.com/onsi/gomega v1.19.0 // indirect
-	github.com/opencontainers/go-digest v1.0.0-rc1 // indirect
+	github.com/opencontainers/go-digest v1.0.0-rc1
 	github.com/opencontainers/image-spec v1.0.1 // indirect
 	github.com/pkg/errors v0.8.0
 	github.com/sirupsen/logrus v1.0.5 // indirect
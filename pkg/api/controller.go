@@ -2,13 +2,26 @@ package api
 
 import (
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 )
 
 type Controller interface {
 	GetContainerID() string
 	GetContainerName() string
+	GetHostname() string
 	GetCgroup() string
 	GetLabels() map[string]string
 	GetHostConfig() *container.HostConfig
-	GetSharedVolumeSource(source string) string
+	GetSharedVolume(source string) (string, string)
+	GetMountsFrom(definition string) ([]mount.Mount, error)
+	GetDockerProxyMount(name string) *mount.Mount
+	GetExports() Exports
+}
+
+// Exports hold the files copied out of the components,
+// until the components or the controller copying them pick them up.
+type Exports interface {
+	Resolve(component, sourcePath string, data []byte, err error)
+	IsResolved(component, sourcePath string) bool
+	Wait(component, sourcePath string) ([]byte, error)
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/volume"
 	"io"
 	"time"
 )
@@ -15,9 +16,17 @@ type Engine interface {
 	StopContainer(containerID string, timeout *time.Duration) error
 	RemoveContainer(containerID string) error
 	CopyToContainer(containerID string, destPath string, content io.Reader) error
+	CopyFromContainer(containerID string, srcPath string) (io.ReadCloser, error)
+	SignalContainer(containerID string, signal string) error
+	ExecInContainer(containerID string, command []string) (int, error)
 	WaitContainer(containerID string) (<-chan container.ContainerWaitOKBody, <-chan error)
 	StreamLogs(containerID string) (io.ReadCloser, error)
 	PullImage(reference string) (io.ReadCloser, error)
+	HasImage(image string) (bool, error)
+	GetLocalImageDigests(image string) ([]string, error)
+	GetRemoteImageDigest(image string) (string, error)
 	InspectVolume(name string) (types.Volume, error)
+	CreateVolume(options volume.VolumesCreateBody) (types.Volume, error)
+	RemoveVolume(name string) error
 	WatchHealthcheckEvents() (<-chan events.Message, <-chan error)
 }
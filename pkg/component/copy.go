@@ -2,7 +2,6 @@ package component
 
 import (
 	"archive/tar"
-	"bytes"
 	"errors"
 	"fmt"
 	"github.com/rycus86/podlike/pkg/convert"
@@ -11,39 +10,102 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
+const (
+	SymlinksFollow   = "follow"
+	SymlinksPreserve = "preserve"
+)
+
 func (c *Component) copyFilesIfNecessary() error {
+	configs, err := c.getCopyConfigs()
+	if err != nil {
+		return err
+	}
+
+	for _, config := range configs {
+		var checksum string
+
+		if config.Watch != nil {
+			// checked before copying, so the changes made while copying are copied again
+			if checksum, err = c.getChecksum(config); err != nil {
+				return err
+			}
+		}
+
+		if err := c.copyFiles(config); err != nil {
+			return err
+		}
+
+		if config.Watch != nil {
+			c.watchedCopies = append(c.watchedCopies, watchedCopy{config: config, checksum: checksum})
+		}
+	}
+
+	return nil
+}
+
+func (c *Component) getCopyConfigs() ([]CopyConfig, error) {
+	var configs []CopyConfig
+
 	for key, value := range c.client.GetLabels() {
 		if strings.HasPrefix(key, "pod.copy.") {
 			if target := strings.TrimPrefix(key, "pod.copy."); target != c.Name {
 				continue
 			}
 
-			configs, err := parseCopyConfig(value)
+			parsed, err := parseCopyConfig(value)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			for _, config := range configs {
-				targetDir, targetFilename := path.Split(config.Target)
-				reader, err := createTar(config.Source, targetFilename)
-				if err != nil {
-					return err
-				}
+			configs = append(configs, parsed...)
+		}
+	}
 
-				fmt.Println("Copying", config.Source, "to", c.Name, "@", config.Target, "...")
+	return configs, nil
+}
 
-				err = c.engine.CopyToContainer(c.container.ID, targetDir, reader)
-				if err != nil {
-					return err
-				}
-			}
+// copyFiles copies the source of the configuration into the component's container.
+func (c *Component) copyFiles(config CopyConfig) error {
+	targetDir, targetFilename := path.Split(config.Target)
+
+	if config.From != "" {
+		reader, err := waitForCopySource(config, targetFilename, c.client.GetExports())
+		if err != nil {
+			return err
 		}
+
+		fmt.Println("Copying", config.From, "to", c.Name, "@", config.Target, "...")
+
+		return c.engine.CopyToContainer(c.container.ID, targetDir, reader)
 	}
 
-	return nil
+	var renderer *renderContext
+	if config.Render {
+		renderer = c.newRenderContext()
+	}
+
+	reader, err := createTar(config, targetFilename, renderer)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Copying", config.Source, "to", c.Name, "@", config.Target, "...")
+
+	err = c.engine.CopyToContainer(c.container.ID, targetDir, reader)
+
+	reader.Close()
+
+	// errors from creating the archive are more descriptive than the copy errors they cause
+	if tarErr := reader.Wait(); tarErr != nil && tarErr != io.ErrClosedPipe {
+		return tarErr
+	}
+
+	return err
 }
 
 func parseCopyConfig(definition string) ([]CopyConfig, error) {
@@ -72,24 +134,44 @@ func parseCopyConfig(definition string) ([]CopyConfig, error) {
 	// a mapping, like:
 	//  pod.copy.sample: |
 	//    /source: /target
-	if _, isMap := value.(map[interface{}]interface{}); isMap {
-		mapped, err := convert.ToStringToStringMap(value)
-		if err != nil {
-			return nil, err
-		}
+	//    /other/source:
+	//      target: /other/target
+	//      mode: 0755
+	if mapValue, isMap := value.(map[interface{}]interface{}); isMap {
+		parsed := make([]CopyConfig, 0, len(mapValue))
 
-		parsed := make([]CopyConfig, 0, len(mapped))
+		for rawSource, rawTarget := range mapValue {
+			if options, ok := rawTarget.(map[interface{}]interface{}); ok {
+				source, ok := rawSource.(string)
+				if !ok {
+					return nil, errors.New(fmt.Sprintf("not a string value: %+v (%T)", rawSource, rawSource))
+				}
 
-		for source, target := range mapped {
-			source := strings.Trim(source, " \t\n")
-			target := strings.Trim(target, " \t\n")
+				config, err := parseCopyOptions(source, options)
+				if err != nil {
+					return nil, err
+				}
 
-			if source == "" || target == "" {
-				return nil, errors.New(fmt.Sprintf(
-					"invalid pod.copy configuration: %s [%s:%s]", value, source, target))
+				parsed = append(parsed, *config)
+				continue
 			}
 
-			parsed = append(parsed, CopyConfig{Source: source, Target: target})
+			mapped, err := convert.ToStringToStringMap(map[interface{}]interface{}{rawSource: rawTarget})
+			if err != nil {
+				return nil, err
+			}
+
+			for source, target := range mapped {
+				source := strings.Trim(source, " \t\n")
+				target := strings.Trim(target, " \t\n")
+
+				if source == "" || target == "" {
+					return nil, errors.New(fmt.Sprintf(
+						"invalid pod.copy configuration: %s [%s:%s]", value, source, target))
+				}
+
+				parsed = append(parsed, CopyConfig{Source: source, Target: target})
+			}
 		}
 
 		return parsed, nil
@@ -98,10 +180,27 @@ func parseCopyConfig(definition string) ([]CopyConfig, error) {
 	// a sequence, like:
 	//  pod.copy.sample: |
 	//    - /source:/target
-	if items, err := convert.ToStringSlice(value); err == nil {
-		parsed := make([]CopyConfig, 0, len(items))
+	//    - source: /other/source
+	//      target: /other/target
+	if rawItems, ok := value.([]interface{}); ok {
+		parsed := make([]CopyConfig, 0, len(rawItems))
+
+		for _, rawItem := range rawItems {
+			if options, ok := rawItem.(map[interface{}]interface{}); ok {
+				config, err := parseCopyOptions("", options)
+				if err != nil {
+					return nil, err
+				}
+
+				parsed = append(parsed, *config)
+				continue
+			}
+
+			item, ok := rawItem.(string)
+			if !ok {
+				return nil, errors.New(fmt.Sprintf("not a string item: %+v (%T)", rawItem, rawItem))
+			}
 
-		for _, item := range items {
 			parts := strings.Split(item, ":")
 
 			if len(parts) != 2 {
@@ -121,42 +220,374 @@ func parseCopyConfig(definition string) ([]CopyConfig, error) {
 		}
 
 		return parsed, nil
-	} else {
-		return nil, err
 	}
 
 	return nil, errors.New(fmt.Sprintf("invalid pod.copy configuration: %s (%T)", value, value))
 }
 
-func createTar(path, filename string) (io.Reader, error) {
-	var b bytes.Buffer
+func parseCopyOptions(source string, options map[interface{}]interface{}) (*CopyConfig, error) {
+	var definition struct {
+		Source   string
+		Target   string
+		From     string
+		When     string
+		Mode     interface{}
+		Owner    string
+		Symlinks string
+		Render   bool
+		Watch    interface{}
+	}
 
-	fi, err := os.Stat(path)
+	data, err := yaml.Marshal(options)
 	if err != nil {
 		return nil, err
 	}
 
-	contents, err := ioutil.ReadFile(path)
-	if err != nil {
+	if err := yaml.UnmarshalStrict(data, &definition); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid pod.copy configuration for %s: %s", source, err))
+	}
+
+	if source != "" {
+		if definition.Source != "" {
+			return nil, errors.New(fmt.Sprintf(
+				"invalid pod.copy configuration: the source is given twice: %s and %s", source, definition.Source))
+		}
+
+		definition.Source = source
+	}
+
+	config := CopyConfig{
+		Source:    strings.Trim(definition.Source, " \t\n"),
+		Target:    strings.Trim(definition.Target, " \t\n"),
+		From:      strings.Trim(definition.From, " \t\n"),
+		Condition: definition.When,
+		Symlinks:  definition.Symlinks,
+		Render:    definition.Render,
+	}
+
+	if config.From != "" {
+		if err := validateCopySource(&config, definition.Watch); err != nil {
+			return nil, err
+		}
+
+	} else if config.Condition != "" {
+		return nil, errors.New(fmt.Sprintf(
+			"invalid pod.copy configuration for %s: a condition is only supported for copying from components",
+			config.Source))
+
+	} else if config.Source == "" || config.Target == "" {
+		return nil, errors.New(fmt.Sprintf(
+			"invalid pod.copy configuration: %+v [%s:%s]", options, config.Source, config.Target))
+	}
+
+	if definition.Mode != nil {
+		mode, err := parseFileMode(definition.Mode)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Mode = &mode
+	}
+
+	if definition.Owner != "" {
+		owner, err := parseOwner(definition.Owner)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Owner = owner
+	}
+
+	if definition.Watch != nil {
+		watch, err := parseWatchConfig(definition.Watch)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid watch configuration for %s: %s", config.Source, err))
+		}
+
+		config.Watch = watch
+	}
+
+	if config.Symlinks != "" && config.Symlinks != SymlinksFollow && config.Symlinks != SymlinksPreserve {
+		return nil, errors.New(fmt.Sprintf(
+			"invalid symlink handling for %s: %s (expected %s or %s)",
+			config.Source, config.Symlinks, SymlinksFollow, SymlinksPreserve))
+	}
+
+	return &config, nil
+}
+
+func validateCopySource(config *CopyConfig, watch interface{}) error {
+	if _, _, err := parseCopySource(config.From); err != nil {
+		return err
+	}
+
+	if config.Source != "" {
+		return errors.New(fmt.Sprintf(
+			"invalid pod.copy configuration: both a source (%s) and a source component (%s) is given",
+			config.Source, config.From))
+	}
+
+	if config.Target == "" {
+		return errors.New(fmt.Sprintf("invalid pod.copy configuration: missing target for %s", config.From))
+	}
+
+	if config.Render || config.Symlinks != "" || watch != nil {
+		return errors.New(fmt.Sprintf(
+			"invalid pod.copy configuration for %s: render, symlinks and watch are not supported "+
+				"when copying from components", config.From))
+	}
+
+	switch config.Condition {
+	case "":
+		config.Condition = ConditionStarted
+	case ConditionStarted, ConditionHealthy, ConditionExited:
+	default:
+		return errors.New(fmt.Sprintf(
+			"invalid condition for copying %s: %s (expected %s, %s or %s)",
+			config.From, config.Condition, ConditionStarted, ConditionHealthy, ConditionExited))
+	}
+
+	return nil
+}
+
+func parseFileMode(value interface{}) (os.FileMode, error) {
+	switch mode := value.(type) {
+	case int:
+		// YAML parses 0755 as an octal number already
+		if mode >= 0 && mode <= 07777 {
+			return os.FileMode(mode), nil
+		}
+
+	case string:
+		if parsed, err := strconv.ParseUint(mode, 8, 32); err == nil && parsed <= 07777 {
+			return os.FileMode(parsed), nil
+		}
+
+	}
+
+	return 0, errors.New(fmt.Sprintf("invalid file mode: %+v (%T)", value, value))
+}
+
+func parseOwner(value string) (*CopyOwner, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 2 {
+		return nil, errors.New(fmt.Sprintf("invalid owner, expected uid:gid : %s", value))
+	}
+
+	uid, err := strconv.Atoi(parts[0])
+	if err != nil || uid < 0 {
+		return nil, errors.New(fmt.Sprintf("invalid user ID in owner %s : %s", value, parts[0]))
+	}
+
+	gid := uid
+
+	if len(parts) == 2 {
+		if gid, err = strconv.Atoi(parts[1]); err != nil || gid < 0 {
+			return nil, errors.New(fmt.Sprintf("invalid group ID in owner %s : %s", value, parts[1]))
+		}
+	}
+
+	return &CopyOwner{UID: uid, GID: gid}, nil
+}
+
+type tarStream struct {
+	*io.PipeReader
+
+	result chan error
+}
+
+// Wait returns the result of writing the archive, after the stream is closed.
+func (s *tarStream) Wait() error {
+	return <-s.result
+}
+
+// createTar returns a reader streaming the source file or directory as a tar archive,
+// with the root item of the archive named as the filename given.
+// If the filename is empty, the contents of a source directory are put in the root of the archive.
+// Regular files are rendered as templates, when a render context is given.
+// The reader needs to be closed to release the resources of the background writer.
+func createTar(config CopyConfig, filename string, renderer *renderContext) (*tarStream, error) {
+	if _, err := os.Stat(config.Source); err != nil {
 		return nil, err
 	}
 
-	tw := tar.NewWriter(&b)
+	if filename == "" {
+		if fi, err := os.Stat(config.Source); err == nil && !fi.IsDir() {
+			filename = filepath.Base(config.Source)
+		}
+	}
+
+	reader, writer := io.Pipe()
+	stream := &tarStream{PipeReader: reader, result: make(chan error, 1)}
+
+	go func() {
+		tw := tar.NewWriter(writer)
+
+		err := addToTar(tw, &config, renderer, config.Source, filename, map[string]bool{})
+		if err == nil {
+			err = tw.Close()
+		}
+
+		writer.CloseWithError(err)
+		stream.result <- err
+	}()
+
+	return stream, nil
+}
+
+func addToTar(
+	tw *tar.Writer, config *CopyConfig, renderer *renderContext,
+	source, name string, visited map[string]bool) error {
+
+	fi, err := os.Lstat(source)
+	if err != nil {
+		return err
+	}
+
+	if fi.Mode()&os.ModeSymlink != 0 {
+		if config.Symlinks == SymlinksPreserve {
+			link, err := os.Readlink(source)
+			if err != nil {
+				return err
+			}
+
+			return tw.WriteHeader(config.newHeader(fi, name, tar.TypeSymlink, link))
+		}
+
+		if fi, err = os.Stat(source); err != nil {
+			return err
+		}
+	}
+
+	if fi.IsDir() {
+		realPath, err := filepath.EvalSymlinks(source)
+		if err != nil {
+			return err
+		}
+
+		if visited[realPath] {
+			return errors.New(fmt.Sprintf("symlink loop detected at %s", source))
+		}
+
+		visited[realPath] = true
+		defer delete(visited, realPath)
+
+		if name != "" {
+			if err := tw.WriteHeader(config.newHeader(fi, name+"/", tar.TypeDir, "")); err != nil {
+				return err
+			}
+		}
+
+		entries, err := ioutil.ReadDir(source)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			entryName := entry.Name()
+			if name != "" {
+				entryName = name + "/" + entryName
+			}
+
+			if err := addToTar(tw, config, renderer, filepath.Join(source, entry.Name()), entryName, visited); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if !fi.Mode().IsRegular() {
+		fmt.Println("[Warning] Skipping copying", source, "as it is not a regular file")
+		return nil
+	}
+
+	hdr := config.newHeader(fi, name, tar.TypeReg, "")
+
+	if renderer != nil {
+		contents, err := ioutil.ReadFile(source)
+		if err != nil {
+			return err
+		}
+
+		rendered, err := renderer.render(source, contents)
+		if err != nil {
+			return err
+		}
+
+		hdr.Size = int64(len(rendered))
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		_, err = tw.Write(rendered)
+		return err
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hdr.Size = fi.Size()
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(tw, file, fi.Size())
+	return err
+}
+
+func (c *CopyConfig) newHeader(fi os.FileInfo, name string, typeFlag byte, link string) *tar.Header {
 	hdr := tar.Header{
-		Name: filename,
-		Mode: 0644,
-		Size: fi.Size(),
+		Typeflag: typeFlag,
+		Name:     name,
+		Linkname: link,
+		Mode:     int64(fi.Mode().Perm()),
+		ModTime:  fi.ModTime(),
 	}
-	if err := tw.WriteHeader(&hdr); err != nil {
-		return nil, err
+
+	if typeFlag == tar.TypeReg && c.Mode != nil {
+		hdr.Mode = int64(*c.Mode)
 	}
 
-	if _, err = tw.Write(contents); err != nil {
-		return nil, err
+	if c.Owner != nil {
+		hdr.Uid = c.Owner.UID
+		hdr.Gid = c.Owner.GID
 	}
-	if err := tw.Close(); err != nil {
-		return nil, err
+
+	return &hdr
+}
+
+func (c CopyConfig) String() string {
+	var options string
+
+	if c.Mode != nil {
+		options += fmt.Sprintf(" mode=%04o", *c.Mode)
+	}
+
+	if c.Owner != nil {
+		options += fmt.Sprintf(" owner=%d:%d", c.Owner.UID, c.Owner.GID)
+	}
+
+	if c.From != "" {
+		return fmt.Sprintf("{%s:%s %s%s}", c.Condition, c.From, c.Target, options)
+	}
+
+	if c.Symlinks != "" {
+		options += " symlinks=" + c.Symlinks
+	}
+
+	if c.Render {
+		options += " render"
+	}
+
+	if c.Watch != nil {
+		options += " watch"
 	}
 
-	return &b, nil
+	return fmt.Sprintf("{%s %s%s}", c.Source, c.Target, options)
 }
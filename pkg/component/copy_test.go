@@ -1,10 +1,16 @@
 package component
 
 import (
+	"archive/tar"
 	"errors"
 	"fmt"
 	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -79,12 +85,12 @@ pod.copy.test: >
 
 func TestCopy_ParseAsSimpleString(t *testing.T) {
 	parseAndVerify(t, "pod.copy.test: /src:/dst",
-		CopyConfig{"/src", "/dst"})
+		CopyConfig{Source: "/src", Target: "/dst"})
 
 	parseAndVerify(t, `
 pod.copy.test: >
   /new:/line
-`, CopyConfig{"/new", "/line"})
+`, CopyConfig{Source: "/new", Target: "/line"})
 }
 
 func TestCopy_ParseAsSequenceOfStrings(t *testing.T) {
@@ -92,12 +98,12 @@ func TestCopy_ParseAsSequenceOfStrings(t *testing.T) {
 pod.copy.test: | 
   - /one:/first
   - /two:/second
-`, CopyConfig{"/one", "/first"}, CopyConfig{"/two", "/second"})
+`, CopyConfig{Source: "/one", Target: "/first"}, CopyConfig{Source: "/two", Target: "/second"})
 
 	parseAndVerify(t, `
 pod.copy.test: >
   [/one:/first, /two:/second]
-`, CopyConfig{"/one", "/first"}, CopyConfig{"/two", "/second"})
+`, CopyConfig{Source: "/one", Target: "/first"}, CopyConfig{Source: "/two", Target: "/second"})
 }
 
 func TestCopy_ParseAsMappingOfStrings(t *testing.T) {
@@ -105,7 +111,7 @@ func TestCopy_ParseAsMappingOfStrings(t *testing.T) {
 pod.copy.test: | 
   /one: /first
   /two: /second
-`, CopyConfig{"/one", "/first"}, CopyConfig{"/two", "/second"})
+`, CopyConfig{Source: "/one", Target: "/first"}, CopyConfig{Source: "/two", Target: "/second"})
 
 	parseAndVerify(t, `
 pod.copy.test: | 
@@ -113,9 +119,9 @@ pod.copy.test: |
   /some:        /the
   /whitespace:  /mapping
 `,
-		CopyConfig{"/with", "/in"},
-		CopyConfig{"/some", "/the"},
-		CopyConfig{"/whitespace", "/mapping"})
+		CopyConfig{Source: "/with", Target: "/in"},
+		CopyConfig{Source: "/some", Target: "/the"},
+		CopyConfig{Source: "/whitespace", Target: "/mapping"})
 }
 
 func TestCopy_SameSourceDifferentTargets(t *testing.T) {
@@ -123,7 +129,7 @@ func TestCopy_SameSourceDifferentTargets(t *testing.T) {
 pod.copy.test: |
   - '/src:/target/1'
   - '/src:/target/2'
-`, CopyConfig{"/src", "/target/1"}, CopyConfig{"/src", "/target/2"})
+`, CopyConfig{Source: "/src", Target: "/target/1"}, CopyConfig{Source: "/src", Target: "/target/2"})
 }
 
 func parseAndVerify(t *testing.T, yamlConfig string, expectedConfigs ...CopyConfig) []CopyConfig {
@@ -216,3 +222,219 @@ func getCopyDefinition(yamlConfig string) (string, error) {
 
 	return definition, nil
 }
+
+func TestCopy_ParseWithOptions(t *testing.T) {
+	parsed := parseAndVerify(t, `
+pod.copy.test: |
+  /etc/conf/scripts:
+    target: /opt/app/bin
+    mode: 0755
+    owner: 1000:2000
+    symlinks: preserve
+  /etc/conf/app.conf: /opt/app/app.conf
+`, CopyConfig{Source: "/etc/conf/scripts", Target: "/opt/app/bin"},
+		CopyConfig{Source: "/etc/conf/app.conf", Target: "/opt/app/app.conf"})
+
+	for _, config := range parsed {
+		if config.Source == "/etc/conf/scripts" {
+			if config.Mode == nil || *config.Mode != 0755 {
+				t.Error("Unexpected mode:", config.Mode)
+			}
+
+			if config.Owner == nil || config.Owner.UID != 1000 || config.Owner.GID != 2000 {
+				t.Error("Unexpected owner:", config.Owner)
+			}
+
+			if config.Symlinks != SymlinksPreserve {
+				t.Error("Unexpected symlink handling:", config.Symlinks)
+			}
+		} else if config.Mode != nil || config.Owner != nil || config.Symlinks != "" {
+			t.Error("Unexpected options:", config)
+		}
+	}
+
+	parsed = parseAndVerify(t, `
+pod.copy.test: |
+  - /src:/dst
+  - source: /scripts
+    target: /bin/scripts
+    mode: '0700'
+    owner: '33'
+`, CopyConfig{Source: "/src", Target: "/dst"},
+		CopyConfig{Source: "/scripts", Target: "/bin/scripts"})
+
+	if fmt.Sprintf("%v", parsed) != "[{/src /dst} {/scripts /bin/scripts mode=0700 owner=33:33}]" {
+		t.Error("Unexpected options:", parsed)
+	}
+}
+
+func TestCopy_OptionFailures(t *testing.T) {
+	for definition, expected := range map[string]string{
+		"/src: {target: /dst, mode: 'rwx'}":       "invalid file mode: rwx (string)",
+		"/src: {target: /dst, owner: 'www-data'}": "invalid user ID in owner www-data : www-data",
+		"/src: {target: /dst, symlinks: ignore}":  "invalid symlink handling for /src: ignore (expected follow or preserve)",
+		"/src: {source: /other, target: /dst}":    "invalid pod.copy configuration: the source is given twice: /src and /other",
+		"- {source: /src}":                        "invalid pod.copy configuration: map[source:/src] [/src:]",
+	} {
+		if _, err := parseCopyConfig(definition); err == nil {
+			t.Error("Expected to fail:", definition)
+		} else if err.Error() != expected {
+			t.Errorf("Unexpected error:\n%s\n%s", err, expected)
+		}
+	}
+}
+
+func TestCopy_DirectoryAsTar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "conf", "sub"), 0750)
+	ioutil.WriteFile(filepath.Join(dir, "conf", "app.conf"), []byte("config"), 0640)
+	ioutil.WriteFile(filepath.Join(dir, "conf", "sub", "run.sh"), []byte("#!/bin/sh"), 0755)
+	os.Symlink("app.conf", filepath.Join(dir, "conf", "link.conf"))
+
+	verifyTar(t, CopyConfig{Source: filepath.Join(dir, "conf")}, "target",
+		"target/ dir 0750 0:0",
+		"target/app.conf file 0640 0:0 config",
+		"target/link.conf file 0640 0:0 config",
+		"target/sub/ dir 0750 0:0",
+		"target/sub/run.sh file 0755 0:0 #!/bin/sh")
+
+	mode := os.FileMode(0600)
+
+	verifyTar(t, CopyConfig{
+		Source:   filepath.Join(dir, "conf"),
+		Mode:     &mode,
+		Owner:    &CopyOwner{UID: 1000, GID: 1001},
+		Symlinks: SymlinksPreserve,
+	}, "",
+		"app.conf file 0600 1000:1001 config",
+		"link.conf symlink app.conf 1000:1001",
+		"sub/ dir 0750 1000:1001",
+		"sub/run.sh file 0600 1000:1001 #!/bin/sh")
+
+	verifyTar(t, CopyConfig{Source: filepath.Join(dir, "conf", "sub", "run.sh")}, "",
+		"run.sh file 0755 0:0 #!/bin/sh")
+
+	if _, err := createTar(CopyConfig{Source: filepath.Join(dir, "missing")}, "target", nil); err == nil {
+		t.Error("Expected to fail for a missing source")
+	}
+}
+
+func TestCopy_SymlinkLoop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "conf"), 0755)
+	os.Symlink("..", filepath.Join(dir, "conf", "parent"))
+
+	reader, err := createTar(CopyConfig{Source: filepath.Join(dir, "conf")}, "target", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	if _, err := ioutil.ReadAll(reader); err == nil || !strings.Contains(err.Error(), "symlink loop") {
+		t.Error("Expected to fail on a symlink loop:", err)
+	}
+}
+
+func verifyTar(t *testing.T, config CopyConfig, filename string, expected ...string) {
+	reader, err := createTar(config, filename, nil)
+	if err != nil {
+		t.Fatal("Failed to create the tar archive:", err)
+	}
+	defer reader.Close()
+
+	var actual []string
+
+	tr := tar.NewReader(reader)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal("Failed to read the tar archive:", err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			actual = append(actual, fmt.Sprintf("%s dir %04o %d:%d", hdr.Name, hdr.Mode, hdr.Uid, hdr.Gid))
+		case tar.TypeSymlink:
+			actual = append(actual, fmt.Sprintf("%s symlink %s %d:%d", hdr.Name, hdr.Linkname, hdr.Uid, hdr.Gid))
+		default:
+			contents, _ := ioutil.ReadAll(tr)
+			actual = append(actual, fmt.Sprintf(
+				"%s file %04o %d:%d %s", hdr.Name, hdr.Mode, hdr.Uid, hdr.Gid, contents))
+		}
+	}
+
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected tar contents:\n%s\nexpected:\n%s",
+			strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestCopy_RenderTemplates(t *testing.T) {
+	parsed := parseAndVerify(t, `
+pod.copy.test: |
+  /etc/conf/nginx.conf:
+    target: /etc/nginx/nginx.conf
+    render: true
+`, CopyConfig{Source: "/etc/conf/nginx.conf", Target: "/etc/nginx/nginx.conf"})
+
+	if !parsed[0].Render {
+		t.Error("Expected to render the copied file")
+	}
+
+	dir, err := ioutil.TempDir("", "podlike-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(
+		"server_name {{ .Env.DOMAIN }}; # {{ .Component.Name }} in {{ .Controller.Name }} - {{ .Task.Name }}"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "missing.conf"), []byte("value: {{ .Env.MISSING }}"), 0644)
+
+	renderer := &renderContext{
+		Env:        map[string]string{"DOMAIN": "example.com"},
+		Component:  renderComponent{Name: "proxy"},
+		Controller: renderController{ID: "c0001", Name: "pod"},
+		Task:       renderSwarmObject{Name: "stack_pod.1.abcd"},
+	}
+
+	reader, err := createTar(CopyConfig{Source: filepath.Join(dir, "nginx.conf")}, "default.conf", renderer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	if hdr, err := tr.Next(); err != nil {
+		t.Fatal(err)
+	} else if contents, _ := ioutil.ReadAll(tr); string(contents) != "server_name example.com; # proxy in pod - stack_pod.1.abcd" {
+		t.Error("Unexpected rendered contents:", string(contents))
+	} else if hdr.Size != int64(len(contents)) {
+		t.Error("Unexpected size:", hdr.Size)
+	}
+
+	reader, err = createTar(CopyConfig{Source: filepath.Join(dir, "missing.conf")}, "", renderer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ioutil.ReadAll(reader)
+	reader.Close()
+
+	if err := reader.Wait(); err == nil || !strings.Contains(err.Error(), "MISSING") {
+		t.Error("Expected to fail on a missing variable:", err)
+	}
+}
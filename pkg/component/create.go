@@ -10,39 +10,36 @@ import (
 )
 
 func (c *Component) createContainer(configuration *config.Configuration) (string, error) {
-	if configuration.AlwaysPull {
-		if err := c.pullImage(); err != nil {
+	if configuration.Policy != nil {
+		if err := c.checkPolicy(configuration); err != nil {
 			return "", err
 		}
 	}
 
-	containerConfig, err := c.newContainerConfig()
-	if err != nil {
+	if err := c.prepareImage(configuration); err != nil {
 		return "", err
 	}
 
-	hostConfig, err := c.newHostConfig(configuration)
+	request, err := c.newCreateRequest(configuration)
 	if err != nil {
 		return "", err
 	}
 
-	name := c.client.GetContainerName() + ".podlike." + c.Name
-
 	created, err := c.engine.CreateContainer(
-		containerConfig,
-		hostConfig,
-		name)
+		request.Config,
+		request.HostConfig,
+		request.Name)
 
 	if err != nil {
-		if client.IsErrNotFound(err) {
+		if client.IsErrNotFound(err) && c.PullPolicy != PullNever {
 			if err := c.pullImage(); err != nil {
 				return "", err
 			}
 
 			created, err = c.engine.CreateContainer(
-				containerConfig,
-				hostConfig,
-				name)
+				request.Config,
+				request.HostConfig,
+				request.Name)
 
 			if err != nil {
 				return "", err
@@ -61,7 +58,31 @@ func (c *Component) createContainer(configuration *config.Configuration) (string
 	}
 }
 
+// newCreateRequest returns the name and the configuration of the container
+// of the component, as it is sent to the engine.
+func (c *Component) newCreateRequest(configuration *config.Configuration) (*CreateRequest, error) {
+	containerConfig, err := c.newContainerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	hostConfig, err := c.newHostConfig(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateRequest{
+		Name:       c.client.GetContainerName() + ".podlike." + c.Name,
+		Config:     containerConfig,
+		HostConfig: hostConfig,
+	}, nil
+}
+
 func (c *Component) newContainerConfig() (*container.Config, error) {
+	if err := c.checkHostname(); err != nil {
+		return nil, err
+	}
+
 	entrypoint, err := convert.ToStrSlice(c.Entrypoint)
 	if err != nil {
 		return nil, err
@@ -77,23 +98,23 @@ func (c *Component) newContainerConfig() (*container.Config, error) {
 		return nil, err
 	}
 
-	envFromVariables, err := convert.ToStringToStringMap(c.Environment)
+	envFromVariables, err := c.getEnvironment()
 	if err != nil {
 		return nil, err
 	}
 
-	labels, err := convert.ToStringToStringMap(c.Labels)
+	labels, err := c.getLabels()
 	if err != nil {
 		return nil, err
 	}
 
 	containerConfig := container.Config{
-		Image:      c.Image,
+		Image:      c.getImage(),
 		Entrypoint: entrypoint,
 		Cmd:        command,
 		WorkingDir: c.WorkingDir,
 		Env:        mergeEnvVariables(envFromFiles, envFromVariables),
-		Labels:     labels,
+		Labels:     c.addIdentityLabels(c.addImageLabels(labels)),
 		OpenStdin:  c.StdinOpen,
 		Tty:        c.Tty,
 		StopSignal: c.StopSignal,
@@ -186,7 +207,8 @@ func (c *Component) newHostConfig(configuration *config.Configuration) (*contain
 	}
 
 	hostConfig := container.HostConfig{
-		AutoRemove: true,
+		// keep the container until the files are copied out of it
+		AutoRemove: !c.needsExportAfterExit(),
 
 		Resources: resources,
 
@@ -196,6 +218,7 @@ func (c *Component) newHostConfig(configuration *config.Configuration) (*contain
 		Privileged:     c.Privileged,
 		ReadonlyRootfs: c.ReadOnly,
 		Runtime:        c.Runtime,
+		Init:           c.Init,
 
 		OomScoreAdj: c.getOomScoreAdjust(),
 
@@ -217,12 +240,22 @@ func (c *Component) newHostConfig(configuration *config.Configuration) (*contain
 	}
 
 	if c.Volumes != nil {
-		mounts, err := c.getMounts()
+		mounts, binds, err := c.getMounts()
 		if err != nil {
 			return nil, err
 		}
 
 		hostConfig.Mounts = mounts
+		hostConfig.Binds = binds
+	}
+
+	if c.Secrets != nil || c.Configs != nil {
+		mounts, err := c.getSecretMounts()
+		if err != nil {
+			return nil, err
+		}
+
+		hostConfig.Mounts = append(hostConfig.Mounts, mounts...)
 	}
 
 	if c.ShmSize != nil {
@@ -285,7 +318,19 @@ func (c *Component) newHostConfig(configuration *config.Configuration) (*contain
 		hostConfig.IpcMode = container.IpcMode("container:" + c.client.GetContainerID())
 	}
 
-	if configuration.ShareVolumes {
+	if proxyMount := c.client.GetDockerProxyMount(c.Name); proxyMount != nil {
+		hostConfig.Mounts = append(hostConfig.Mounts, *proxyMount)
+	}
+
+	if c.VolumesFrom != nil {
+		mounts, err := c.getMountsFrom(&hostConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		hostConfig.Mounts = append(hostConfig.Mounts, mounts...)
+
+	} else if configuration.ShareVolumes {
 		hostConfig.VolumesFrom = []string{c.client.GetContainerID()}
 	}
 
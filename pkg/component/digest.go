@@ -0,0 +1,208 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+const (
+	imageDigestLabel    = "com.github.rycus86.podlike.image.digest"
+	imageReferenceLabel = "com.github.rycus86.podlike.image.reference"
+)
+
+// pinImage resolves the image of the component to a digest,
+// and verifies the digest the image is pinned to, if any.
+func (c *Component) pinImage(configuration *config.Configuration) error {
+	named, err := reference.ParseNormalizedNamed(c.Image)
+	if err != nil {
+		return errors.New(fmt.Sprintf("invalid image reference for %s : %s", c.Name, err))
+	}
+
+	var resolved string
+
+	if canonical, ok := named.(reference.Canonical); ok {
+		resolved = canonical.Digest().String()
+
+		if tagged, ok := named.(reference.Tagged); ok {
+			if err := c.verifyPinnedTag(named, tagged.Tag(), resolved, configuration); err != nil {
+				return err
+			}
+		}
+
+	} else if configuration.PinImages == config.PinImagesRequire {
+		return c.notPinnedError()
+
+	} else {
+		resolved, err = c.resolveDigest(reference.TagNameOnly(named).String(), named.Name(), configuration)
+		if err != nil {
+			return errors.New(fmt.Sprintf("failed to resolve the digest of %s for %s : %s", c.Image, c.Name, err))
+		}
+	}
+
+	if err := c.setPinnedImage(named, resolved); err != nil {
+		return err
+	}
+
+	fmt.Println("Resolved image for", c.Name, ":", c.Image, "->", c.pinnedImage)
+
+	return nil
+}
+
+// previewPinnedImage pins the image of the component for a dry run, without contacting the engine
+// or the registry, and returns false if the image is not pinned to a digest yet, so it isn't resolved.
+func (c *Component) previewPinnedImage(configuration *config.Configuration) (bool, error) {
+	named, err := reference.ParseNormalizedNamed(c.Image)
+	if err != nil {
+		return false, errors.New(fmt.Sprintf("invalid image reference for %s : %s", c.Name, err))
+	}
+
+	canonical, ok := named.(reference.Canonical)
+	if !ok {
+		if configuration.PinImages == config.PinImagesRequire {
+			return false, c.notPinnedError()
+		}
+
+		return false, nil
+	}
+
+	return true, c.setPinnedImage(named, canonical.Digest().String())
+}
+
+func (c *Component) setPinnedImage(named reference.Named, resolved string) error {
+	pinned, err := reference.WithDigest(reference.TrimNamed(named), digest.Digest(resolved))
+	if err != nil {
+		return errors.New(fmt.Sprintf("invalid digest for %s : %s", c.Image, err))
+	}
+
+	c.imageDigest = resolved
+	c.pinnedImage = pinned.String()
+
+	return nil
+}
+
+func (c *Component) notPinnedError() error {
+	return errors.New(fmt.Sprintf(
+		"the image of the %s component is not pinned to a digest: %s (expected %s@sha256:...)",
+		c.Name, c.Image, c.Image))
+}
+
+// verifyPinnedTag checks that the tag of an image like `name:tag@sha256:...`
+// still points to the digest it is pinned to in the registry,
+// or in the local images when the image is never pulled.
+func (c *Component) verifyPinnedTag(named reference.Named, tag, pinned string, configuration *config.Configuration) error {
+	tagged, err := reference.WithTag(reference.TrimNamed(named), tag)
+	if err != nil {
+		return err
+	}
+
+	if c.usesLocalImageOnly(configuration) {
+		digests, err := c.getLocalDigests(tagged.String(), named.Name())
+		if err != nil {
+			return errors.New(fmt.Sprintf("failed to verify the digest of %s for %s : %s", c.Image, c.Name, err))
+		}
+
+		for _, local := range digests {
+			if local == pinned {
+				return nil
+			}
+		}
+
+		return errors.New(fmt.Sprintf(
+			"the image of the %s component does not match its pinned digest: the local %s image has %v instead of %s",
+			c.Name, tagged.String(), digests, pinned))
+	}
+
+	remote, err := c.engine.GetRemoteImageDigest(tagged.String())
+	if err != nil {
+		return errors.New(fmt.Sprintf("failed to verify the digest of %s for %s : %s", c.Image, c.Name, err))
+	}
+
+	if remote != pinned {
+		return errors.New(fmt.Sprintf(
+			"the image of the %s component does not match its pinned digest: %s points to %s instead of %s",
+			c.Name, tagged.String(), remote, pinned))
+	}
+
+	return nil
+}
+
+// resolveDigest asks the registry for the digest of the image, so the replicas on different nodes
+// resolve it to the same digest, regardless of the local images they have.
+// The local images are only used when the image is never pulled, or when the registry is not available.
+func (c *Component) resolveDigest(image, name string, configuration *config.Configuration) (string, error) {
+	if !c.usesLocalImageOnly(configuration) {
+		remote, err := c.engine.GetRemoteImageDigest(image)
+		if err == nil {
+			return remote, nil
+		}
+
+		if digests, localErr := c.getLocalDigests(image, name); localErr == nil && len(digests) > 0 {
+			fmt.Println("[Warning] Failed to resolve", image, "in the registry for", c.Name, ", using the local image:", err)
+			return digests[0], nil
+		}
+
+		return "", err
+	}
+
+	digests, err := c.getLocalDigests(image, name)
+	if err != nil {
+		return "", err
+	}
+
+	if len(digests) == 0 {
+		return "", errors.New(fmt.Sprintf("no local image with a digest found for %s", image))
+	}
+
+	return digests[0], nil
+}
+
+// getLocalDigests returns the digests of the local image for the repository name given.
+func (c *Component) getLocalDigests(image, name string) ([]string, error) {
+	repoDigests, err := c.engine.GetLocalImageDigests(image)
+	if err != nil {
+		return nil, err
+	}
+
+	var digests []string
+
+	for _, repoDigest := range repoDigests {
+		parsed, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil {
+			continue
+		}
+
+		if canonical, ok := parsed.(reference.Canonical); ok && parsed.Name() == name {
+			digests = append(digests, canonical.Digest().String())
+		}
+	}
+
+	return digests, nil
+}
+
+// getImage returns the image reference to use for the container.
+func (c *Component) getImage() string {
+	if c.pinnedImage != "" {
+		return c.pinnedImage
+	}
+
+	return c.Image
+}
+
+func (c *Component) addImageLabels(labels map[string]string) map[string]string {
+	if c.imageDigest == "" {
+		return labels
+	}
+
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	labels[imageDigestLabel] = c.imageDigest
+	labels[imageReferenceLabel] = c.Image
+
+	return labels
+}
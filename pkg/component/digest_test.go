@@ -0,0 +1,216 @@
+package component
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+const (
+	digestV1 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	digestV2 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func TestPinImage_ResolveLocal(t *testing.T) {
+	c, engine := newMockComponent("app", nil)
+	c.Image = "sample/app:1.0"
+	c.PullPolicy = PullNever
+
+	engine.localDigests = map[string][]string{
+		"docker.io/sample/app:1.0": {"other/app@" + digestV2, "sample/app@" + digestV1},
+	}
+	engine.remoteDigests = map[string]string{
+		"docker.io/sample/app:1.0": digestV2,
+	}
+
+	// the registry is not used for images that are never pulled
+	if err := c.pinImage(&config.Configuration{PinImages: config.PinImagesResolve}); err != nil {
+		t.Fatal("Failed to pin the image:", err)
+	}
+
+	if c.getImage() != "docker.io/sample/app@"+digestV1 {
+		t.Error("Unexpected image:", c.getImage())
+	}
+
+	containerConfig, err := c.newContainerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if containerConfig.Image != c.getImage() {
+		t.Error("Unexpected container image:", containerConfig.Image)
+	}
+
+	if containerConfig.Labels[imageDigestLabel] != digestV1 ||
+		containerConfig.Labels[imageReferenceLabel] != "sample/app:1.0" {
+
+		t.Error("Unexpected labels:", containerConfig.Labels)
+	}
+}
+
+func TestPinImage_ResolveRemote(t *testing.T) {
+	c, engine := newMockComponent("app", nil)
+	c.Image = "localhost:5000/app"
+
+	engine.localDigests = map[string][]string{
+		"localhost:5000/app:latest": {"localhost:5000/app@" + digestV1},
+	}
+	engine.remoteDigests = map[string]string{
+		"localhost:5000/app:latest": digestV2,
+	}
+
+	// the registry takes precedence over the local images, so all replicas get the same digest
+	if err := c.pinImage(&config.Configuration{PinImages: config.PinImagesResolve}); err != nil {
+		t.Fatal("Failed to pin the image:", err)
+	}
+
+	if c.getImage() != "localhost:5000/app@"+digestV2 {
+		t.Error("Unexpected image:", c.getImage())
+	}
+
+	// the local image is used when the registry doesn't know about the image
+	engine.remoteDigests = nil
+
+	if err := c.pinImage(&config.Configuration{PinImages: config.PinImagesResolve}); err != nil {
+		t.Fatal("Failed to pin the image:", err)
+	}
+
+	if c.getImage() != "localhost:5000/app@"+digestV1 {
+		t.Error("Unexpected image:", c.getImage())
+	}
+
+	c, _ = newMockComponent("app", nil)
+	c.Image = "localhost:5000/missing"
+
+	if err := c.pinImage(&config.Configuration{PinImages: config.PinImagesResolve}); err == nil {
+		t.Error("Expected to fail for an unknown image")
+	}
+
+	c.PullPolicy = PullNever
+
+	if err := c.pinImage(&config.Configuration{PinImages: config.PinImagesResolve}); err == nil {
+		t.Error("Expected to fail for a missing local image")
+	}
+}
+
+func TestPinImage_Require(t *testing.T) {
+	c, engine := newMockComponent("app", nil)
+	engine.remoteDigests = map[string]string{
+		"docker.io/sample/app:1.0": digestV2,
+	}
+
+	require := &config.Configuration{PinImages: config.PinImagesRequire}
+
+	c.Image = "sample/app:1.0"
+	if err := c.pinImage(require); err == nil || !strings.Contains(err.Error(), "not pinned") {
+		t.Error("Expected to fail for an image without a digest:", err)
+	}
+
+	c.Image = "sample/app@" + digestV1
+	if err := c.pinImage(require); err != nil {
+		t.Error("Failed to pin the image:", err)
+	} else if c.imageDigest != digestV1 {
+		t.Error("Unexpected digest:", c.imageDigest)
+	}
+
+	c.Image = "sample/app:1.0@" + digestV2
+	if err := c.pinImage(require); err != nil {
+		t.Error("Failed to verify the image:", err)
+	}
+
+	c.Image = "sample/app:1.0@" + digestV1
+	if err := c.pinImage(require); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Error("Expected to fail for a mismatching digest:", err)
+	}
+
+	// a matching local image does not override the registry
+	engine.localDigests = map[string][]string{
+		"docker.io/sample/app:1.0": {"sample/app@" + digestV1},
+	}
+
+	if err := c.pinImage(require); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Error("Expected to fail for a mismatching digest:", err)
+	}
+
+	// but it is accepted for images that are never pulled
+	c.PullPolicy = PullNever
+
+	if err := c.pinImage(require); err != nil {
+		t.Error("Failed to verify the image:", err)
+	}
+
+	c.Image = "sample/app:1.0@" + digestV2
+	if err := c.pinImage(require); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Error("Expected to fail for a mismatching local digest:", err)
+	}
+}
+
+func TestPinImage_Status(t *testing.T) {
+	c, engine := newMockComponent("app", nil)
+	c.Image = "sample/app:1.0"
+
+	if status := c.Status(); status != "app: container c0002, image sample/app:1.0" {
+		t.Error("Unexpected status:", status)
+	}
+
+	engine.remoteDigests = map[string]string{
+		"docker.io/sample/app:1.0": digestV1,
+	}
+
+	if err := c.pinImage(&config.Configuration{PinImages: config.PinImagesResolve}); err != nil {
+		t.Fatal("Failed to pin the image:", err)
+	}
+
+	if status := c.Status(); status != "app: container c0002, image docker.io/sample/app@"+digestV1+" (pinned from sample/app:1.0)" {
+		t.Error("Unexpected status:", status)
+	}
+
+	c.container = nil
+
+	if status := c.Status(); !strings.HasPrefix(status, "app: not created,") {
+		t.Error("Unexpected status:", status)
+	}
+}
+
+func TestPinImage_DryRun(t *testing.T) {
+	configuration := &config.Configuration{PinImages: config.PinImagesResolve}
+
+	c, _ := newMockComponent("app", nil)
+	c.Image = "sample/app:1.0"
+
+	request, err := c.GetCreateRequest(configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !request.UnresolvedImage || request.Config.Image != "sample/app:1.0" {
+		t.Error("Expected an unresolved image:", request.Config.Image)
+	}
+
+	pinned, _ := newMockComponent("pinned", nil)
+	pinned.Image = "sample/app:1.0@" + digestV1
+
+	request, err = pinned.GetCreateRequest(configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.UnresolvedImage || request.Config.Image != "docker.io/sample/app@"+digestV1 {
+		t.Error("Unexpected image:", request.Config.Image)
+	}
+
+	if request.Config.Labels[imageDigestLabel] != digestV1 {
+		t.Error("Unexpected labels:", request.Config.Labels)
+	}
+
+	if _, err := c.GetCreateRequest(&config.Configuration{PinImages: config.PinImagesRequire}); err == nil ||
+		!strings.Contains(err.Error(), "is not pinned to a digest") {
+
+		t.Error("Expected to fail without a digest:", err)
+	}
+
+	if request, err := c.GetCreateRequest(&config.Configuration{}); err != nil || request.UnresolvedImage {
+		t.Error("Unexpected request without image pinning:", request, err)
+	}
+}
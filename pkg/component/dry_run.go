@@ -0,0 +1,68 @@
+package component
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+// GetCreateRequest returns the name and the configuration of the container
+// of the component, exactly as creating it would send it to the engine,
+// but without resolving or pulling its image. With image pinning, the images
+// that are not pinned to a digest already are marked as unresolved.
+func (c *Component) GetCreateRequest(configuration *config.Configuration) (*CreateRequest, error) {
+	if configuration.Policy != nil {
+		if err := c.checkPolicy(configuration); err != nil {
+			return nil, err
+		}
+	}
+
+	var unresolved bool
+
+	if configuration.PinImages != "" {
+		pinned, err := c.previewPinnedImage(configuration)
+		if err != nil {
+			return nil, err
+		}
+
+		unresolved = !pinned
+	}
+
+	request, err := c.newCreateRequest(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	request.UnresolvedImage = unresolved
+
+	return request, nil
+}
+
+// PrintCreateRequests writes the create requests of the init and the regular components
+// as a JSON array, without creating anything.
+func PrintCreateRequests(w io.Writer, initComponents, components []*Component, configuration *config.Configuration) error {
+	requests := make([]*CreateRequest, 0, len(initComponents)+len(components))
+
+	for idx, comp := range append(initComponents, components...) {
+		request, err := comp.GetCreateRequest(configuration)
+		if err != nil {
+			return errors.New(fmt.Sprintf("failed to prepare the container of %s : %s", comp.Name, err))
+		}
+
+		request.Component = comp.Name
+		request.Init = idx < len(initComponents)
+
+		requests = append(requests, request)
+	}
+
+	output, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(output))
+	return err
+}
@@ -4,72 +4,165 @@ import (
 	"errors"
 	"fmt"
 	"github.com/rycus86/podlike/pkg/convert"
-	"io/ioutil"
+	"github.com/rycus86/podlike/pkg/interpolation"
+	"os"
 	"strings"
 )
 
+type envFileReference struct {
+	Path     string
+	Required bool
+}
+
 func variablesFromEnvFiles(files interface{}) (map[string]string, error) {
 	variables := map[string]string{}
 
-	if files == nil {
-		return variables, nil
+	references, err := parseEnvFileReferences(files)
+	if err != nil {
+		return nil, err
 	}
 
-	if path, ok := files.(string); ok {
-		if fromFile, err := fromEnvFile(path); err != nil {
+	for _, reference := range references {
+		if _, err := os.Stat(reference.Path); os.IsNotExist(err) && !reference.Required {
+			continue
+		}
+
+		fromFile, err := interpolation.LoadEnvFile(reference.Path)
+		if err != nil {
 			return nil, err
-		} else {
-			for key, value := range fromFile {
-				variables[key] = value
-			}
 		}
+
+		for key, value := range fromFile {
+			variables[key] = value
+		}
+	}
+
+	return variables, nil
+}
+
+// parseEnvFileReferences parses the env files from either a single path,
+// or a list of paths and mappings with a path and an optional required flag:
+//
+//	env_file:
+//	  - ./common.env
+//	  - path: ./override.env
+//	    required: false
+func parseEnvFileReferences(files interface{}) ([]envFileReference, error) {
+	if files == nil {
+		return nil, nil
 	}
 
-	if list, ok := files.([]interface{}); ok {
-		for _, file := range list {
-			if path, ok := file.(string); ok {
-				if fromFile, err := fromEnvFile(path); err != nil {
-					return nil, err
-				} else {
-					for key, value := range fromFile {
-						variables[key] = value
+	if path, ok := files.(string); ok {
+		return []envFileReference{{Path: path, Required: true}}, nil
+	}
+
+	list, ok := files.([]interface{})
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unexpected env file(s): %+v (%T)", files, files))
+	}
+
+	references := make([]envFileReference, 0, len(list))
+
+	for _, file := range list {
+		switch item := file.(type) {
+		case string:
+			references = append(references, envFileReference{Path: item, Required: true})
+
+		case map[interface{}]interface{}:
+			reference := envFileReference{Required: true}
+
+			for key, value := range item {
+				switch key {
+				case "path":
+					path, ok := value.(string)
+					if !ok || path == "" {
+						return nil, errors.New(fmt.Sprintf("wrong env file path: %+v (%T)", value, value))
 					}
+
+					reference.Path = path
+
+				case "required":
+					required, ok := value.(bool)
+					if !ok {
+						return nil, errors.New(fmt.Sprintf("wrong env file required flag: %+v (%T)", value, value))
+					}
+
+					reference.Required = required
+
+				default:
+					return nil, errors.New(fmt.Sprintf("unexpected env file property: %+v", key))
+
 				}
-			} else {
-				return nil, errors.New(fmt.Sprintf("wrong env file path: %+v (%T)", file, file))
 			}
-		}
 
-		return variables, nil
+			if reference.Path == "" {
+				return nil, errors.New(fmt.Sprintf("missing env file path: %+v", item))
+			}
+
+			references = append(references, reference)
+
+		default:
+			return nil, errors.New(fmt.Sprintf("wrong env file path: %+v (%T)", file, file))
+
+		}
 	}
 
-	return nil, errors.New(fmt.Sprintf("unexpected env file(s): %+v (%T)", files, files))
+	return references, nil
 }
 
-func fromEnvFile(path string) (map[string]string, error) {
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, err
+// getEnvironment returns the environment variables of the component.
+// Variables without a value inherit it from the controller, like with Compose,
+// or they are left out, if the controller doesn't have them either.
+func (c *Component) getEnvironment() (map[string]string, error) {
+	if c.Environment == nil {
+		return nil, nil
 	}
 
 	variables := map[string]string{}
 
-	for _, line := range strings.Split(string(data), "\n") {
-		if line == "" {
-			continue
+	inherit := func(key string) {
+		if value, ok := os.LookupEnv(key); ok {
+			variables[key] = value
 		}
+	}
 
-		if strings.HasPrefix(line, "#") {
-			continue
+	switch environment := c.Environment.(type) {
+	case []interface{}:
+		for _, item := range environment {
+			definition, ok := item.(string)
+			if !ok {
+				return nil, errors.New(fmt.Sprintf("not a string item: %+v (%T)", item, item))
+			}
+
+			if parts := strings.SplitN(definition, "=", 2); len(parts) == 2 {
+				variables[parts[0]] = parts[1]
+			} else {
+				inherit(definition)
+			}
 		}
 
-		parts := strings.SplitN(line, "=", 2)
+	case map[interface{}]interface{}:
+		for key, value := range environment {
+			name, ok := key.(string)
+			if !ok {
+				return nil, errors.New(fmt.Sprintf("not a string key: %+v (%T)", key, key))
+			}
 
-		if len(parts) == 2 {
-			variables[parts[0]] = parts[1]
-		} else {
-			// TODO is this an error with Compose?
+			switch typed := value.(type) {
+			case nil:
+				inherit(name)
+			case string:
+				variables[name] = typed
+			case int, int64, float64, bool:
+				variables[name] = fmt.Sprintf("%v", typed)
+			default:
+				return nil, errors.New(fmt.Sprintf("not a string value: %+v (%T)", value, value))
+			}
 		}
+
+	default:
+		return nil, errors.New(fmt.Sprintf("unexpected environment: %+v (%T)", c.Environment, c.Environment))
+
 	}
 
 	return variables, nil
@@ -0,0 +1,106 @@
+package component
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnvFiles_LongSyntax(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	first := filepath.Join(dir, "first.env")
+	second := filepath.Join(dir, "second.env")
+
+	ioutil.WriteFile(first, []byte("export SHARED=first\r\nQUOTED=\"a b\" # comment\r\n"), 0644)
+	ioutil.WriteFile(second, []byte("SHARED=second\nREFERENCE=${QUOTED}-x\n"), 0644)
+
+	variables, err := variablesFromEnvFiles([]interface{}{
+		first,
+		map[interface{}]interface{}{"path": second},
+		map[interface{}]interface{}{"path": filepath.Join(dir, "missing.env"), "required": false},
+	})
+
+	if err != nil {
+		t.Fatal("Failed to read the env files:", err)
+	}
+
+	expected := map[string]string{
+		"SHARED":    "second",
+		"QUOTED":    "a b",
+		"REFERENCE": "-x",
+	}
+
+	if !reflect.DeepEqual(variables, expected) {
+		t.Error("Unexpected variables:", variables)
+	}
+
+	for _, files := range []interface{}{
+		filepath.Join(dir, "missing.env"),
+		[]interface{}{map[interface{}]interface{}{"path": filepath.Join(dir, "missing.env")}},
+		[]interface{}{map[interface{}]interface{}{"required": false}},
+		[]interface{}{map[interface{}]interface{}{"path": first, "optional": true}},
+		[]interface{}{map[interface{}]interface{}{"path": first, "required": "no"}},
+		[]interface{}{42},
+	} {
+		if _, err := variablesFromEnvFiles(files); err == nil {
+			t.Error("Expected to fail:", files)
+		}
+	}
+
+	ioutil.WriteFile(first, []byte("INVALID LINE\n"), 0644)
+
+	if _, err := variablesFromEnvFiles(first); err == nil {
+		t.Error("Expected to fail for an invalid line")
+	}
+}
+
+func TestEnvironment_Inherited(t *testing.T) {
+	os.Setenv("PODLIKE_TEST_INHERITED", "from-controller")
+	defer os.Unsetenv("PODLIKE_TEST_INHERITED")
+
+	for _, environment := range []interface{}{
+		[]interface{}{"STATIC=x", "EMPTY=", "PODLIKE_TEST_INHERITED", "PODLIKE_TEST_MISSING"},
+		map[interface{}]interface{}{
+			"STATIC":                 "x",
+			"EMPTY":                  "",
+			"PODLIKE_TEST_INHERITED": nil,
+			"PODLIKE_TEST_MISSING":   nil,
+		},
+	} {
+		c := Component{Environment: environment}
+
+		variables, err := c.getEnvironment()
+		if err != nil {
+			t.Fatal("Failed to get the environment:", err)
+		}
+
+		expected := map[string]string{
+			"STATIC":                 "x",
+			"EMPTY":                  "",
+			"PODLIKE_TEST_INHERITED": "from-controller",
+		}
+
+		if !reflect.DeepEqual(variables, expected) {
+			t.Error("Unexpected environment:", variables)
+		}
+	}
+
+	c := Component{Environment: map[interface{}]interface{}{"PORT": 8080, "DEBUG": true}}
+
+	if variables, err := c.getEnvironment(); err != nil || variables["PORT"] != "8080" || variables["DEBUG"] != "true" {
+		t.Error("Unexpected environment:", variables, err)
+	}
+
+	c = Component{Environment: map[interface{}]interface{}{"LIST": []interface{}{"a"}}}
+
+	if _, err := c.getEnvironment(); err == nil {
+		t.Error("Expected to fail")
+	}
+}
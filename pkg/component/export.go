@@ -0,0 +1,399 @@
+package component
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rycus86/podlike/pkg/api"
+	"github.com/rycus86/podlike/pkg/healthcheck"
+)
+
+const (
+	ConditionStarted = "started"
+	ConditionHealthy = "healthy"
+	ConditionExited  = "exited"
+)
+
+type exportedFiles struct {
+	ready chan struct{}
+	once  sync.Once
+
+	data []byte
+	err  error
+}
+
+type exports struct {
+	lock  sync.Mutex
+	items map[string]*exportedFiles
+}
+
+// NewExports returns an empty store for the files copied out of the components of a pod.
+func NewExports() api.Exports {
+	return &exports{items: map[string]*exportedFiles{}}
+}
+
+func (e *exports) get(component, sourcePath string) *exportedFiles {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	key := component + ":" + sourcePath
+
+	if existing, ok := e.items[key]; ok {
+		return existing
+	}
+
+	created := &exportedFiles{ready: make(chan struct{})}
+	e.items[key] = created
+
+	return created
+}
+
+// Resolve stores the tar archive of the files exported, or the error
+// if they couldn't be, unless it was already resolved before.
+func (e *exports) Resolve(component, sourcePath string, data []byte, err error) {
+	exported := e.get(component, sourcePath)
+
+	exported.once.Do(func() {
+		exported.data = data
+		exported.err = err
+
+		close(exported.ready)
+	})
+}
+
+func (e *exports) IsResolved(component, sourcePath string) bool {
+	select {
+	case <-e.get(component, sourcePath).ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// Wait blocks until the files are exported from the source component,
+// and returns the tar archive of them.
+func (e *exports) Wait(component, sourcePath string) ([]byte, error) {
+	exported := e.get(component, sourcePath)
+
+	<-exported.ready
+
+	return exported.data, exported.err
+}
+
+func parseCopySource(from string) (string, string, error) {
+	parts := strings.SplitN(from, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || !path.IsAbs(parts[1]) {
+		return "", "", errors.New(fmt.Sprintf(
+			"invalid copy source, expected component:/absolute/path : %s", from))
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// getExports returns the copy configurations from all components
+// that need files from this component.
+func (c *Component) getExports() []CopyConfig {
+	var found []CopyConfig
+
+	for key, value := range c.client.GetLabels() {
+		if !strings.HasPrefix(key, "pod.copy.") && key != "pod.controller.copy" {
+			continue
+		}
+
+		configs, err := parseCopyConfig(value)
+		if err != nil {
+			// the target component will report it
+			continue
+		}
+
+		for _, config := range configs {
+			if config.From == "" {
+				continue
+			}
+
+			if name, _, err := parseCopySource(config.From); err == nil && name == c.Name {
+				found = append(found, config)
+			}
+		}
+	}
+
+	return found
+}
+
+func (c *Component) needsExportAfterExit() bool {
+	for _, config := range c.getExports() {
+		if config.Condition == ConditionExited {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Component) startExporting() {
+	var needsHealthyState bool
+
+	for _, config := range c.getExports() {
+		if config.Condition == ConditionHealthy {
+			needsHealthyState = true
+		}
+	}
+
+	c.exportFiles(ConditionStarted)
+
+	if needsHealthyState {
+		go func() {
+			healthcheck.WaitUntilReady(c.Name, true)
+			c.exportFiles(ConditionHealthy)
+		}()
+	}
+}
+
+func (c *Component) exportFiles(condition string) {
+	for _, config := range c.getExports() {
+		if config.Condition != condition {
+			continue
+		}
+
+		_, sourcePath, _ := parseCopySource(config.From)
+
+		exports := c.client.GetExports()
+
+		if exports.IsResolved(c.Name, sourcePath) {
+			continue // already done
+		}
+
+		fmt.Println("Copying", sourcePath, "from", c.Name, "...")
+
+		reader, err := c.engine.CopyFromContainer(c.container.ID, sourcePath)
+		if err != nil {
+			exports.Resolve(c.Name, sourcePath, nil, err)
+			continue
+		}
+
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+
+		exports.Resolve(c.Name, sourcePath, data, err)
+	}
+}
+
+// failExports releases the components waiting for files
+// that this component can no longer provide.
+func (c *Component) failExports(reason string) {
+	for _, config := range c.getExports() {
+		_, sourcePath, _ := parseCopySource(config.From)
+
+		c.client.GetExports().Resolve(c.Name, sourcePath, nil, errors.New(fmt.Sprintf(
+			"failed to copy %s : %s", config.From, reason)))
+	}
+}
+
+// waitForCopySource returns a tar stream of the files from the source component,
+// with the root item renamed to the filename given.
+func waitForCopySource(config CopyConfig, filename string, exports api.Exports) (io.Reader, error) {
+	name, sourcePath, err := parseCopySource(config.From)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Waiting for", name, "to be", config.Condition, "to copy", sourcePath, "...")
+
+	data, err := exports.Wait(name, sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return retargetTar(data, path.Base(sourcePath), filename, &config)
+}
+
+func retargetTar(data []byte, sourceName, targetName string, config *CopyConfig) (io.Reader, error) {
+	var (
+		buffer bytes.Buffer
+
+		tr = tar.NewReader(bytes.NewReader(data))
+		tw = tar.NewWriter(&buffer)
+	)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(hdr.Name, sourceName)
+
+		if name == "" && targetName == "" && hdr.Typeflag != tar.TypeDir {
+			// a single file copied into a target directory keeps its name
+			name = sourceName
+		} else {
+			name = strings.TrimPrefix(targetName+name, "/")
+		}
+
+		if name == "" {
+			// the root directory when copying its contents only
+			continue
+		}
+
+		hdr.Name = name
+
+		if config.Mode != nil && hdr.Typeflag == tar.TypeReg {
+			hdr.Mode = int64(*config.Mode)
+		}
+
+		if config.Owner != nil {
+			hdr.Uid = config.Owner.UID
+			hdr.Gid = config.Owner.GID
+			hdr.Uname = ""
+			hdr.Gname = ""
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+
+		if _, err := io.Copy(tw, tr); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &buffer, nil
+}
+
+// CopyToController copies the files from components into the controller,
+// as configured in the `pod.controller.copy` label, once they become available.
+func CopyToController(definition string, exports api.Exports) error {
+	configs, err := parseCopyConfig(definition)
+	if err != nil {
+		return err
+	}
+
+	for _, config := range configs {
+		if config.From == "" {
+			return errors.New(fmt.Sprintf(
+				"invalid pod.controller.copy configuration, a source component is required: %s", config))
+		}
+	}
+
+	for _, config := range configs {
+		targetDir, targetFilename := path.Split(config.Target)
+
+		reader, err := waitForCopySource(config, targetFilename, exports)
+		if err != nil {
+			return err
+		}
+
+		if err := extractTar(reader, targetDir, config.Owner); err != nil {
+			return err
+		}
+
+		fmt.Println("Copied", config.From, "to the controller @", config.Target)
+	}
+
+	return nil
+}
+
+func extractTar(reader io.Reader, targetDir string, owner *CopyOwner) error {
+	tr := tar.NewReader(reader)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		target := filepath.Join(targetDir, hdr.Name)
+		if isOutside(targetDir, target) {
+			return errors.New(fmt.Sprintf("invalid path in the copied files: %s", hdr.Name))
+		}
+
+		// writing through a symlink copied earlier could escape the target directory
+		if err := checkNoSymlinks(targetDir, target); err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode).Perm()); err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			// only leading .. elements are allowed, the parents of the link are checked not to be symlinks
+			if filepath.IsAbs(hdr.Linkname) || filepath.Clean(hdr.Linkname) != hdr.Linkname ||
+				isOutside(targetDir, filepath.Join(filepath.Dir(target), hdr.Linkname)) {
+
+				return errors.New(fmt.Sprintf("invalid symlink in the copied files: %s -> %s", hdr.Name, hdr.Linkname))
+			}
+
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(file, tr)
+			file.Close()
+
+			if err != nil {
+				return err
+			}
+
+		default:
+			fmt.Println("[Warning] Skipping copying", hdr.Name, "as it is not a regular file")
+			continue
+		}
+
+		if owner != nil {
+			if err := os.Lchown(target, owner.UID, owner.GID); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// isOutside returns true if the target path is not within the base directory.
+func isOutside(base, target string) bool {
+	relative, err := filepath.Rel(base, target)
+
+	return err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+// checkNoSymlinks verifies that neither the target, nor its parents within the base directory are symlinks.
+func checkNoSymlinks(base, target string) error {
+	for current := target; current != filepath.Clean(base) && !isOutside(base, current); current = filepath.Dir(current) {
+		fi, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return errors.New(fmt.Sprintf("refusing to write through the symlink in the copied files: %s", current))
+		}
+	}
+
+	return nil
+}
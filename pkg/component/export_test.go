@@ -0,0 +1,292 @@
+package component
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExport_ParseCopySources(t *testing.T) {
+	parsed := parseAndVerify(t, `
+pod.copy.test: |
+  - from: init-1:/out/cert.pem
+    target: /etc/ssl/cert.pem
+    when: exited
+  - from: app:/var/generated
+    target: /etc/generated
+    owner: 1000:1000
+`)
+
+	if len(parsed) != 2 {
+		t.Fatal("Unexpected configurations:", parsed)
+	}
+
+	if parsed[0].From != "init-1:/out/cert.pem" || parsed[0].Condition != ConditionExited ||
+		parsed[0].Target != "/etc/ssl/cert.pem" || parsed[0].Source != "" {
+
+		t.Error("Unexpected configuration:", parsed[0])
+	}
+
+	if parsed[1].From != "app:/var/generated" || parsed[1].Condition != ConditionStarted ||
+		parsed[1].Owner == nil || parsed[1].Owner.UID != 1000 {
+
+		t.Error("Unexpected configuration:", parsed[1])
+	}
+
+	for _, invalid := range []string{
+		"[{from: init-1, target: /dst}]",
+		"[{from: 'init-1:relative/path', target: /dst}]",
+		"[{from: 'init-1:/src', target: /dst, when: stopped}]",
+		"[{from: 'init-1:/src', source: /src, target: /dst}]",
+		"[{from: 'init-1:/src'}]",
+		"[{from: 'init-1:/src', target: /dst, render: true}]",
+		"[{from: 'init-1:/src', target: /dst, watch: true}]",
+		"[{source: /src, target: /dst, when: exited}]",
+		"{/src: {from: 'init-1:/src', target: /dst}}",
+	} {
+		if _, err := parseCopyConfig(invalid); err == nil {
+			t.Error("Expected to fail:", invalid)
+		}
+	}
+}
+
+func TestExport_CopyBetweenComponents(t *testing.T) {
+	labels := map[string]string{
+		"pod.copy.app": `
+- from: init-1:/out/certs
+  target: /etc/ssl/app
+  mode: 0600
+  when: exited
+- from: init-1:/out/config.yml
+  target: /etc/app/
+  when: exited`,
+	}
+
+	source, sourceEngine := newMockComponent("init-1", labels)
+	sourceEngine.exported = map[string][]byte{
+		"/out/certs": createTestTar(t,
+			"certs/", "",
+			"certs/cert.pem", "certificate",
+			"certs/key.pem", "key"),
+		"/out/config.yml": createTestTar(t,
+			"config.yml", "key: value"),
+	}
+
+	if !source.needsExportAfterExit() {
+		t.Error("The source component should not be removed automatically")
+	}
+
+	target, targetEngine := newMockComponent("app", labels)
+	target.client = source.client
+
+	if target.needsExportAfterExit() {
+		t.Error("The target component does not need to be kept after exit")
+	}
+
+	source.exportFiles(ConditionStarted)
+	source.exportFiles(ConditionExited)
+
+	if err := target.copyFilesIfNecessary(); err != nil {
+		t.Fatal("Failed to copy the files:", err)
+	}
+
+	copied := targetEngine.getCopied()
+	sort.Strings(copied)
+
+	if strings.Join(copied, ",") != "/etc/app/config.yml=key: value,"+
+		"/etc/ssl/app/=,/etc/ssl/app/cert.pem=certificate,/etc/ssl/app/key.pem=key" {
+
+		t.Error("Unexpected files copied:", copied)
+	}
+
+	if err := source.RemoveExited(); err != nil || !reflect.DeepEqual(sourceEngine.removed, []string{"c0002"}) {
+		t.Error("Expected to remove the exited source component:", sourceEngine.removed, err)
+	}
+
+	if err := target.RemoveExited(); err != nil || len(targetEngine.removed) > 0 {
+		t.Error("Expected to leave the removal to the engine:", targetEngine.removed, err)
+	}
+}
+
+func TestExport_FailedSource(t *testing.T) {
+	labels := map[string]string{
+		"pod.copy.app": "[{from: 'init-2:/out/missing', target: /etc/missing, when: exited}]",
+	}
+
+	source, _ := newMockComponent("init-2", labels)
+	source.failExports("the component has exited with an error")
+
+	target, _ := newMockComponent("app", labels)
+	target.client = source.client
+
+	if err := target.copyFilesIfNecessary(); err == nil || !strings.Contains(err.Error(), "exited with an error") {
+		t.Error("Expected to fail:", err)
+	}
+}
+
+func TestExport_CopyToController(t *testing.T) {
+	labels := map[string]string{
+		"pod.controller.copy": "[{from: 'generator:/out/generated', target: /unused, when: healthy}]",
+	}
+
+	source, sourceEngine := newMockComponent("generator", labels)
+	sourceEngine.exported = map[string][]byte{
+		"/out/generated": createTestTar(t,
+			"generated/", "",
+			"generated/sub/", "",
+			"generated/sub/file.txt", "contents"),
+	}
+
+	source.exportFiles(ConditionHealthy)
+
+	dir, err := ioutil.TempDir("", "podlike-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	definition := "[{from: 'generator:/out/generated', target: '" + dir + "/', when: healthy}]"
+
+	if err := CopyToController(definition, source.client.GetExports()); err != nil {
+		t.Fatal("Failed to copy to the controller:", err)
+	}
+
+	if contents, err := ioutil.ReadFile(filepath.Join(dir, "sub", "file.txt")); err != nil || string(contents) != "contents" {
+		t.Error("Unexpected contents:", string(contents), err)
+	}
+
+	if err := CopyToController("/src:/dst", source.client.GetExports()); err == nil {
+		t.Error("Expected to fail without a source component")
+	}
+
+	if err := extractTar(bytes.NewReader(createTestTar(t, "../escape", "x")), dir, nil); err == nil {
+		t.Error("Expected to fail on paths outside the target directory")
+	}
+}
+
+func TestExport_MaliciousTar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "target")
+	outside := filepath.Join(dir, "outside")
+
+	os.Mkdir(target, 0755)
+	os.Mkdir(outside, 0755)
+
+	symlinkTar := func(name, link string, nextFile string) []byte {
+		var buffer bytes.Buffer
+
+		tw := tar.NewWriter(&buffer)
+		tw.WriteHeader(&tar.Header{Name: name, Linkname: link, Typeflag: tar.TypeSymlink, Mode: 0777})
+
+		if nextFile != "" {
+			tw.WriteHeader(&tar.Header{Name: nextFile, Typeflag: tar.TypeReg, Mode: 0644, Size: 1})
+			tw.Write([]byte("x"))
+		}
+
+		tw.Close()
+
+		return buffer.Bytes()
+	}
+
+	for _, data := range [][]byte{
+		symlinkTar("absolute", outside, ""),
+		symlinkTar("relative", "../outside", ""),
+		symlinkTar("nested/../../escape", "inside", ""),
+	} {
+		if err := extractTar(bytes.NewReader(data), target, nil); err == nil {
+			t.Error("Expected to fail on the symlink escaping the target directory")
+		}
+	}
+
+	// a symlink already in the target directory, like from an earlier copy
+	if err := os.Symlink(outside, filepath.Join(target, "existing")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := extractTar(bytes.NewReader(symlinkTar("through", "existing/../other", "")), target, nil); err == nil {
+		t.Error("Expected to fail on the symlink through an existing one")
+	}
+
+	for _, data := range [][]byte{
+		createTestTar(t, "existing/file.txt", "x"),
+		createTestTar(t, "existing/sub/", ""),
+		createTestTar(t, "existing", "x"),
+	} {
+		if err := extractTar(bytes.NewReader(data), target, nil); err == nil {
+			t.Error("Expected to fail on writing through a symlink")
+		}
+	}
+
+	if entries, _ := ioutil.ReadDir(outside); len(entries) > 0 {
+		t.Error("Unexpected files written outside of the target directory:", entries)
+	}
+
+	// symlinks within the target directory are fine
+	if err := extractTar(bytes.NewReader(symlinkTar("sibling", "other", "other")), target, nil); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func TestExport_RetargetTar(t *testing.T) {
+	data := createTestTar(t, "source.txt", "text")
+
+	for target, expected := range map[string][]string{
+		"renamed.txt": {"renamed.txt"},
+		"":            {"source.txt"},
+	} {
+		reader, err := retargetTar(data, "source.txt", target, &CopyConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+
+		tr := tar.NewReader(reader)
+		for hdr, err := tr.Next(); err == nil; hdr, err = tr.Next() {
+			names = append(names, hdr.Name)
+		}
+
+		if !reflect.DeepEqual(names, expected) {
+			t.Error("Unexpected names:", names, "expected:", expected)
+		}
+	}
+}
+
+func createTestTar(t *testing.T, namesAndContents ...string) []byte {
+	var buffer bytes.Buffer
+
+	tw := tar.NewWriter(&buffer)
+
+	for idx := 0; idx < len(namesAndContents); idx += 2 {
+		name, contents := namesAndContents[idx], namesAndContents[idx+1]
+
+		hdr := tar.Header{Name: name, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(name, "/") {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+		}
+
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+
+		tw.Write([]byte(contents))
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
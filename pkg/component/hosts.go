@@ -0,0 +1,117 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ExtraHost is an `extra_hosts` entry of a component,
+// added to the hosts file shared by the components in the pod.
+type ExtraHost struct {
+	Name    string
+	Address string
+}
+
+func (h ExtraHost) String() string {
+	return h.Name + ":" + h.Address
+}
+
+var hostnameRe = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// EnableLenientMode reports the settings that can't be applied
+// to the component in the pod as warnings, instead of failing on them.
+func (c *Component) EnableLenientMode() {
+	c.lenient = true
+}
+
+// IsLenient returns true if the settings that can't be applied are only warnings.
+func (c *Component) IsLenient() bool {
+	return c.lenient
+}
+
+// GetExtraHosts parses the `extra_hosts` entries of the component,
+// either from a list of `hostname:IP` items, or from a mapping of hostnames to IPs.
+func (c *Component) GetExtraHosts() ([]ExtraHost, error) {
+	var hosts []ExtraHost
+
+	switch value := c.ExtraHosts.(type) {
+	case nil:
+		return nil, nil
+
+	case []interface{}:
+		for _, item := range value {
+			entry, ok := item.(string)
+			if !ok {
+				return nil, errors.New(fmt.Sprintf("not a string item: %+v (%T)", item, item))
+			}
+
+			// IPv6 addresses have colons in them, the hostname doesn't
+			parts := strings.SplitN(entry, ":", 2)
+			if len(parts) != 2 {
+				return nil, errors.New(fmt.Sprintf("invalid extra host, expected hostname:IP : %s", entry))
+			}
+
+			hosts = append(hosts, ExtraHost{Name: strings.TrimSpace(parts[0]), Address: strings.TrimSpace(parts[1])})
+		}
+
+	case map[interface{}]interface{}:
+		for key, address := range value {
+			hosts = append(hosts, ExtraHost{Name: fmt.Sprintf("%v", key), Address: fmt.Sprintf("%v", address)})
+		}
+
+		sort.SliceStable(hosts, func(i, j int) bool {
+			return hosts[i].Name < hosts[j].Name
+		})
+
+	default:
+		return nil, errors.New(fmt.Sprintf("unexpected extra hosts: %+v (%T)", value, value))
+
+	}
+
+	for idx, host := range hosts {
+		if !hostnameRe.MatchString(host.Name) {
+			return nil, errors.New(fmt.Sprintf("invalid hostname in the extra host %s", host))
+		}
+
+		// IPv6 addresses can be in brackets too
+		hosts[idx].Address = strings.Trim(host.Address, "[]")
+
+		if net.ParseIP(hosts[idx].Address) == nil {
+			return nil, errors.New(fmt.Sprintf("invalid IP address in the extra host %s", host))
+		}
+	}
+
+	return hosts, nil
+}
+
+// checkHostname verifies that the hostname of the component, if set, matches the controller's,
+// because the components share the network namespace, and so the UTS namespace, of the controller.
+func (c *Component) checkHostname() error {
+	if c.Hostname == "" {
+		return nil
+	}
+
+	if !hostnameRe.MatchString(c.Hostname) {
+		return errors.New(fmt.Sprintf("invalid hostname: %s", c.Hostname))
+	}
+
+	controllerHostname := c.client.GetHostname()
+	if controllerHostname == "" || c.Hostname == controllerHostname {
+		return nil
+	}
+
+	if c.lenient {
+		logWarning(
+			"The hostname of the", c.Name, "component is", c.Hostname, "but it's going to be",
+			controllerHostname, "because of the controller")
+
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf(
+		"the hostname %s does not match the hostname of the controller: %s", c.Hostname, controllerHostname))
+}
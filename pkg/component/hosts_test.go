@@ -0,0 +1,103 @@
+package component
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/rycus86/podlike/pkg/config"
+	"gopkg.in/yaml.v2"
+)
+
+func TestExtraHosts_Parse(t *testing.T) {
+	for _, definition := range []string{
+		"extra_hosts: ['db:10.0.0.5', 'ipv6:fe80::1']",
+		"extra_hosts: {db: 10.0.0.5, ipv6: '[fe80::1]'}",
+	} {
+		var comp Component
+
+		if err := yaml.UnmarshalStrict([]byte(definition), &comp); err != nil {
+			t.Fatal(err)
+		}
+
+		hosts, err := comp.GetExtraHosts()
+		if err != nil {
+			t.Fatal("Failed to parse the extra hosts:", err)
+		}
+
+		expected := []ExtraHost{
+			{Name: "db", Address: "10.0.0.5"},
+			{Name: "ipv6", Address: "fe80::1"},
+		}
+
+		if !reflect.DeepEqual(hosts, expected) {
+			t.Error("Unexpected extra hosts:", hosts)
+		}
+	}
+
+	for _, invalid := range []interface{}{
+		[]interface{}{"db"},
+		[]interface{}{"db:not-an-ip"},
+		[]interface{}{"-db-:10.0.0.5"},
+		[]interface{}{42},
+		"db:10.0.0.5",
+	} {
+		comp := Component{ExtraHosts: invalid}
+
+		if _, err := comp.GetExtraHosts(); err == nil {
+			t.Error("Expected to fail for", invalid)
+		}
+	}
+}
+
+func TestHostname_MatchesController(t *testing.T) {
+	comp := Component{Image: "sample", Hostname: "mock-host"}
+	comp.Initialize("test", &mockController{}, nil)
+
+	if problems := comp.Validate(&config.Configuration{}); len(problems) > 0 {
+		t.Error("Unexpected problems:", problems)
+	}
+
+	comp.Hostname = "other-host"
+
+	if _, err := comp.newContainerConfig(); err == nil {
+		t.Error("Expected to fail on a different hostname")
+	}
+
+	comp.EnableLenientMode()
+
+	containerConfig, err := comp.newContainerConfig()
+	if err != nil {
+		t.Fatal("Expected only a warning in lenient mode:", err)
+	}
+
+	if containerConfig.Hostname != "" {
+		t.Error("The hostname should be left to the controller:", containerConfig.Hostname)
+	}
+
+	comp.Hostname = "invalid_hostname"
+
+	if _, err := comp.newContainerConfig(); err == nil {
+		t.Error("Expected to fail on an invalid hostname")
+	}
+}
+
+func TestInit_HostConfig(t *testing.T) {
+	enabled := true
+
+	comp := Component{Image: "sample", Init: &enabled}
+	comp.Initialize("test", &mockController{}, nil)
+
+	hostConfig, err := comp.newHostConfig(&config.Configuration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hostConfig.Init == nil || !*hostConfig.Init {
+		t.Error("Expected the init process to be enabled:", hostConfig.Init)
+	}
+
+	if hostConfig.NetworkMode != container.NetworkMode("container:c0001") {
+		t.Error("Unexpected network mode:", hostConfig.NetworkMode)
+	}
+}
@@ -0,0 +1,88 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rycus86/podlike/pkg/convert"
+	"github.com/rycus86/podlike/pkg/version"
+)
+
+const (
+	// the label namespace the controller sets on the containers of the components
+	reservedLabelPrefix = "com.github.rycus86.podlike."
+
+	// ControllerLabel is the ID of the controller container that owns the component,
+	// the same label the pod volumes have
+	ControllerLabel = "com.github.rycus86.podlike.controller"
+	// ControllerNameLabel is the name of the controller container
+	ControllerNameLabel = "com.github.rycus86.podlike.controller.name"
+	// ComponentLabel is the name of the component
+	ComponentLabel = "com.github.rycus86.podlike.component"
+	// ComponentRoleLabel is either RoleInit or RoleComponent
+	ComponentRoleLabel = "com.github.rycus86.podlike.component.role"
+	// VersionLabel is the version of the controller that created the component
+	VersionLabel = "com.github.rycus86.podlike.version"
+
+	RoleInit      = "init"
+	RoleComponent = "component"
+)
+
+// the labels copied from the controller, to find the components by the Swarm service or task
+var copiedLabelPrefixes = []string{
+	"com.docker.swarm.",
+	"com.docker.stack.namespace",
+}
+
+// MarkAsInitComponent sets the role of the component to be an init component.
+func (c *Component) MarkAsInitComponent() {
+	c.initComponent = true
+}
+
+// getLabels returns the labels of the component definition,
+// that must not use the label namespace reserved for the controller.
+func (c *Component) getLabels() (map[string]string, error) {
+	labels, err := convert.ToStringToStringMap(c.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range labels {
+		if strings.HasPrefix(key, reservedLabelPrefix) {
+			return nil, errors.New(fmt.Sprintf(
+				"the %s label is in the namespace reserved for the controller: %s*", key, reservedLabelPrefix))
+		}
+	}
+
+	return labels, nil
+}
+
+// addIdentityLabels adds the labels identifying the pod, and the Swarm service and task
+// of the controller, so the containers of the components can be filtered by them.
+func (c *Component) addIdentityLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	for key, value := range c.client.GetLabels() {
+		for _, prefix := range copiedLabelPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				labels[key] = value
+			}
+		}
+	}
+
+	role := RoleComponent
+	if c.initComponent {
+		role = RoleInit
+	}
+
+	labels[ControllerLabel] = c.client.GetContainerID()
+	labels[ControllerNameLabel] = strings.TrimPrefix(c.client.GetContainerName(), "/")
+	labels[ComponentLabel] = c.Name
+	labels[ComponentRoleLabel] = role
+	labels[VersionLabel] = version.Parse().Tag
+
+	return labels
+}
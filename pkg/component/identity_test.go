@@ -0,0 +1,69 @@
+package component
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+func TestIdentityLabels(t *testing.T) {
+	controller := &mockController{labels: map[string]string{
+		"com.docker.swarm.service.name": "stack_app",
+		"com.docker.swarm.task.id":      "t0001",
+		"com.docker.stack.namespace":    "stack",
+		"pod.component.app":             "image: sample",
+	}}
+
+	comp := Component{Image: "sample", Labels: map[interface{}]interface{}{"custom": "value"}}
+	comp.Initialize("app", controller, nil)
+
+	containerConfig, err := comp.newContainerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"custom":                        "value",
+		"com.docker.swarm.service.name": "stack_app",
+		"com.docker.swarm.task.id":      "t0001",
+		"com.docker.stack.namespace":    "stack",
+		ControllerLabel:                 "c0001",
+		ControllerNameLabel:             "mock-controller",
+		ComponentLabel:                  "app",
+		ComponentRoleLabel:              RoleComponent,
+		VersionLabel:                    "dev",
+	}
+
+	if !reflect.DeepEqual(containerConfig.Labels, expected) {
+		t.Error("Unexpected labels:", containerConfig.Labels)
+	}
+
+	initComp := Component{Image: "sample"}
+	initComp.Initialize("init-1", controller, nil)
+	initComp.MarkAsInitComponent()
+
+	containerConfig, err = initComp.newContainerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if role := containerConfig.Labels[ComponentRoleLabel]; role != RoleInit {
+		t.Error("Unexpected role:", role)
+	}
+}
+
+func TestIdentityLabels_Reserved(t *testing.T) {
+	comp := Component{Image: "sample", Labels: []interface{}{ComponentLabel + "=other"}}
+	comp.Initialize("app", &mockController{}, nil)
+
+	if _, err := comp.newContainerConfig(); err == nil {
+		t.Error("Expected to fail on a reserved label")
+	}
+
+	problems := comp.Validate(&config.Configuration{})
+
+	if len(problems) != 1 || problems[0].Field != "labels" {
+		t.Error("Unexpected problems:", problems)
+	}
+}
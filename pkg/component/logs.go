@@ -2,39 +2,177 @@ package component
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
+	"time"
+
+	"github.com/rycus86/podlike/pkg/config"
 )
 
-func (c *Component) streamLogs() {
+type logLine struct {
+	Stream string
+	Text   string
+}
+
+type logRecord struct {
+	Time      time.Time `json:"time"`
+	Component string    `json:"component"`
+	Stream    string    `json:"stream"`
+	Message   string    `json:"message"`
+}
+
+func (c *Component) streamLogs(configuration *config.Configuration) {
 	if reader, err := c.engine.StreamLogs(c.container.ID); err == nil {
 		defer reader.Close()
 
+		multiline, err := c.getMultilineConfig()
+		if err != nil {
+			fmt.Println("Invalid multiline configuration for", c.Name, ":", err)
+			multiline = nil
+		}
+
 		fmt.Println("Streaming logs for", c.Name)
 
-		bufReader := bufio.NewReader(reader)
-		defer reader.Close()
+		lines := make(chan logLine)
 
-		for {
-			out, _, err := bufReader.ReadLine()
-			if err != nil {
-				if err != io.EOF {
-					fmt.Println("Stopped streaming logs for", c.Name, ":", err)
-				}
+		go readLogLines(reader, lines, c.Name, multiline != nil)
+
+		emit := func(stream string, lines []string) {
+			printLogRecord(configuration.LogFormat, c.Name, stream, lines)
+		}
+
+		if multiline != nil {
+			groupLogLines(lines, multiline, emit)
+		} else {
+			for line := range lines {
+				emit(line.Stream, []string{line.Text})
+			}
+		}
+	}
+}
+
+// readLogLines reads the multiplexed log stream line by line, trimming the whitespace around them,
+// or only after them when grouping multi-line records, as their indentation marks the continuation lines.
+func readLogLines(reader io.Reader, lines chan<- logLine, name string, keepIndentation bool) {
+	defer close(lines)
+
+	bufReader := bufio.NewReader(reader)
+
+	for {
+		out, _, err := bufReader.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				fmt.Println("Stopped streaming logs for", name, ":", err)
+			}
+			return
+		}
+
+		if len(out) < 8 {
+			continue
+		}
+
+		streamType := "out"
+		if out[0] == 2 {
+			streamType = "err"
+		}
+
+		text := string(out[8:])
+
+		if keepIndentation {
+			text = strings.TrimRight(text, " \t\r\n")
+		} else {
+			text = strings.TrimSpace(text)
+		}
+
+		lines <- logLine{
+			Stream: streamType,
+			Text:   text,
+		}
+	}
+}
+
+// groupLogLines collects continuation lines into a single record per stream,
+// and emits them when a new record starts, or when no new lines arrived within
+// the flush timeout.
+func groupLogLines(lines <-chan logLine, multiline *MultilineConfig, emit func(stream string, lines []string)) {
+	var (
+		pending = map[string][]string{}
+		order   []string
+
+		timer = time.NewTimer(multiline.Timeout)
+	)
+
+	defer timer.Stop()
+
+	flush := func(stream string) {
+		if group, ok := pending[stream]; ok && len(group) > 0 {
+			emit(stream, group)
+		}
+
+		delete(pending, stream)
+	}
+
+	flushAll := func() {
+		for _, stream := range order {
+			flush(stream)
+		}
+
+		order = nil
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flushAll()
 				return
 			}
 
-			if len(out) < 8 {
-				continue
+			group, exists := pending[line.Stream]
+
+			if exists && len(group) < multiline.MaxLines && multiline.isContinuation(group, line.Text) {
+				pending[line.Stream] = append(group, line.Text)
+			} else {
+				flush(line.Stream)
+
+				if !exists {
+					order = append(order, line.Stream)
+				}
+
+				pending[line.Stream] = []string{line.Text}
 			}
 
-			streamType := "out"
-			if out[0] == 2 {
-				streamType = "err"
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
 			}
+			timer.Reset(multiline.Timeout)
 
-			fmt.Printf("[%s] %s: %s\n", streamType, c.Name, strings.TrimSpace(string(out[8:])))
+		case <-timer.C:
+			flushAll()
+
+			timer.Reset(multiline.Timeout)
+		}
+	}
+}
+
+func printLogRecord(format, name, stream string, lines []string) {
+	if format == config.LogFormatJSON {
+		data, err := json.Marshal(logRecord{
+			Time:      time.Now().UTC(),
+			Component: name,
+			Stream:    stream,
+			Message:   strings.Join(lines, "\n"),
+		})
+		if err == nil {
+			fmt.Println(string(data))
+			return
 		}
 	}
+
+	fmt.Printf("[%s] %s: %s\n", stream, name, strings.Join(lines, "\n    "))
 }
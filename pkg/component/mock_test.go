@@ -0,0 +1,220 @@
+package component
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/rycus86/podlike/pkg/api"
+)
+
+type mockController struct {
+	api.Controller
+
+	labels map[string]string
+
+	exports     api.Exports
+	exportsInit sync.Once
+}
+
+func (m *mockController) GetContainerID() string {
+	return "c0001"
+}
+
+func (m *mockController) GetContainerName() string {
+	return "/mock-controller"
+}
+
+func (m *mockController) GetHostname() string {
+	return "mock-host"
+}
+
+func (m *mockController) GetCgroup() string {
+	return "/docker/c0001"
+}
+
+func (m *mockController) GetLabels() map[string]string {
+	return m.labels
+}
+
+func (m *mockController) GetHostConfig() *container.HostConfig {
+	return &container.HostConfig{}
+}
+
+func (m *mockController) GetSharedVolume(source string) (string, string) {
+	return "", ""
+}
+
+func (m *mockController) GetDockerProxyMount(name string) *mount.Mount {
+	return nil
+}
+
+func (m *mockController) GetExports() api.Exports {
+	m.exportsInit.Do(func() {
+		m.exports = NewExports()
+	})
+
+	return m.exports
+}
+
+func (m *mockController) GetMountsFrom(definition string) ([]mount.Mount, error) {
+	return []mount.Mount{
+		{Type: mount.TypeVolume, Source: "shared", Target: "/data"},
+		{Type: mount.TypeBind, Source: "/etc/" + definition, Target: "/etc/" + definition},
+	}, nil
+}
+
+type mockEngine struct {
+	api.Engine
+
+	lock sync.Mutex
+
+	copied   []string
+	signals  []string
+	executed [][]string
+	removed  []string
+
+	// the files copied before the container was started
+	copiedBeforeStart []string
+
+	// tar archives to return for paths copied out of the container
+	exported map[string][]byte
+
+	// image digests by image reference
+	localDigests  map[string][]string
+	remoteDigests map[string]string
+
+	// local images, and the outputs of the pulls in order
+	images map[string]bool
+	pulls  []string
+	pulled []string
+}
+
+func (m *mockEngine) CopyToContainer(containerID string, destPath string, content io.Reader) error {
+	tr := tar.NewReader(content)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		contents, _ := ioutil.ReadAll(tr)
+
+		m.lock.Lock()
+		m.copied = append(m.copied, destPath+hdr.Name+"="+string(contents))
+		m.lock.Unlock()
+	}
+}
+
+func (m *mockEngine) CreateContainer(
+	containerConfig *container.Config, hostConfig *container.HostConfig, name string) (container.ContainerCreateCreatedBody, error) {
+
+	return container.ContainerCreateCreatedBody{ID: "c0002"}, nil
+}
+
+func (m *mockEngine) InspectContainer(containerID string) (*types.ContainerJSON, error) {
+	return &types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{ID: containerID}, Config: &container.Config{}}, nil
+}
+
+func (m *mockEngine) StartContainer(containerID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.copiedBeforeStart = append([]string{}, m.copied...)
+	return nil
+}
+
+func (m *mockEngine) RemoveContainer(containerID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.removed = append(m.removed, containerID)
+	return nil
+}
+
+func (m *mockEngine) CopyFromContainer(containerID string, srcPath string) (io.ReadCloser, error) {
+	if data, ok := m.exported[srcPath]; ok {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	return nil, errors.New("no such file: " + srcPath)
+}
+
+func (m *mockEngine) SignalContainer(containerID string, signal string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.signals = append(m.signals, signal)
+	return nil
+}
+
+func (m *mockEngine) ExecInContainer(containerID string, command []string) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.executed = append(m.executed, command)
+	return 0, nil
+}
+
+func (m *mockEngine) GetLocalImageDigests(image string) ([]string, error) {
+	return m.localDigests[image], nil
+}
+
+func (m *mockEngine) GetRemoteImageDigest(image string) (string, error) {
+	if digest, ok := m.remoteDigests[image]; ok {
+		return digest, nil
+	}
+
+	return "", errors.New("manifest unknown: " + image)
+}
+
+func (m *mockEngine) HasImage(image string) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.images[image], nil
+}
+
+func (m *mockEngine) PullImage(reference string) (io.ReadCloser, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.pulled = append(m.pulled, reference)
+
+	if len(m.pulls) == 0 {
+		return nil, errors.New("no more pulls expected")
+	}
+
+	output := m.pulls[0]
+	m.pulls = m.pulls[1:]
+
+	return ioutil.NopCloser(strings.NewReader(output)), nil
+}
+
+func (m *mockEngine) getCopied() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]string{}, m.copied...)
+}
+
+func newMockComponent(name string, labels map[string]string) (*Component, *mockEngine) {
+	engine := &mockEngine{}
+
+	return &Component{
+		Name:      name,
+		client:    &mockController{labels: labels},
+		engine:    engine,
+		container: &types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{ID: "c0002"}},
+	}, engine
+}
@@ -0,0 +1,139 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultMultilineTimeout  = 1 * time.Second
+	defaultMultilineMaxLines = 500
+)
+
+var (
+	indentedLine = regexp.MustCompile(`^\s+\S`)
+
+	javaContinuation = regexp.MustCompile(`^(\s+|Caused by:|Suppressed:)`)
+
+	pythonChainedCause = regexp.MustCompile(
+		`^(During handling of the above exception|The above exception was the direct cause)`)
+)
+
+type MultilineConfig struct {
+	Preset   string
+	Pattern  string
+	Timeout  time.Duration
+	MaxLines int `yaml:"max_lines"`
+
+	isContinuation func(group []string, line string) bool
+}
+
+func (c *Component) getMultilineConfig() (*MultilineConfig, error) {
+	if definition, ok := c.client.GetLabels()["pod.multiline."+c.Name]; ok {
+		return parseMultilineConfig(definition)
+	}
+
+	return nil, nil
+}
+
+func parseMultilineConfig(definition string) (*MultilineConfig, error) {
+	var value interface{}
+
+	if err := yaml.Unmarshal([]byte(definition), &value); err != nil {
+		return nil, err
+	}
+
+	var multiline MultilineConfig
+
+	// a preset name, like:
+	//  pod.multiline.app: java
+	if preset, ok := value.(string); ok {
+		multiline.Preset = strings.TrimSpace(preset)
+
+	} else if _, isMap := value.(map[interface{}]interface{}); isMap {
+		// a mapping, like:
+		//  pod.multiline.app: |
+		//    pattern: '^\d{4}-\d{2}-\d{2}'
+		//    timeout: 2s
+		if err := yaml.UnmarshalStrict([]byte(definition), &multiline); err != nil {
+			return nil, err
+		}
+
+	} else {
+		return nil, errors.New(fmt.Sprintf("invalid pod.multiline configuration: %s (%T)", value, value))
+	}
+
+	if multiline.Timeout <= 0 {
+		multiline.Timeout = defaultMultilineTimeout
+	}
+
+	if multiline.MaxLines <= 0 {
+		multiline.MaxLines = defaultMultilineMaxLines
+	}
+
+	if multiline.Pattern != "" {
+		if multiline.Preset != "" {
+			return nil, errors.New(fmt.Sprintf(
+				"invalid pod.multiline configuration: both a preset (%s) and a pattern (%s) is given",
+				multiline.Preset, multiline.Pattern))
+		}
+
+		startOfRecord, err := regexp.Compile(multiline.Pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		multiline.isContinuation = func(group []string, line string) bool {
+			return !startOfRecord.MatchString(line)
+		}
+
+		return &multiline, nil
+	}
+
+	switch multiline.Preset {
+	case "java":
+		multiline.isContinuation = isJavaContinuation
+	case "python":
+		multiline.isContinuation = isPythonContinuation
+	case "go":
+		multiline.isContinuation = isGoContinuation
+	default:
+		return nil, errors.New(fmt.Sprintf("invalid pod.multiline preset: %s", multiline.Preset))
+	}
+
+	return &multiline, nil
+}
+
+func isJavaContinuation(group []string, line string) bool {
+	return javaContinuation.MatchString(line)
+}
+
+func isPythonContinuation(group []string, line string) bool {
+	if indentedLine.MatchString(line) || pythonChainedCause.MatchString(line) {
+		return true
+	}
+
+	last := group[len(group)-1]
+
+	if line == "" || strings.HasPrefix(line, "Traceback (most recent call last):") {
+		// blank lines and tracebacks following a chained cause message
+		return pythonChainedCause.MatchString(last) || last == ""
+	}
+
+	// the exception type and message closes the traceback
+	return strings.HasPrefix(group[0], "Traceback (most recent call last):") && indentedLine.MatchString(last)
+}
+
+func isGoContinuation(group []string, line string) bool {
+	if strings.HasPrefix(group[0], "panic: ") || strings.HasPrefix(group[0], "fatal error: ") {
+		// the rest of the output is the stack trace of the goroutines
+		return true
+	}
+
+	return indentedLine.MatchString(line)
+}
@@ -0,0 +1,207 @@
+package component
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultiline_JavaPreset(t *testing.T) {
+	verifyGroupedLines(t, "java", []string{
+		"Starting application",
+		"Exception in thread \"main\" java.lang.IllegalStateException: failed",
+		"\tat com.example.App.start(App.java:12)",
+		"\tat com.example.App.main(App.java:5)",
+		"Caused by: java.io.IOException: not found",
+		"\tat com.example.Config.load(Config.java:40)",
+		"\t... 2 more",
+		"Shutting down",
+	},
+		"Starting application",
+		"Exception in thread \"main\" java.lang.IllegalStateException: failed|"+
+			"\tat com.example.App.start(App.java:12)|"+
+			"\tat com.example.App.main(App.java:5)|"+
+			"Caused by: java.io.IOException: not found|"+
+			"\tat com.example.Config.load(Config.java:40)|"+
+			"\t... 2 more",
+		"Shutting down")
+}
+
+func TestMultiline_PythonPreset(t *testing.T) {
+	verifyGroupedLines(t, "python", []string{
+		"INFO: processing",
+		"Traceback (most recent call last):",
+		"  File \"app.py\", line 3, in <module>",
+		"    main()",
+		"ValueError: invalid value",
+		"INFO: done",
+	},
+		"INFO: processing",
+		"Traceback (most recent call last):|"+
+			"  File \"app.py\", line 3, in <module>|"+
+			"    main()|"+
+			"ValueError: invalid value",
+		"INFO: done")
+}
+
+func TestMultiline_GoPreset(t *testing.T) {
+	verifyGroupedLines(t, "go", []string{
+		"starting",
+		"panic: runtime error: index out of range",
+		"",
+		"goroutine 1 [running]:",
+		"main.main()",
+		"\t/go/src/app/main.go:8 +0x1d",
+	},
+		"starting",
+		"panic: runtime error: index out of range||"+
+			"goroutine 1 [running]:|"+
+			"main.main()|"+
+			"\t/go/src/app/main.go:8 +0x1d")
+}
+
+func TestMultiline_Pattern(t *testing.T) {
+	verifyGroupedLines(t, `
+pattern: '^\d{4}-\d{2}-\d{2} '
+timeout: 2s`, []string{
+		"2018-06-01 10:00:00 first",
+		"continued",
+		"2018-06-01 10:00:01 second",
+	},
+		"2018-06-01 10:00:00 first|continued",
+		"2018-06-01 10:00:01 second")
+}
+
+func TestMultiline_MaxLines(t *testing.T) {
+	verifyGroupedLines(t, `{preset: java, max_lines: 2}`, []string{
+		"Exception",
+		"\tat one",
+		"\tat two",
+	},
+		"Exception|\tat one",
+		"\tat two")
+}
+
+func TestMultiline_SeparateStreams(t *testing.T) {
+	multiline, err := parseMultilineConfig("java")
+	if err != nil {
+		t.Fatal("Failed to parse:", err)
+	}
+
+	lines := make(chan logLine, 4)
+	lines <- logLine{Stream: "err", Text: "Exception"}
+	lines <- logLine{Stream: "out", Text: "regular output"}
+	lines <- logLine{Stream: "err", Text: "\tat trace"}
+	close(lines)
+
+	var records []string
+
+	groupLogLines(lines, multiline, func(stream string, lines []string) {
+		records = append(records, stream+":"+strings.Join(lines, "|"))
+	})
+
+	if len(records) != 2 || records[0] != "err:Exception|\tat trace" || records[1] != "out:regular output" {
+		t.Error("Unexpected records:", records)
+	}
+}
+
+func TestMultiline_FlushTimeout(t *testing.T) {
+	multiline, err := parseMultilineConfig("{preset: java, timeout: 50ms}")
+	if err != nil {
+		t.Fatal("Failed to parse:", err)
+	}
+
+	lines := make(chan logLine)
+	records := make(chan string, 2)
+
+	go groupLogLines(lines, multiline, func(stream string, lines []string) {
+		records <- strings.Join(lines, "|")
+	})
+
+	lines <- logLine{Stream: "err", Text: "Exception"}
+	lines <- logLine{Stream: "err", Text: "\tat trace"}
+
+	select {
+	case record := <-records:
+		if record != "Exception|\tat trace" {
+			t.Error("Unexpected record:", record)
+		}
+
+	case <-time.After(2 * time.Second):
+		t.Error("The pending record was not flushed")
+	}
+
+	close(lines)
+}
+
+func TestMultiline_InvalidConfiguration(t *testing.T) {
+	for _, definition := range []string{
+		"unknown",
+		"{preset: java, pattern: '^x'}",
+		"{pattern: '^(unclosed'}",
+		"{preset: java, unknown: field}",
+		"[java]",
+	} {
+		if _, err := parseMultilineConfig(definition); err == nil {
+			t.Error("Expected to fail:", definition)
+		}
+	}
+}
+
+func TestMultiline_Trimming(t *testing.T) {
+	var stream bytes.Buffer
+
+	for _, line := range []string{"  plain  \r", "\tat indented"} {
+		stream.Write([]byte{1, 0, 0, 0, 0, 0, 0, byte(len(line) + 1)})
+		stream.WriteString(line + "\n")
+	}
+
+	for keepIndentation, expected := range map[bool][]string{
+		false: {"plain", "at indented"},
+		true:  {"  plain", "\tat indented"},
+	} {
+		lines := make(chan logLine, 2)
+
+		readLogLines(bytes.NewReader(stream.Bytes()), lines, "test", keepIndentation)
+
+		var actual []string
+
+		for line := range lines {
+			actual = append(actual, line.Text)
+		}
+
+		if strings.Join(actual, "|") != strings.Join(expected, "|") {
+			t.Errorf("Unexpected lines with indentation kept (%v): %q", keepIndentation, actual)
+		}
+	}
+}
+
+func verifyGroupedLines(t *testing.T, definition string, input []string, expected ...string) {
+	multiline, err := parseMultilineConfig(definition)
+	if err != nil {
+		t.Fatal("Failed to parse:", definition, err)
+	}
+
+	lines := make(chan logLine, len(input))
+	for _, line := range input {
+		lines <- logLine{Stream: "out", Text: line}
+	}
+	close(lines)
+
+	var records []string
+
+	groupLogLines(lines, multiline, func(stream string, lines []string) {
+		records = append(records, strings.Join(lines, "|"))
+	})
+
+	if len(records) != len(expected) {
+		t.Fatalf("Unexpected number of records: %d != %d\n%q", len(records), len(expected), records)
+	}
+
+	for idx, record := range records {
+		if record != expected[idx] {
+			t.Errorf("Unexpected record:\n%q\n%q", record, expected[idx])
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package component
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/rycus86/podlike/pkg/config"
+	"github.com/rycus86/podlike/pkg/policy"
+	"github.com/rycus86/podlike/pkg/volume"
+	"gopkg.in/yaml.v2"
+)
+
+// the source of the `volumes_from` definitions selecting all the volumes of the controller
+const controllerVolumesSource = "controller"
+
+// CheckPolicies verifies all the components against the security policy, if there is one,
+// before their images are resolved or pulled, so no registry is contacted for a forbidden image.
+func CheckPolicies(components []*Component, configuration *config.Configuration) error {
+	if configuration.Policy == nil {
+		return nil
+	}
+
+	var messages []string
+
+	for _, c := range components {
+		if err := c.checkPolicy(configuration); err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+
+	if len(messages) > 0 {
+		return errors.New(strings.Join(messages, "\n"))
+	}
+
+	return nil
+}
+
+// checkPolicy verifies the component definition against the security policy.
+func (c *Component) checkPolicy(configuration *config.Configuration) error {
+	subject, err := c.newPolicySubject(configuration)
+	if err != nil {
+		return err
+	}
+
+	return configuration.Policy.Check(subject)
+}
+
+func (c *Component) newPolicySubject(configuration *config.Configuration) (*policy.Subject, error) {
+	// use the same property names as in the component definitions
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var properties map[string]interface{}
+	if err := yaml.Unmarshal(data, &properties); err != nil {
+		return nil, err
+	}
+
+	bindSources, err := c.getBindSources(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy.Subject{
+		Name:        c.Name,
+		Image:       c.Image,
+		Properties:  properties,
+		BindSources: bindSources,
+		CapAdd:      c.CapAdd,
+		SecurityOpt: c.SecurityOpt,
+		ReadOnly:    c.ReadOnly,
+	}, nil
+}
+
+// getBindSources returns the host paths of all the bind mounts the container of the component
+// would get, including the ones from `volumes_from`, from sharing the volumes of the controller,
+// and the socket of the Docker API proxy, not only the ones in the volumes of the definition.
+func (c *Component) getBindSources(configuration *config.Configuration) ([]string, error) {
+	hostConfig, err := c.newHostConfig(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	mounts := hostConfig.Mounts
+
+	for _, item := range hostConfig.VolumesFrom {
+		if item != c.client.GetContainerID() {
+			continue
+		}
+
+		fromController, err := c.client.GetMountsFrom(controllerVolumesSource)
+		if err != nil {
+			return nil, err
+		}
+
+		mounts = append(mounts, fromController...)
+	}
+
+	var bindSources []string
+
+	for _, mnt := range mounts {
+		if mnt.Type == mount.TypeBind {
+			bindSources = append(bindSources, mnt.Source)
+		}
+	}
+
+	for _, bind := range hostConfig.Binds {
+		v, err := volume.ParseShortSyntax(bind)
+		if err != nil {
+			return nil, err
+		}
+
+		if v.GetMountType() == mount.TypeBind {
+			bindSources = append(bindSources, v.Source)
+		}
+	}
+
+	sort.Strings(bindSources)
+
+	return bindSources, nil
+}
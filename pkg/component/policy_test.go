@@ -0,0 +1,144 @@
+package component
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/rycus86/podlike/pkg/config"
+	"github.com/rycus86/podlike/pkg/policy"
+	"gopkg.in/yaml.v2"
+)
+
+func TestPolicy_ComponentSubject(t *testing.T) {
+	var component Component
+
+	if err := yaml.UnmarshalStrict([]byte(`
+image: nginx
+privileged: true
+cap_add: [SYS_ADMIN]
+devices: [/dev/fuse]
+volumes:
+  - /var/run/docker.sock:/var/run/docker.sock
+  - data:/data
+  - type: bind
+    source: /var/log
+    target: /logs
+`), &component); err != nil {
+		t.Fatal("Failed to parse the component:", err)
+	}
+
+	component.Initialize("app", &mockController{}, nil)
+
+	subject, err := component.newPolicySubject(&config.Configuration{})
+	if err != nil {
+		t.Fatal("Failed to create the subject:", err)
+	}
+
+	if subject.Properties["privileged"] != true || subject.Properties["read_only"] != false {
+		t.Error("Unexpected properties:", subject.Properties)
+	}
+
+	if len(subject.BindSources) != 2 || subject.BindSources[0] != "/var/log" || subject.BindSources[1] != "/var/run/docker.sock" {
+		t.Error("Unexpected bind sources:", subject.BindSources)
+	}
+
+	securityPolicy := &policy.Policy{
+		Forbidden:    []string{"privileged", "devices", "tty"},
+		BindPaths:    []string{"/var/log"},
+		Capabilities: []string{},
+	}
+
+	err = component.checkPolicy(&config.Configuration{Policy: securityPolicy})
+	if err == nil {
+		t.Fatal("Expected to fail")
+	}
+
+	for _, expected := range []string{
+		"devices: forbidden",
+		"privileged: forbidden",
+		"bind mounting /var/run/docker.sock is not allowed",
+		"cap_add: SYS_ADMIN is not allowed",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q in the error:\n%s", expected, err)
+		}
+	}
+
+	if strings.Contains(err.Error(), "tty") {
+		t.Error("Unexpected violation:", err)
+	}
+}
+
+func TestPolicy_CheckedBeforeImages(t *testing.T) {
+	securityPolicy, err := policy.Parse([]byte("{registries: [registry.example.com]}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := &mockEngine{}
+
+	allowed := &Component{Image: "registry.example.com/app", PullPolicy: PullAlways}
+	allowed.Initialize("allowed", &mockController{}, engine)
+
+	forbidden := &Component{Image: "docker.io/library/nginx", PullPolicy: PullAlways}
+	forbidden.Initialize("forbidden", &mockController{}, engine)
+
+	err = CheckPolicies([]*Component{allowed, forbidden}, &config.Configuration{Policy: securityPolicy})
+
+	if err == nil {
+		t.Fatal("Expected to fail for the forbidden registry")
+	} else if !strings.Contains(err.Error(), "the forbidden component violates the security policy") {
+		t.Error("Unexpected error:", err)
+	}
+
+	if len(engine.pulled) > 0 {
+		t.Error("Unexpected pulls:", engine.pulled)
+	}
+
+	if err := CheckPolicies([]*Component{allowed, forbidden}, &config.Configuration{}); err != nil {
+		t.Error("Unexpected error without a policy:", err)
+	}
+}
+
+type mockProxyController struct {
+	mockController
+}
+
+func (m *mockProxyController) GetDockerProxyMount(name string) *mount.Mount {
+	return &mount.Mount{Type: mount.TypeBind, Source: "/var/run/podlike/" + name + ".sock", Target: "/var/run/docker.sock"}
+}
+
+func TestPolicy_FinalBindMounts(t *testing.T) {
+	securityPolicy := &policy.Policy{BindPaths: []string{"/var/log"}}
+
+	for _, item := range []struct {
+		component     Component
+		configuration config.Configuration
+		expected      string
+	}{
+		{Component{Image: "sample", VolumesFrom: []string{"controller:/data"}}, config.Configuration{},
+			"bind mounting /etc/controller:/data is not allowed"},
+		{Component{Image: "sample"}, config.Configuration{ShareVolumes: true},
+			"bind mounting /etc/controller is not allowed"},
+	} {
+		comp := item.component
+		comp.Initialize("app", &mockController{}, nil)
+
+		item.configuration.Policy = securityPolicy
+
+		if err := comp.checkPolicy(&item.configuration); err == nil {
+			t.Error("Expected to fail for", comp.VolumesFrom, item.configuration.ShareVolumes)
+		} else if !strings.Contains(err.Error(), item.expected) {
+			t.Errorf("Expected %q in the error:\n%s", item.expected, err)
+		}
+	}
+
+	comp := Component{Image: "sample"}
+	comp.Initialize("app", &mockProxyController{}, nil)
+
+	err := comp.checkPolicy(&config.Configuration{Policy: securityPolicy})
+	if err == nil || !strings.Contains(err.Error(), "bind mounting /var/run/podlike/app.sock is not allowed") {
+		t.Error("Expected the Docker API proxy socket to be checked:", err)
+	}
+}
@@ -0,0 +1,320 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// the Compose service properties that the components ignore, and the reasons for it,
+// the first group is listed in the README, as these are in the v2 Compose file format
+var ignoredProperties = map[string]string{
+	"build":          "Only pre-built images are supported",
+	"cgroup_parent":  "This is set by the controller",
+	"container_name": "This is set by the controller",
+	"dns":            "DNS management is handled by the controller",
+	"dns_opt":        "DNS management is handled by the controller",
+	"dns_search":     "DNS management is handled by the controller",
+	"domainname":     "Networking is handled by the controller",
+	"expose":         "Expose ports by publishing them on the Swarm service",
+	"extends":        "Only supported in the Compose files of the `pod.compose.file` label",
+	"external_links": "Container links are not supported",
+	"ipc":            "IPC is set by the controller",
+	"links":          "Container links are not supported, and are probably not needed",
+	"mac_address":    "Networking is handled by the controller",
+	"network_mode":   "Network mode is set by the controller",
+	"networks":       "Assign networks through the Swarm service",
+	"pid":            "PID mode is set by the controller",
+	"platform":       "Use a Swarm service constraint instead",
+	"ports":          "Expose ports by publishing them on the Swarm service",
+	"restart":        "Restart modes are not supported",
+	"scale":          "Scale by increasing the number of Swarm service replicas",
+	"volume_driver":  "Set the driver of named volumes in the long volume syntax instead",
+
+	// properties of the v3 Compose files, and the Compose specification
+	"annotations":     "Use labels instead",
+	"attach":          "Stream the logs of the components with the controller instead",
+	"cgroup":          "The cgroup is set by the controller",
+	"credential_spec": "Set it on the Swarm service instead",
+	"deploy":          "Set the deployment options on the Swarm service instead",
+	"develop":         "Development mode is not supported",
+	"gpus":            "Use devices instead",
+	"label_file":      "Set the labels in the definition instead",
+	"models":          "Models are not supported",
+	"post_start":      "Lifecycle hooks are not supported",
+	"pre_stop":        "Lifecycle hooks are not supported",
+	"profiles":        "Only supported in the Compose files of the `pod.compose.file` label",
+	"provider":        "Provider services are not supported",
+	"use_api_socket":  "Use the Docker API proxy of the controller instead",
+	"uts":             "The UTS namespace is shared with the controller",
+
+	// properties of the v1 Compose files
+	"log_driver": "Use logging instead",
+	"log_opt":    "Use logging instead",
+	"net":        "Network mode is set by the controller",
+}
+
+// the properties the components support, from the YAML keys of the fields
+var supportedProperties = getSupportedProperties()
+
+func getSupportedProperties() map[string]bool {
+	properties := map[string]bool{}
+
+	t := reflect.TypeOf(Component{})
+
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := yamlName(field)
+		if name == "-" {
+			continue
+		}
+
+		properties[name] = true
+	}
+
+	return properties
+}
+
+// yamlName returns the YAML key of the field, the same way the YAML decoder finds it.
+func yamlName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	return name
+}
+
+// PropertiesError lists the properties of a component definition
+// that are unknown, or not supported in strict mode.
+type PropertiesError struct {
+	Problems []Problem
+}
+
+func (e *PropertiesError) Error() string {
+	messages := make([]string, 0, len(e.Problems))
+
+	for _, problem := range e.Problems {
+		messages = append(messages, "  - "+problem.String())
+	}
+
+	return fmt.Sprintf("invalid properties in the component definition:\n%s", strings.Join(messages, "\n"))
+}
+
+// LoadDefinition returns the component from its parsed YAML definition,
+// after checking its properties against the Compose service properties.
+// The properties the components don't support are left out, and returned as warnings,
+// or as errors in strict mode, while unknown properties, like typos, are always errors.
+// The `x-` extension properties are left out without warnings.
+func LoadDefinition(definition interface{}, strict bool) (*Component, []Problem, error) {
+	properties, ok := definition.(map[interface{}]interface{})
+	if !ok {
+		return nil, nil, errors.New(fmt.Sprintf("unexpected component definition: %+v (%T)", definition, definition))
+	}
+
+	var (
+		supported = map[interface{}]interface{}{}
+		warnings  []Problem
+		problems  []Problem
+	)
+
+	for key, value := range properties {
+		name := fmt.Sprintf("%v", key)
+
+		if supportedProperties[name] {
+			supported[key] = value
+
+		} else if strings.HasPrefix(name, "x-") {
+			continue
+
+		} else if reason, ok := ignoredProperties[name]; ok {
+			problem := Problem{Field: name, Message: "not supported (" + reason + ")"}
+
+			if strict {
+				problems = append(problems, problem)
+			} else {
+				warnings = append(warnings, problem)
+			}
+
+		} else {
+			problems = append(problems, Problem{Field: name, Message: "unknown property" + suggestProperty(name)})
+
+		}
+	}
+
+	sortProblems(warnings)
+	sortProblems(problems)
+
+	if len(problems) > 0 {
+		return nil, warnings, &PropertiesError{Problems: problems}
+	}
+
+	contents, err := yaml.Marshal(resolveScalars(supported, reflect.TypeOf(Component{})))
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	var component Component
+
+	// the nested properties are still checked strictly
+	if err := yaml.UnmarshalStrict(contents, &component); err != nil {
+		return nil, warnings, err
+	}
+
+	return &component, warnings, nil
+}
+
+// resolveScalars re-resolves the string values as YAML scalars where the target field
+// is a boolean or a number, because the variables in the definitions, like `${SHARES}`,
+// are interpolated into strings, that the YAML decoder would not accept for these fields.
+func resolveScalars(value interface{}, target reflect.Type) interface{} {
+	for target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+
+	switch v := value.(type) {
+	case string:
+		if target == durationType {
+			return v // parsed from strings already
+		}
+
+		switch target.Kind() {
+		case reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+
+			resolved := reflect.New(target)
+			if err := yaml.Unmarshal([]byte(v), resolved.Interface()); err != nil {
+				return v // reported by the decoder
+			}
+
+			return resolved.Elem().Interface()
+		}
+
+	case map[interface{}]interface{}:
+		result := make(map[interface{}]interface{}, len(v))
+
+		for key, item := range v {
+			result[key] = resolveScalars(item, fieldType(target, fmt.Sprintf("%v", key)))
+		}
+
+		return result
+
+	case []interface{}:
+		if target.Kind() != reflect.Slice && target.Kind() != reflect.Array {
+			return v
+		}
+
+		result := make([]interface{}, len(v))
+
+		for idx, item := range v {
+			result[idx] = resolveScalars(item, target.Elem())
+		}
+
+		return result
+
+	}
+
+	return value
+}
+
+// fieldType returns the type of the mapping value for the key,
+// or the type of an empty interface if the target doesn't know the key.
+func fieldType(target reflect.Type, key string) reflect.Type {
+	switch target.Kind() {
+	case reflect.Map:
+		return target.Elem()
+
+	case reflect.Struct:
+		for idx := 0; idx < target.NumField(); idx++ {
+			field := target.Field(idx)
+
+			if field.PkgPath == "" && yamlName(field) == key {
+				return field.Type
+			}
+		}
+
+	}
+
+	return interfaceType
+}
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// suggestProperty returns a hint for the supported property
+// that is at most two edits away from the unknown one.
+func suggestProperty(name string) string {
+	var candidates []string
+
+	for property := range supportedProperties {
+		if editDistance(name, property) <= 2 {
+			candidates = append(candidates, property)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.Strings(candidates)
+
+	return ", did you mean " + strings.Join(candidates, " or ") + "?"
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+
+	return result
+}
+
+func sortProblems(problems []Problem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Field < problems[j].Field
+	})
+}
@@ -0,0 +1,181 @@
+package component
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func parseDefinition(t *testing.T, definition string) interface{} {
+	var parsed interface{}
+
+	if err := yaml.Unmarshal([]byte(definition), &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
+
+func TestLoadDefinition_Lenient(t *testing.T) {
+	definition := parseDefinition(t, `
+image: sample
+ports: [8080:8080]
+container_name: app
+use_api_socket: true
+x-notes: ignored
+healthcheck:
+  test: [CMD, true]
+`)
+
+	comp, warnings, err := LoadDefinition(definition, false)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	if comp.Image != "sample" || comp.Healthcheck == nil {
+		t.Error("Unexpected component:", comp)
+	}
+
+	expected := []Problem{
+		{Field: "container_name", Message: "not supported (This is set by the controller)"},
+		{Field: "ports", Message: "not supported (Expose ports by publishing them on the Swarm service)"},
+		{Field: "use_api_socket", Message: "not supported (Use the Docker API proxy of the controller instead)"},
+	}
+
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Error("Unexpected warnings:", warnings)
+	}
+
+	if _, _, err := LoadDefinition(definition, true); err == nil {
+		t.Error("Expected to fail in strict mode")
+	} else if invalid, ok := err.(*PropertiesError); !ok || !reflect.DeepEqual(invalid.Problems, expected) {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func TestLoadDefinition_ResolvesScalars(t *testing.T) {
+	// the interpolated variables are strings in the parsed definition
+	comp, _, err := LoadDefinition(map[interface{}]interface{}{
+		"image":             "sample",
+		"cpu_shares":        "512",
+		"cpus":              "0.5",
+		"privileged":        "true",
+		"oom_score_adj":     "-100",
+		"stop_grace_period": "1m",
+		"labels":            map[interface{}]interface{}{"version": "1.0"},
+		"blkio_config":      map[interface{}]interface{}{"weight": "300"},
+	}, true)
+
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	if comp.CPUShares != 512 || comp.CPUs != 0.5 || !comp.Privileged {
+		t.Error("Unexpected component:", comp)
+	}
+
+	if comp.OomScoreAdj == nil || *comp.OomScoreAdj != -100 {
+		t.Error("Unexpected OOM score:", comp.OomScoreAdj)
+	}
+
+	if comp.StopGracePeriod != time.Minute {
+		t.Error("Unexpected stop grace period:", comp.StopGracePeriod)
+	}
+
+	if comp.BlkioConfig == nil || comp.BlkioConfig.Weight != 300 {
+		t.Error("Unexpected blkio config:", comp.BlkioConfig)
+	}
+
+	if labels, ok := comp.Labels.(map[interface{}]interface{}); !ok || labels["version"] != "1.0" {
+		t.Error("Unexpected labels:", comp.Labels)
+	}
+
+	if _, _, err := LoadDefinition(map[interface{}]interface{}{"cpu_shares": "many"}, true); err == nil {
+		t.Error("Expected to fail on an invalid number")
+	}
+}
+
+func TestLoadDefinition_Unknown(t *testing.T) {
+	for definition, expected := range map[string]string{
+		"{image: sample, enviroment: [A=b]}":         "enviroment: unknown property, did you mean environment?",
+		"{image: sample, something: else}":           "something: unknown property\n",
+		"{image: sample, healthcheck: {intervl: 1}}": "field intervl not found in type component.Healthcheck",
+		"[image, sample]":                            "unexpected component definition",
+	} {
+		_, _, err := LoadDefinition(parseDefinition(t, definition), false)
+
+		if err == nil {
+			t.Error("Expected to fail for", definition)
+		} else if !strings.Contains(err.Error()+"\n", expected) {
+			t.Error("Unexpected error for", definition, ":", err)
+		}
+	}
+}
+
+func TestLoadDefinition_DocumentedProperties(t *testing.T) {
+	readme, err := ioutil.ReadFile("../../README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	section := regexp.MustCompile("(?s)## Unsupported properties\n\n(.+?)\n\n").FindStringSubmatch(string(readme))
+	if section == nil {
+		t.Fatal("The list of unsupported properties is not found in the README")
+	}
+
+	other := regexp.MustCompile("(?s)the Compose Specification, are not supported either:\n\n(.+?)\n\n").FindStringSubmatch(string(readme))
+	if other == nil {
+		t.Fatal("The list of unsupported v1 and v3 properties is not found in the README")
+	}
+
+	documented := map[string]bool{}
+
+	for _, line := range strings.Split(section[1]+"\n"+other[1], "\n") {
+		match := regexp.MustCompile("^- `(.+)`: (.+)$").FindStringSubmatch(line)
+		if match == nil {
+			t.Error("Unexpected line in the README:", line)
+			continue
+		}
+
+		documented[match[1]] = true
+
+		if reason := ignoredProperties[match[1]]; reason != match[2] {
+			t.Errorf("Unexpected reason for %s: %s (documented: %s)", match[1], reason, match[2])
+		}
+	}
+
+	schemaData, err := ioutil.ReadFile("testdata/config_schema_v2.4.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema struct {
+		Definitions struct {
+			Service struct {
+				Properties map[string]interface{}
+			}
+		}
+	}
+
+	if err := json.Unmarshal(schemaData, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	for property := range schema.Definitions.Service.Properties {
+		if !supportedProperties[property] && ignoredProperties[property] == "" {
+			t.Error("Unknown v2 Compose property:", property)
+		}
+	}
+
+	for property := range ignoredProperties {
+		if !documented[property] {
+			t.Error("The unsupported property is not documented in the README:", property)
+		}
+	}
+}
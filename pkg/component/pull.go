@@ -1,20 +1,355 @@
 package component
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/rycus86/podlike/pkg/config"
 )
 
-func (c *Component) pullImage() error {
-	fmt.Println("Pulling image:", c.Image)
+const (
+	PullAlways  = "always"
+	PullMissing = "missing"
+	PullNever   = "never"
+	PullIfNewer = "if-newer"
+)
+
+var (
+	// the number of attempts to pull an image, with exponential backoff between them
+	pullAttempts = 3
+	pullBackoff  = 2 * time.Second
+
+	// how often to report the progress of pulling an image
+	pullProgressInterval = 5 * time.Second
+)
+
+// pullMessage is a message in the JSON stream of an image pull
+type pullMessage struct {
+	ID       string
+	Status   string
+	Progress *struct {
+		Current int64
+		Total   int64
+	} `json:"progressDetail"`
+	ErrorDetail *struct {
+		Code    int
+		Message string
+	} `json:"errorDetail"`
+	Error string `json:"error"`
+}
+
+// PrepareImages resolves and pulls the images of the components in parallel,
+// according to their pull policies.
+func PrepareImages(components []*Component, configuration *config.Configuration) error {
+	var (
+		wg     sync.WaitGroup
+		errs   = make([]error, len(components))
+		failed []string
+	)
+
+	wg.Add(len(components))
+
+	for idx, c := range components {
+		go func(idx int, c *Component) {
+			defer wg.Done()
+
+			errs[idx] = c.prepareImage(configuration)
+		}(idx, c)
+	}
+
+	wg.Wait()
+
+	for idx, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("  - %s: %s", components[idx].Name, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.New("failed to prepare the images:\n" + strings.Join(failed, "\n"))
+	}
+
+	return nil
+}
+
+// prepareImage pins the image of the component if necessary,
+// then pulls it according to the pull policy.
+func (c *Component) prepareImage(configuration *config.Configuration) error {
+	if c.imagePrepared {
+		return nil
+	}
+
+	policy, err := c.getPullPolicy(configuration)
+	if err != nil {
+		return err
+	}
+
+	if configuration.PinImages != "" {
+		if err := c.pinImage(configuration); err != nil {
+			return err
+		}
+	}
+
+	switch policy {
+	case PullAlways:
+		err = c.pullImage()
+
+	case PullMissing:
+		err = c.pullImageIfMissing()
+
+	case PullNever:
+		err = c.checkImageExists()
+
+	case PullIfNewer:
+		err = c.pullImageIfNewer()
+
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.imagePrepared = true
+
+	return nil
+}
+
+// getPullPolicy returns the pull policy of the component,
+// defaulting to `always` with the `-pull` flag, and `missing` otherwise.
+func (c *Component) getPullPolicy(configuration *config.Configuration) (string, error) {
+	switch c.PullPolicy {
+	case "":
+		if configuration.AlwaysPull {
+			return PullAlways, nil
+		}
+
+		return PullMissing, nil
+
+	case PullAlways, PullMissing, PullNever, PullIfNewer:
+		return c.PullPolicy, nil
+
+	default:
+		return "", errors.New(fmt.Sprintf(
+			"invalid pull policy for %s : %s (expected %s, %s, %s or %s)",
+			c.Name, c.PullPolicy, PullAlways, PullMissing, PullNever, PullIfNewer))
+
+	}
+}
+
+// usesLocalImageOnly returns true when the image is never pulled from the registry.
+func (c *Component) usesLocalImageOnly(configuration *config.Configuration) bool {
+	policy, _ := c.getPullPolicy(configuration)
+	return policy == PullNever
+}
+
+func (c *Component) pullImageIfMissing() error {
+	exists, err := c.engine.HasImage(c.getImage())
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	return c.pullImage()
+}
+
+func (c *Component) checkImageExists() error {
+	exists, err := c.engine.HasImage(c.getImage())
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return errors.New(fmt.Sprintf(
+			"the %s image is not available locally, and the pull policy of %s is %s",
+			c.getImage(), c.Name, PullNever))
+	}
+
+	return nil
+}
+
+// pullImageIfNewer pulls the image if the registry has a different version than the local one.
+func (c *Component) pullImageIfNewer() error {
+	if c.pinnedImage != "" {
+		// the image is pinned to a digest, so it can't change
+		return c.pullImageIfMissing()
+	}
 
-	if reader, err := c.engine.PullImage(c.Image); err != nil {
+	exists, err := c.engine.HasImage(c.Image)
+	if err != nil {
 		return err
-	} else {
-		defer reader.Close()
+	}
 
-		ioutil.ReadAll(reader)
+	if !exists {
+		return c.pullImage()
+	}
 
+	remote, err := c.engine.GetRemoteImageDigest(c.Image)
+	if err != nil {
+		logWarning("Failed to check for a newer", c.Image, "image for", c.Name, ", using the local one:", err)
 		return nil
 	}
+
+	localDigests, err := c.engine.GetLocalImageDigests(c.Image)
+	if err != nil {
+		return err
+	}
+
+	for _, local := range localDigests {
+		if strings.HasSuffix(local, "@"+remote) {
+			return nil
+		}
+	}
+
+	return c.pullImage()
+}
+
+// pullImage pulls the image of the component, retrying with an exponential backoff on failures.
+func (c *Component) pullImage() error {
+	image := c.getImage()
+
+	fmt.Println("Pulling image:", image)
+
+	backoff := pullBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := c.pullImageOnce(image)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= pullAttempts {
+			return errors.New(fmt.Sprintf("failed to pull %s after %d attempts : %s", image, attempt, err))
+		}
+
+		logWarning("Failed to pull", image, "for", c.Name, ":", err, "- retrying in", backoff)
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (c *Component) pullImageOnce(image string) error {
+	reader, err := c.engine.PullImage(image)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	progress := newPullProgress()
+	decoder := json.NewDecoder(reader)
+
+	for {
+		var message pullMessage
+
+		if err := decoder.Decode(&message); err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.New(fmt.Sprintf("failed to read the pull output : %s", err))
+		}
+
+		if message.ErrorDetail != nil && message.ErrorDetail.Message != "" {
+			return errors.New(message.ErrorDetail.Message)
+		} else if message.Error != "" {
+			return errors.New(message.Error)
+		}
+
+		progress.update(&message)
+
+		if progress.shouldReport() {
+			fmt.Printf("[%s] Pulling %s : %s\n", c.Name, image, progress.String())
+		}
+	}
+
+	fmt.Printf("[%s] Pulled %s : %s\n", c.Name, image, progress.status)
+
+	return nil
+}
+
+type pullProgress struct {
+	status string
+	layers map[string]*layerProgress
+
+	lastReport time.Time
+}
+
+type layerProgress struct {
+	current, total int64
+	done           bool
+}
+
+func newPullProgress() *pullProgress {
+	return &pullProgress{
+		layers:     map[string]*layerProgress{},
+		lastReport: time.Now(),
+	}
+}
+
+func (p *pullProgress) update(message *pullMessage) {
+	if message.ID == "" || strings.HasPrefix(message.Status, "Pulling from") {
+		if message.Status != "" {
+			p.status = message.Status
+		}
+
+		return
+	}
+
+	layer, ok := p.layers[message.ID]
+	if !ok {
+		layer = &layerProgress{}
+		p.layers[message.ID] = layer
+	}
+
+	switch message.Status {
+	case "Downloading":
+		if message.Progress != nil {
+			layer.current = message.Progress.Current
+			layer.total = message.Progress.Total
+		}
+
+	case "Download complete":
+		layer.current = layer.total
+
+	case "Pull complete", "Already exists":
+		layer.current = layer.total
+		layer.done = true
+
+	}
+}
+
+// shouldReport returns true at most once in every reporting interval.
+func (p *pullProgress) shouldReport() bool {
+	if time.Since(p.lastReport) < pullProgressInterval {
+		return false
+	}
+
+	p.lastReport = time.Now()
+	return true
+}
+
+func (p *pullProgress) String() string {
+	var (
+		done           int
+		current, total int64
+	)
+
+	for _, layer := range p.layers {
+		if layer.done {
+			done++
+		}
+
+		current += layer.current
+		total += layer.total
+	}
+
+	return fmt.Sprintf("%d/%d layers complete, %s/%s downloaded",
+		done, len(p.layers), units.HumanSize(float64(current)), units.HumanSize(float64(total)))
 }
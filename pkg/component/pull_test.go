@@ -0,0 +1,173 @@
+package component
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+const (
+	pullSuccess = `{"status": "Pulling from library/app", "id": "1.0"}
+{"status": "Downloading", "id": "l1", "progressDetail": {"current": 100, "total": 200}}
+{"status": "Already exists", "id": "l2"}
+{"status": "Pull complete", "id": "l1"}
+{"status": "Status: Downloaded newer image for app:1.0"}
+`
+	pullFailure = `{"status": "Pulling from library/app", "id": "1.0"}
+{"errorDetail": {"message": "manifest for app:1.0 not found"}, "error": "manifest for app:1.0 not found"}
+`
+)
+
+func init() {
+	pullBackoff = time.Millisecond
+}
+
+func TestPull_StreamErrors(t *testing.T) {
+	c, engine := newMockComponent("app", nil)
+	c.Image = "app:1.0"
+
+	engine.pulls = []string{pullFailure, pullFailure, pullFailure}
+
+	err := c.pullImage()
+	if err == nil || !strings.Contains(err.Error(), "manifest for app:1.0 not found") {
+		t.Error("Expected to fail:", err)
+	}
+
+	if len(engine.pulled) != pullAttempts {
+		t.Error("Unexpected number of attempts:", engine.pulled)
+	}
+}
+
+func TestPull_Retries(t *testing.T) {
+	c, engine := newMockComponent("app", nil)
+	c.Image = "app:1.0"
+
+	engine.pulls = []string{pullFailure, `{"status": "Downloading", "id": "l1"`, pullSuccess}
+
+	if err := c.pullImage(); err != nil {
+		t.Error("Failed to pull:", err)
+	}
+
+	if len(engine.pulled) != 3 {
+		t.Error("Unexpected number of attempts:", engine.pulled)
+	}
+}
+
+func TestPull_Policies(t *testing.T) {
+	for _, item := range []struct {
+		Policy      string
+		AlwaysPull  bool
+		Exists      bool
+		Remote      string
+		ShouldPull  bool
+		ShouldError bool
+	}{
+		{Policy: "", Exists: true, ShouldPull: false},
+		{Policy: "", Exists: false, ShouldPull: true},
+		{Policy: "", AlwaysPull: true, Exists: true, ShouldPull: true},
+		{Policy: PullAlways, Exists: true, ShouldPull: true},
+		{Policy: PullMissing, Exists: true, ShouldPull: false},
+		{Policy: PullMissing, AlwaysPull: true, Exists: true, ShouldPull: false},
+		{Policy: PullNever, Exists: true, ShouldPull: false},
+		{Policy: PullNever, Exists: false, ShouldError: true},
+		{Policy: PullIfNewer, Exists: false, ShouldPull: true},
+		{Policy: PullIfNewer, Exists: true, Remote: digestV1, ShouldPull: false},
+		{Policy: PullIfNewer, Exists: true, Remote: digestV2, ShouldPull: true},
+		{Policy: PullIfNewer, Exists: true, Remote: "", ShouldPull: false},
+		{Policy: "sometimes", ShouldError: true},
+	} {
+		c, engine := newMockComponent("app", nil)
+		c.Image = "app:1.0"
+		c.PullPolicy = item.Policy
+
+		engine.images = map[string]bool{"app:1.0": item.Exists}
+		engine.localDigests = map[string][]string{"app:1.0": {"app@" + digestV1}}
+		engine.pulls = []string{pullSuccess}
+
+		if item.Remote != "" {
+			engine.remoteDigests = map[string]string{"app:1.0": item.Remote}
+		}
+
+		err := c.prepareImage(&config.Configuration{AlwaysPull: item.AlwaysPull})
+
+		if item.ShouldError {
+			if err == nil {
+				t.Errorf("Expected to fail: %+v", item)
+			}
+
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error: %+v : %s", item, err)
+		}
+
+		if pulled := len(engine.pulled) > 0; pulled != item.ShouldPull {
+			t.Errorf("Unexpected pull: %+v", item)
+		}
+	}
+}
+
+func TestPull_PrepareImages(t *testing.T) {
+	var components []*Component
+
+	for _, name := range []string{"first", "second", "third"} {
+		c, engine := newMockComponent(name, nil)
+		c.Image = name + ":1.0"
+
+		if name == "second" {
+			engine.pulls = []string{pullFailure, pullFailure, pullFailure}
+		} else {
+			engine.pulls = []string{pullSuccess}
+		}
+
+		components = append(components, c)
+	}
+
+	err := PrepareImages(components, &config.Configuration{})
+	if err == nil {
+		t.Fatal("Expected to fail")
+	}
+
+	if !strings.Contains(err.Error(), "second:") || strings.Contains(err.Error(), "first:") {
+		t.Error("Unexpected error:", err)
+	}
+
+	if !components[0].imagePrepared || components[1].imagePrepared || !components[2].imagePrepared {
+		t.Error("Unexpected prepared images")
+	}
+}
+
+func TestPull_Progress(t *testing.T) {
+	progress := newPullProgress()
+
+	for _, message := range []pullMessage{
+		{ID: "l1", Status: "Pulling fs layer"},
+		{ID: "l2", Status: "Already exists"},
+		{ID: "l1", Status: "Downloading"},
+	} {
+		progress.update(&message)
+	}
+
+	message := pullMessage{ID: "l1", Status: "Downloading"}
+	message.Progress = &struct {
+		Current int64
+		Total   int64
+	}{Current: 1000, Total: 4000}
+
+	progress.update(&message)
+
+	if s := progress.String(); s != "1/2 layers complete, 1kB/4kB downloaded" {
+		t.Error("Unexpected progress:", s)
+	}
+
+	if progress.shouldReport() {
+		t.Error("Should not report within the interval")
+	}
+
+	progress.lastReport = time.Now().Add(-pullProgressInterval)
+
+	if !progress.shouldReport() || progress.shouldReport() {
+		t.Error("Should report once after the interval")
+	}
+}
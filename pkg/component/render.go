@@ -0,0 +1,94 @@
+package component
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+type renderContext struct {
+	Env map[string]string
+
+	Component  renderComponent
+	Controller renderController
+
+	Task    renderSwarmObject
+	Service renderSwarmObject
+	Node    renderSwarmObject
+	Stack   string
+}
+
+type renderComponent struct {
+	Name string
+}
+
+type renderController struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+}
+
+type renderSwarmObject struct {
+	ID   string
+	Name string
+}
+
+func (c *Component) newRenderContext() *renderContext {
+	labels := c.client.GetLabels()
+
+	ctx := renderContext{
+		Env: map[string]string{},
+
+		Component: renderComponent{
+			Name: c.Name,
+		},
+		Controller: renderController{
+			ID:     c.client.GetContainerID(),
+			Name:   strings.TrimPrefix(c.client.GetContainerName(), "/"),
+			Labels: labels,
+		},
+
+		Task: renderSwarmObject{
+			ID:   labels["com.docker.swarm.task.id"],
+			Name: labels["com.docker.swarm.task.name"],
+		},
+		Service: renderSwarmObject{
+			ID:   labels["com.docker.swarm.service.id"],
+			Name: labels["com.docker.swarm.service.name"],
+		},
+		Node: renderSwarmObject{
+			ID: labels["com.docker.swarm.node.id"],
+		},
+		Stack: labels["com.docker.stack.namespace"],
+	}
+
+	for _, variable := range os.Environ() {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) == 2 {
+			ctx.Env[parts[0]] = parts[1]
+		}
+	}
+
+	return &ctx
+}
+
+// render processes the contents of the source file as a Go template,
+// failing on references to missing variables.
+func (ctx *renderContext) render(source string, contents []byte) ([]byte, error) {
+	tmpl, err := template.New(filepath.Base(source)).Option("missingkey=error").Parse(string(contents))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("failed to parse %s as a template: %s", source, err))
+	}
+
+	var buffer bytes.Buffer
+
+	if err := tmpl.Execute(&buffer, ctx); err != nil {
+		return nil, errors.New(fmt.Sprintf("failed to render %s: %s", source, err))
+	}
+
+	return buffer.Bytes(), nil
+}
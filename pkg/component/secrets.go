@@ -0,0 +1,194 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/docker/docker/api/types/mount"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	secretsDir = "/run/secrets"
+
+	defaultSecretMode = 0444
+)
+
+// getSecretsAndConfigs returns the copy configurations delivering
+// the controller's secrets and configs to the component.
+func (c *Component) getSecretsAndConfigs() ([]CopyConfig, error) {
+	secrets, err := parseSecretReferences("secrets", c.Secrets, secretsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := parseSecretReferences("configs", c.Configs, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(secrets, configs...), nil
+}
+
+// parseSecretReferences parses the short (name only) or long syntax
+// of Compose secrets and configs, with their sources and targets
+// relative to the directory given.
+func parseSecretReferences(kind string, items []interface{}, baseDir string) ([]CopyConfig, error) {
+	var configs []CopyConfig
+
+	for _, item := range items {
+		var reference struct {
+			Source string
+			Target string
+			UID    string
+			GID    string
+			Mode   interface{}
+		}
+
+		if name, ok := item.(string); ok {
+			reference.Source = name
+		} else {
+			data, err := yaml.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := yaml.UnmarshalStrict(data, &reference); err != nil {
+				return nil, errors.New(fmt.Sprintf("invalid %s definition: %+v : %s", kind, item, err))
+			}
+		}
+
+		if reference.Source == "" {
+			return nil, errors.New(fmt.Sprintf("invalid %s definition, the source is missing: %+v", kind, item))
+		}
+
+		config := CopyConfig{
+			Source: resolvePath(baseDir, reference.Source),
+			Target: resolvePath(baseDir, reference.Source),
+			Owner:  &CopyOwner{},
+		}
+
+		if reference.Target != "" {
+			config.Target = resolvePath(baseDir, reference.Target)
+		}
+
+		if path.Dir(config.Target) == "/" {
+			return nil, errors.New(fmt.Sprintf(
+				"invalid %s definition for %s: the target needs to be in a directory other than / : %s",
+				kind, reference.Source, config.Target))
+		}
+
+		mode := os.FileMode(defaultSecretMode)
+
+		if reference.Mode != nil {
+			parsed, err := parseFileMode(reference.Mode)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("invalid %s definition for %s: %s", kind, reference.Source, err))
+			}
+
+			mode = parsed
+		}
+
+		config.Mode = &mode
+
+		for _, id := range []struct {
+			value  string
+			target *int
+		}{
+			{reference.UID, &config.Owner.UID},
+			{reference.GID, &config.Owner.GID},
+		} {
+			if id.value == "" {
+				continue
+			}
+
+			parsed, err := strconv.Atoi(id.value)
+			if err != nil || parsed < 0 {
+				return nil, errors.New(fmt.Sprintf(
+					"invalid %s definition for %s: invalid user or group ID: %s", kind, reference.Source, id.value))
+			}
+
+			*id.target = parsed
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+func resolvePath(baseDir, value string) string {
+	if path.IsAbs(value) {
+		return path.Clean(value)
+	}
+
+	return path.Join(baseDir, value)
+}
+
+// getSecretMounts returns in-memory volumes for each target directory of the secrets and configs,
+// so their contents never get written to the disk, nor to the writable layer of the container.
+func (c *Component) getSecretMounts() ([]mount.Mount, error) {
+	configs, err := c.getSecretsAndConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	directories := map[string]bool{}
+
+	for _, config := range configs {
+		directories[path.Dir(config.Target)] = true
+	}
+
+	var targets []string
+
+	for directory := range directories {
+		targets = append(targets, directory)
+	}
+
+	sort.Strings(targets)
+
+	mounts := make([]mount.Mount, 0, len(targets))
+
+	for _, target := range targets {
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Target: target,
+			VolumeOptions: &mount.VolumeOptions{
+				NoCopy: true,
+				DriverConfig: &mount.Driver{
+					Name: "local",
+					Options: map[string]string{
+						"type":   "tmpfs",
+						"device": "tmpfs",
+						"o":      "mode=0755",
+					},
+				},
+			},
+		})
+	}
+
+	return mounts, nil
+}
+
+// deliverSecretsAndConfigs copies the secrets and configs into the in-memory volumes.
+// This has to happen after the container has started, because the engine unmounts
+// the volumes of a container that is not running after copying into them,
+// and the in-memory volumes lose their contents then.
+func (c *Component) deliverSecretsAndConfigs() error {
+	configs, err := c.getSecretsAndConfigs()
+	if err != nil {
+		return err
+	}
+
+	for _, config := range configs {
+		if err := c.copyFiles(config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package component
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/rycus86/podlike/pkg/config"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSecrets_Parse(t *testing.T) {
+	component := parseSecretsComponent(t, `
+secrets:
+  - db-password
+  - source: api-key
+    target: keys/api
+    uid: "1000"
+    gid: "2000"
+    mode: 0400
+configs:
+  - source: app-config
+    target: /etc/app/app.conf
+  - source: /etc/proxy.conf
+    target: /etc/proxy/proxy.conf
+    mode: 0440
+`)
+
+	configs, err := component.getSecretsAndConfigs()
+	if err != nil {
+		t.Fatal("Failed to parse:", err)
+	}
+
+	expected := []string{
+		"{/run/secrets/db-password /run/secrets/db-password mode=0444 owner=0:0}",
+		"{/run/secrets/api-key /run/secrets/keys/api mode=0400 owner=1000:2000}",
+		"{/app-config /etc/app/app.conf mode=0444 owner=0:0}",
+		"{/etc/proxy.conf /etc/proxy/proxy.conf mode=0440 owner=0:0}",
+	}
+
+	if len(configs) != len(expected) {
+		t.Fatal("Unexpected configurations:", configs)
+	}
+
+	for idx, config := range configs {
+		if actual := config.String(); actual != expected[idx] {
+			t.Errorf("Unexpected configuration:\n%s\n%s", actual, expected[idx])
+		}
+	}
+}
+
+func TestSecrets_Mounts(t *testing.T) {
+	component := parseSecretsComponent(t, `
+secrets: [first, second]
+configs:
+  - source: app-config
+    target: /etc/app/app.conf
+`)
+
+	mounts, err := component.getSecretMounts()
+	if err != nil {
+		t.Fatal("Failed to get the mounts:", err)
+	}
+
+	if len(mounts) != 2 || mounts[0].Target != "/etc/app" || mounts[1].Target != "/run/secrets" {
+		t.Fatal("Unexpected mounts:", mounts)
+	}
+
+	for _, mnt := range mounts {
+		if mnt.Type != mount.TypeVolume || mnt.Source != "" ||
+			mnt.VolumeOptions == nil || mnt.VolumeOptions.DriverConfig == nil ||
+			mnt.VolumeOptions.DriverConfig.Options["type"] != "tmpfs" {
+
+			t.Errorf("Unexpected mount: %+v", mnt)
+		}
+	}
+}
+
+func TestSecrets_Deliver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "app.conf")
+	if err := ioutil.WriteFile(source, []byte("key=value"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	component := parseSecretsComponent(t, `
+configs:
+  - source: `+source+`
+    target: /etc/app/app.conf
+    uid: "100"
+`)
+
+	mock, engine := newMockComponent("app", map[string]string{})
+	mock.Configs = component.Configs
+	component = mock
+
+	if err := component.deliverSecretsAndConfigs(); err != nil {
+		t.Fatal("Failed to deliver:", err)
+	}
+
+	if copied := engine.getCopied(); !reflect.DeepEqual(copied, []string{"/etc/app/app.conf=key=value"}) {
+		t.Error("Unexpected copied files:", copied)
+	}
+}
+
+func TestSecrets_DeliverAfterStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "app.conf")
+	if err := ioutil.WriteFile(source, []byte("key=value"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	component, engine := newMockComponent("app", map[string]string{})
+	component.Image = "sample/app"
+	component.PullPolicy = PullNever
+	component.Configs = []interface{}{map[interface{}]interface{}{"source": source, "target": "/etc/app/app.conf"}}
+	component.DisableHealthChecking()
+
+	engine.images = map[string]bool{"sample/app": true}
+
+	if err := component.Start(&config.Configuration{}); err != nil {
+		t.Fatal("Failed to start:", err)
+	}
+	defer component.stopWatchingCopiedFiles()
+
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+
+	// the in-memory volumes would lose the files copied before starting
+	if len(engine.copiedBeforeStart) > 0 {
+		t.Error("Unexpected files copied before starting:", engine.copiedBeforeStart)
+	}
+
+	if !reflect.DeepEqual(engine.copied, []string{"/etc/app/app.conf=key=value"}) {
+		t.Error("Unexpected copied files:", engine.copied)
+	}
+}
+
+func TestSecrets_Invalid(t *testing.T) {
+	for definition, expectedError := range map[string]string{
+		"secrets: [{target: /run/secrets/x}]":             "the source is missing",
+		"secrets: [{source: x, unknown: field}]":          "field unknown not found",
+		"secrets: [{source: x, mode: rwx}]":               "invalid file mode",
+		"secrets: [{source: x, uid: root}]":               "invalid user or group ID",
+		"configs: [app-config]":                           "needs to be in a directory other than /",
+		"configs: [{source: x, target: /etc/x, gid: -1}]": "invalid user or group ID",
+	} {
+		component := parseSecretsComponent(t, definition)
+
+		if _, err := component.getSecretsAndConfigs(); err == nil {
+			t.Error("Expected to fail:", definition)
+		} else if !strings.Contains(err.Error(), expectedError) {
+			t.Error("Unexpected error for", definition, ":", err)
+		}
+	}
+}
+
+func parseSecretsComponent(t *testing.T, definition string) *Component {
+	var component Component
+
+	if err := yaml.UnmarshalStrict([]byte(definition), &component); err != nil {
+		t.Fatal("Failed to parse the component:", err)
+	}
+
+	return &component
+}
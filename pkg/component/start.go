@@ -30,17 +30,53 @@ func (c *Component) Start(configuration *config.Configuration) error {
 		return err
 	}
 
+	if err := c.deliverSecretsAndConfigs(); err != nil {
+		return err
+	}
+
 	healthcheck.MarkStarted(c.container.ID, c.Name)
 
+	c.startWatchingCopiedFiles()
+	c.startExporting()
+
 	if configuration.StreamLogs {
-		go c.streamLogs()
+		go c.streamLogs(configuration)
 	}
 
-	fmt.Println("Component started:", c.Name)
+	if c.imageDigest != "" {
+		fmt.Println("Component started:", c.Name, "image:", c.pinnedImage)
+	} else {
+		fmt.Println("Component started:", c.Name)
+	}
 
 	return nil
 }
 
+// Status returns a summary of the component for the status output of the pod:
+// the container it runs in, and its image, with the digest it is pinned to.
+func (c *Component) Status() string {
+	status := c.Name + ":"
+
+	if c.container != nil {
+		id := c.container.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+
+		status += " container " + id + ","
+	} else {
+		status += " not created,"
+	}
+
+	if c.imageDigest != "" {
+		status += " image " + c.pinnedImage + " (pinned from " + c.Image + ")"
+	} else {
+		status += " image " + c.Image
+	}
+
+	return status
+}
+
 func (c *Component) startContainer() error {
 	return c.engine.StartContainer(c.container.ID)
 }
@@ -14,6 +14,9 @@ func (c *Component) Stop() error {
 		return errors.New("Container is not running for component: " + c.Name)
 	}
 
+	c.stopWatchingCopiedFiles()
+	c.failExports("the component has stopped")
+
 	stopError := c.stopContainer()
 	removeError := c.removeContainer()
 
@@ -24,6 +27,21 @@ func (c *Component) Stop() error {
 	}
 }
 
+// RemoveExited cleans up after the component has exited successfully.
+// It stops watching the files copied into it, releases the components waiting for files
+// it did not export, and removes its container, when that is not removed automatically,
+// because files are copied from it on exit.
+func (c *Component) RemoveExited() error {
+	c.stopWatchingCopiedFiles()
+	c.failExports("the component has exited")
+
+	if c.container == nil || !c.needsExportAfterExit() {
+		return nil
+	}
+
+	return c.removeContainer()
+}
+
 func (c *Component) stopContainer() error {
 	var stopTimeout *time.Duration
 
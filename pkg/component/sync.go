@@ -0,0 +1,212 @@
+package component
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/rycus86/podlike/pkg/convert"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultWatchInterval = 5 * time.Second
+
+type watchedCopy struct {
+	config   CopyConfig
+	checksum string
+}
+
+func parseWatchConfig(value interface{}) (*WatchConfig, error) {
+	// a simple flag, like:
+	//  watch: true
+	if enabled, ok := value.(bool); ok {
+		if !enabled {
+			return nil, nil
+		}
+
+		return &WatchConfig{Interval: defaultWatchInterval}, nil
+	}
+
+	if _, isMap := value.(map[interface{}]interface{}); !isMap {
+		return nil, errors.New(fmt.Sprintf("unexpected value: %+v (%T)", value, value))
+	}
+
+	// a mapping, like:
+	//  watch:
+	//    interval: 10s
+	//    signal: SIGHUP
+	var definition struct {
+		Interval time.Duration
+		Signal   string
+		Exec     interface{}
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.UnmarshalStrict(data, &definition); err != nil {
+		return nil, err
+	}
+
+	exec, err := convert.ToStrSlice(definition.Exec)
+	if err != nil {
+		return nil, err
+	}
+
+	watch := WatchConfig{
+		Interval: definition.Interval,
+		Signal:   definition.Signal,
+		Exec:     exec,
+	}
+
+	if watch.Interval <= 0 {
+		watch.Interval = defaultWatchInterval
+	}
+
+	return &watch, nil
+}
+
+func (c *Component) startWatchingCopiedFiles() {
+	if len(c.watchedCopies) == 0 {
+		return
+	}
+
+	c.stopWatching = make(chan struct{})
+	c.watchers = &sync.WaitGroup{}
+	c.watchers.Add(len(c.watchedCopies))
+
+	for _, item := range c.watchedCopies {
+		go func(item watchedCopy, stop <-chan struct{}, watchers *sync.WaitGroup) {
+			defer watchers.Done()
+
+			c.watchCopiedFiles(item.config, item.checksum, stop)
+		}(item, c.stopWatching, c.watchers)
+	}
+}
+
+func (c *Component) stopWatchingCopiedFiles() {
+	if c.stopWatching != nil {
+		close(c.stopWatching)
+		c.stopWatching = nil
+
+		c.watchers.Wait()
+	}
+}
+
+// watchCopiedFiles periodically checks the source of the copy configuration,
+// and copies it again when it has changed, and then did not change for another interval.
+func (c *Component) watchCopiedFiles(config CopyConfig, checksum string, stop <-chan struct{}) {
+	ticker := time.NewTicker(config.Watch.Interval)
+	defer ticker.Stop()
+
+	var pending string
+
+	for {
+		select {
+		case <-stop:
+			return
+
+		case <-ticker.C:
+			current, err := c.getChecksum(config)
+			if err != nil {
+				fmt.Println("Failed to check", config.Source, "for changes for", c.Name, ":", err)
+				continue
+			}
+
+			if current == checksum {
+				pending = ""
+				continue
+			}
+
+			if current != pending {
+				// wait for the changes to settle
+				pending = current
+				continue
+			}
+
+			if err := c.copyFiles(config); err != nil {
+				fmt.Println("Failed to copy the changes of", config.Source, "to", c.Name, ":", err)
+				continue
+			}
+
+			checksum = current
+			pending = ""
+
+			if err := c.notifyAboutCopiedFiles(config.Watch); err != nil {
+				fmt.Println("Failed to notify", c.Name, "about the changes of", config.Source, ":", err)
+			}
+		}
+	}
+}
+
+// getChecksum returns the checksum of the contents and the metadata of the files
+// the configuration copies, leaving out the modification times,
+// so only the actual changes are copied again.
+func (c *Component) getChecksum(config CopyConfig) (string, error) {
+	var renderer *renderContext
+	if config.Render {
+		renderer = c.newRenderContext()
+	}
+
+	_, targetFilename := path.Split(config.Target)
+
+	reader, err := createTar(config, targetFilename, renderer)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	tr := tar.NewReader(reader)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(hash, "%s\x00%c\x00%s\x00%o\x00%d:%d\x00%d\x00",
+			hdr.Name, hdr.Typeflag, hdr.Linkname, hdr.Mode, hdr.Uid, hdr.Gid, hdr.Size)
+
+		if _, err := io.Copy(hash, tr); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (c *Component) notifyAboutCopiedFiles(watch *WatchConfig) error {
+	if watch.Signal != "" {
+		fmt.Println("Sending", watch.Signal, "to", c.Name)
+
+		if err := c.engine.SignalContainer(c.container.ID, watch.Signal); err != nil {
+			return err
+		}
+	}
+
+	if len(watch.Exec) > 0 {
+		fmt.Println("Executing", watch.Exec, "in", c.Name)
+
+		exitCode, err := c.engine.ExecInContainer(c.container.ID, watch.Exec)
+		if err != nil {
+			return err
+		}
+
+		if exitCode != 0 {
+			return errors.New(fmt.Sprintf("%v exited with status %d", watch.Exec, exitCode))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package component
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSync_ParseWatchConfig(t *testing.T) {
+	parsed := parseAndVerify(t, `
+pod.copy.test: |
+  /etc/conf/nginx.conf:
+    target: /etc/nginx/nginx.conf
+    watch: true
+  /etc/conf/app.conf:
+    target: /etc/app.conf
+    watch:
+      interval: 30s
+      signal: SIGHUP
+      exec: app reload --force
+  /etc/conf/other.conf:
+    target: /etc/other.conf
+    watch: false
+`,
+		CopyConfig{Source: "/etc/conf/nginx.conf", Target: "/etc/nginx/nginx.conf"},
+		CopyConfig{Source: "/etc/conf/app.conf", Target: "/etc/app.conf"},
+		CopyConfig{Source: "/etc/conf/other.conf", Target: "/etc/other.conf"})
+
+	for _, config := range parsed {
+		switch config.Source {
+		case "/etc/conf/nginx.conf":
+			if config.Watch == nil || config.Watch.Interval != defaultWatchInterval ||
+				config.Watch.Signal != "" || config.Watch.Exec != nil {
+
+				t.Error("Unexpected watch configuration:", config.Watch)
+			}
+
+		case "/etc/conf/app.conf":
+			if config.Watch == nil || config.Watch.Interval != 30*time.Second || config.Watch.Signal != "SIGHUP" ||
+				!reflect.DeepEqual(config.Watch.Exec, []string{"app", "reload", "--force"}) {
+
+				t.Error("Unexpected watch configuration:", config.Watch)
+			}
+
+		default:
+			if config.Watch != nil {
+				t.Error("Unexpected watch configuration:", config.Watch)
+			}
+		}
+	}
+
+	for _, invalid := range []string{
+		"{target: /dst, watch: sometimes}",
+		"{target: /dst, watch: {interval: often}}",
+		"{target: /dst, watch: {signal: SIGHUP, unknown: true}}",
+	} {
+		if _, err := parseCopyConfig("/src: " + invalid); err == nil {
+			t.Error("Expected to fail:", invalid)
+		}
+	}
+}
+
+func TestSync_CopyChangedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "app.conf")
+	ioutil.WriteFile(source, []byte("v1"), 0644)
+
+	component, engine := newMockComponent("app", map[string]string{
+		"pod.copy.app": "" +
+			source + ":\n" +
+			"  target: /etc/app.conf\n" +
+			"  watch: {interval: 10ms, signal: SIGHUP, exec: [app, reload]}",
+	})
+
+	if err := component.copyFilesIfNecessary(); err != nil {
+		t.Fatal("Failed to copy the files:", err)
+	}
+
+	component.startWatchingCopiedFiles()
+	defer component.stopWatchingCopiedFiles()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if copied := engine.getCopied(); len(copied) != 1 {
+		t.Error("Unexpected copies without changes:", copied)
+	}
+
+	ioutil.WriteFile(source, []byte("v2"), 0644)
+
+	waitFor(t, func() bool {
+		engine.lock.Lock()
+		defer engine.lock.Unlock()
+
+		return len(engine.executed) > 0
+	})
+
+	component.stopWatchingCopiedFiles()
+
+	copied := engine.getCopied()
+	if strings.Join(copied, ",") != "/etc/app.conf=v1,/etc/app.conf=v2" {
+		t.Error("Unexpected copies:", copied)
+	}
+
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+
+	if !reflect.DeepEqual(engine.signals, []string{"SIGHUP"}) {
+		t.Error("Unexpected signals:", engine.signals)
+	}
+
+	if !reflect.DeepEqual(engine.executed, [][]string{{"app", "reload"}}) {
+		t.Error("Unexpected commands executed:", engine.executed)
+	}
+}
+
+func TestSync_ChecksumIgnoresModTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "app.conf")
+	ioutil.WriteFile(source, []byte("v1"), 0644)
+
+	modTime := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	os.Chtimes(source, modTime, modTime)
+
+	component, _ := newMockComponent("app", nil)
+	config := CopyConfig{Source: source, Target: "/etc/app.conf"}
+
+	reader, err := createTar(config, "app.conf", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hdr, err := tar.NewReader(reader).Next()
+	reader.Close()
+
+	if err != nil || !hdr.ModTime.Equal(modTime) {
+		t.Error("Unexpected modification time:", hdr, err)
+	}
+
+	original, err := component.getChecksum(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Chtimes(source, time.Now(), time.Now())
+
+	if touched, err := component.getChecksum(config); err != nil || touched != original {
+		t.Error("The checksum changed without changes:", touched, err)
+	}
+
+	os.Chmod(source, 0600)
+
+	if changed, err := component.getChecksum(config); err != nil || changed == original {
+		t.Error("The checksum did not change with the mode:", changed, err)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	for idx := 0; idx < 200; idx++ {
+		if condition() {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("Timed out waiting for the condition")
+}
+
+func TestSync_StopWatchingOnExit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "init.conf")
+	ioutil.WriteFile(source, []byte("v1"), 0644)
+
+	labels := map[string]string{
+		"pod.copy.init-1": "" +
+			source + ":\n" +
+			"  target: /etc/init.conf\n" +
+			"  watch: {interval: 10ms}",
+		"pod.copy.app": "[{from: 'init-1:/out/missing', target: /etc/missing, when: healthy}]",
+	}
+
+	component, engine := newMockComponent("init-1", labels)
+
+	if err := component.copyFilesIfNecessary(); err != nil {
+		t.Fatal("Failed to copy the files:", err)
+	}
+
+	component.startWatchingCopiedFiles()
+
+	if err := component.RemoveExited(); err != nil {
+		t.Fatal("Failed to clean up after the exited component:", err)
+	}
+
+	if component.stopWatching != nil {
+		t.Error("Expected to stop watching the copied files")
+	}
+
+	ioutil.WriteFile(source, []byte("v2"), 0644)
+	time.Sleep(50 * time.Millisecond)
+
+	if copied := engine.getCopied(); len(copied) != 1 {
+		t.Error("Unexpected copies after exit:", copied)
+	}
+
+	if _, err := component.client.GetExports().Wait("init-1", "/out/missing"); err == nil {
+		t.Error("Expected to release the components waiting for files")
+	}
+}
@@ -3,12 +3,16 @@ package component
 import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/blkiodev"
+	"github.com/docker/docker/api/types/container"
 	"github.com/rycus86/podlike/pkg/api"
+	"os"
+	"sync"
 	"time"
 )
 
 type Component struct {
 	Image           string
+	PullPolicy      string `yaml:"pull_policy"`
 	Entrypoint      interface{}
 	Command         interface{}
 	WorkingDir      string      `yaml:"working_dir"`
@@ -24,8 +28,16 @@ type Component struct {
 	User            string
 	Runtime         string
 	Tmpfs           interface{}
+	Init            *bool
 
-	Volumes []interface{}
+	Hostname   string
+	ExtraHosts interface{} `yaml:"extra_hosts"`
+
+	Volumes     []interface{}
+	VolumesFrom []string `yaml:"volumes_from"`
+
+	Secrets []interface{}
+	Configs []interface{}
 
 	Healthcheck *Healthcheck
 
@@ -80,8 +92,24 @@ type Component struct {
 	Name      string               `yaml:"-"`
 	container *types.ContainerJSON `yaml:"-"`
 
+	// the image digest resolved in runtime, and the image reference pinned to it
+	imageDigest string `yaml:"-"`
+	pinnedImage string `yaml:"-"`
+	// the image is pinned and pulled according to the pull policy
+	imagePrepared bool `yaml:"-"`
+
 	// forcibly disable health-checks (for init components)
 	disableHealthChecking bool `yaml:"-"`
+	// the component runs before the others (for the `pod.init.components`)
+	initComponent bool `yaml:"-"`
+
+	// report the settings that can't be applied in the pod as warnings (for Compose services)
+	lenient bool `yaml:"-"`
+
+	// copied files to keep in sync while running
+	watchedCopies []watchedCopy   `yaml:"-"`
+	stopWatching  chan struct{}   `yaml:"-"`
+	watchers      *sync.WaitGroup `yaml:"-"`
 }
 
 type Healthcheck struct {
@@ -107,8 +135,17 @@ type LoggingConfig struct {
 	Options map[string]string
 }
 
-type ComposeProject struct {
-	Services map[string]Component
+// CreateRequest is what creating the container of a component sends to the engine.
+type CreateRequest struct {
+	Component string
+	Init      bool `json:",omitempty"`
+
+	// the image is going to be resolved to a digest when the container is created
+	UnresolvedImage bool `json:",omitempty"`
+
+	Name       string
+	Config     *container.Config
+	HostConfig *container.HostConfig
 }
 
 type ExitEvent struct {
@@ -121,6 +158,26 @@ type ExitEvent struct {
 type CopyConfig struct {
 	Source string
 	Target string
+
+	From      string
+	Condition string
+
+	Mode     *os.FileMode
+	Owner    *CopyOwner
+	Symlinks string
+	Render   bool
+	Watch    *WatchConfig
+}
+
+type WatchConfig struct {
+	Interval time.Duration
+	Signal   string
+	Exec     []string
+}
+
+type CopyOwner struct {
+	UID int
+	GID int
 }
 
 type Dependency struct {
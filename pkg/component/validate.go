@@ -0,0 +1,174 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+	"github.com/rycus86/podlike/pkg/config"
+	"github.com/rycus86/podlike/pkg/convert"
+	"github.com/rycus86/podlike/pkg/policy"
+)
+
+// Problem is an invalid property of a component definition.
+type Problem struct {
+	Field   string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Field + ": " + p.Message
+}
+
+// Validate checks the properties of the component the same way as creating
+// its container would, but without reading files or contacting the engine,
+// and returns all the problems found.
+func (c *Component) Validate(configuration *config.Configuration) []Problem {
+	var problems []Problem
+
+	check := func(field string, err error) {
+		if err != nil {
+			problems = append(problems, Problem{Field: field, Message: err.Error()})
+		}
+	}
+
+	if c.Image == "" {
+		check("image", errors.New("the image is required"))
+	}
+
+	_, err := c.getPullPolicy(configuration)
+	check("pull_policy", err)
+
+	_, err = c.GetDependencies()
+	check("depends_on", err)
+
+	_, err = convert.ToStrSlice(c.Entrypoint)
+	check("entrypoint", err)
+
+	_, err = convert.ToStrSlice(c.Command)
+	check("command", err)
+
+	_, err = c.getLabels()
+	check("labels", err)
+
+	for field, value := range map[string]interface{}{
+		"tmpfs":   c.Tmpfs,
+		"sysctls": c.Sysctls,
+	} {
+		_, err = convert.ToStringToStringMap(value)
+		check(field, err)
+	}
+
+	_, err = c.getEnvironment()
+	check("environment", err)
+
+	_, err = parseEnvFileReferences(c.EnvFile)
+	check("env_file", err)
+
+	check("hostname", c.checkHostname())
+
+	_, err = c.GetExtraHosts()
+	check("extra_hosts", err)
+
+	if c.Healthcheck != nil && !c.Healthcheck.Disable {
+		_, err = parseHealthcheckTest(c.Healthcheck.Test)
+		check("healthcheck.test", err)
+	}
+
+	_, err = asDeviceMappings(c.Devices)
+	check("devices", err)
+
+	_, err = c.getMemoryLimit()
+	check("mem_limit", err)
+
+	_, err = c.getMemorySwapLimit()
+	check("memswap_limit", err)
+
+	if c.ShmSize != nil {
+		_, err = units.RAMInBytes(*c.ShmSize)
+		check("shm_size", err)
+	}
+
+	_, err = c.getUlimits()
+	check("ulimits", err)
+
+	_, _, err = c.getMounts()
+	check("volumes", err)
+
+	_, err = parseSecretReferences("secrets", c.Secrets, secretsDir)
+	check("secrets", err)
+
+	_, err = parseSecretReferences("configs", c.Configs, "/")
+	check("configs", err)
+
+	_, err = c.getMountsFrom(&container.HostConfig{})
+	check("volumes_from", err)
+
+	_, err = c.getCopyConfigs()
+	check("pod.copy."+c.Name, err)
+
+	if configuration.Policy != nil {
+		if err := c.checkPolicy(configuration); err != nil {
+			if violations, ok := err.(*policy.ViolationError); ok {
+				for _, violation := range violations.Violations {
+					check(violation.Field, errors.New(violation.Message))
+				}
+			} else if len(problems) == 0 {
+				// otherwise the same problem is reported for its property already
+				check("policy", err)
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Field < problems[j].Field
+	})
+
+	return problems
+}
+
+// GetCopySources parses a `pod.copy.<name>` or `pod.controller.copy` definition,
+// and returns the names of the components it copies files from.
+func GetCopySources(definition string) ([]string, error) {
+	configs, err := parseCopyConfig(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+
+	for _, config := range configs {
+		if config.From == "" {
+			continue
+		}
+
+		name, _, err := parseCopySource(config.From)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, name)
+	}
+
+	return sources, nil
+}
+
+// ValidateControllerCopy checks the `pod.controller.copy` definition,
+// and returns the names of the components it copies files from.
+func ValidateControllerCopy(definition string) ([]string, error) {
+	configs, err := parseCopyConfig(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range configs {
+		if config.From == "" {
+			return nil, errors.New(fmt.Sprintf(
+				"invalid pod.controller.copy configuration, a source component is required: %s", config))
+		}
+	}
+
+	return GetCopySources(definition)
+}
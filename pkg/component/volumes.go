@@ -1,65 +1,99 @@
 package component
 
 import (
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/go-units"
 	"github.com/mitchellh/mapstructure"
 	"github.com/rycus86/podlike/pkg/volume"
-	"strings"
+	"os"
+	"path"
 )
 
-func (c *Component) getMounts() ([]mount.Mount, error) {
+// getMounts returns the volumes of the component as mounts,
+// or in the `source:target:options` bind format if they can't be expressed as mounts.
+func (c *Component) getMounts() ([]mount.Mount, []string, error) {
 	volumes, err := c.parseVolumes()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if len(volumes) == 0 {
-		return []mount.Mount{}, nil
+		return []mount.Mount{}, nil, nil
 	}
 
-	mounts := make([]mount.Mount, 0, len(volumes))
+	var (
+		mounts = make([]mount.Mount, 0, len(volumes))
+		binds  []string
+	)
 
 	for _, v := range volumes {
+		if v.NeedsBindSyntax() {
+			binds = append(binds, v.AsBind())
+			continue
+		}
+
 		mnt := mount.Mount{
-			Type:     v.GetMountType(),
-			Source:   v.Source,
-			Target:   v.Target,
-			ReadOnly: v.IsReadOnly(),
+			Type:        v.GetMountType(),
+			Source:      v.Source,
+			Target:      v.Target,
+			ReadOnly:    v.IsReadOnly(),
+			Consistency: mount.Consistency(v.GetConsistency()),
 		}
 
-		sharedVolumeSource := c.client.GetSharedVolumeSource(mnt.Source)
+		sharedVolumeSource, sharedVolumeDriver := c.client.GetSharedVolume(mnt.Source)
 		if sharedVolumeSource != "" {
 			mnt.Source = sharedVolumeSource
 		}
 
-		if v.Bind.Propagation != "" {
+		if propagation := v.GetPropagation(); propagation != "" {
 			mnt.BindOptions = &mount.BindOptions{
-				Propagation: mount.Propagation(v.Bind.Propagation),
+				Propagation: mount.Propagation(propagation),
 			}
 		}
 
-		if v.Volume.NoCopy {
+		if mnt.Type == mount.TypeVolume && v.Volume.Driver == "" &&
+			sharedVolumeDriver != "" && sharedVolumeDriver != "local" {
+
+			// the shared volume needs to be looked up with its own driver
+			v.Volume.Driver = sharedVolumeDriver
+		}
+
+		if v.IsNoCopy() || v.Volume.Driver != "" || v.Volume.Labels != nil {
 			mnt.VolumeOptions = &mount.VolumeOptions{
-				NoCopy: true,
+				NoCopy: v.IsNoCopy(),
+				Labels: v.Volume.Labels,
 			}
-		}
 
-		if v.Tmpfs.Size != "" {
-			size, err := units.FromHumanSize(v.Tmpfs.Size)
-			if err != nil {
-				return nil, err
+			if v.Volume.Driver != "" {
+				mnt.VolumeOptions.DriverConfig = &mount.Driver{
+					Name:    v.Volume.Driver,
+					Options: v.Volume.DriverOpts,
+				}
 			}
+		}
 
+		if v.Tmpfs.Size != "" || v.Tmpfs.Mode != 0 {
 			mnt.TmpfsOptions = &mount.TmpfsOptions{
-				SizeBytes: size,
+				Mode: os.FileMode(v.Tmpfs.Mode),
+			}
+
+			if v.Tmpfs.Size != "" {
+				size, err := units.FromHumanSize(v.Tmpfs.Size)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				mnt.TmpfsOptions.SizeBytes = size
 			}
 		}
 
 		mounts = append(mounts, mnt)
 	}
 
-	return mounts, nil
+	return mounts, binds, nil
 }
 
 func (c *Component) parseVolumes() ([]*volume.Volume, error) {
@@ -71,33 +105,71 @@ func (c *Component) parseVolumes() ([]*volume.Volume, error) {
 
 	for idx, item := range c.Volumes {
 		if asString, ok := item.(string); ok {
-			v := volume.Volume{}
-			parts := strings.Split(asString, ":")
-
-			switch len(parts) {
-			case 1:
-				v.Target = parts[0]
-			case 2:
-				v.Source = parts[0]
-				v.Target = parts[1]
-			case 3:
-				v.Source = parts[0]
-				v.Target = parts[1]
-				v.Mode = parts[2]
+			v, err := volume.ParseShortSyntax(asString)
+			if err != nil {
+				return nil, err
 			}
 
-			converted[idx] = &v
+			converted[idx] = v
 		} else {
 			var v volume.Volume
 
-			err := mapstructure.Decode(item, &v)
+			decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+				Result:           &v,
+				WeaklyTypedInput: true,
+				ErrorUnused:      true,
+			})
 			if err != nil {
 				return nil, err
 			}
 
+			if err := decoder.Decode(item); err != nil {
+				return nil, errors.New(fmt.Sprintf("invalid volume definition: %+v : %s", item, err))
+			}
+
 			converted[idx] = &v
 		}
+
+		if err := converted[idx].Validate(); err != nil {
+			return nil, err
+		}
 	}
 
 	return converted, nil
 }
+
+// getMountsFrom returns the controller's mounts selected by `volumes_from`,
+// except the ones with a target the component already mounts something else to.
+func (c *Component) getMountsFrom(hostConfig *container.HostConfig) ([]mount.Mount, error) {
+	targets := map[string]bool{}
+
+	for _, mnt := range hostConfig.Mounts {
+		targets[path.Clean(mnt.Target)] = true
+	}
+
+	for _, bind := range hostConfig.Binds {
+		if v, err := volume.ParseShortSyntax(bind); err == nil {
+			targets[path.Clean(v.Target)] = true
+		}
+	}
+
+	var mounts []mount.Mount
+
+	for _, definition := range c.VolumesFrom {
+		selected, err := c.client.GetMountsFrom(definition)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mnt := range selected {
+			if targets[path.Clean(mnt.Target)] {
+				continue
+			}
+
+			targets[path.Clean(mnt.Target)] = true
+			mounts = append(mounts, mnt)
+		}
+	}
+
+	return mounts, nil
+}
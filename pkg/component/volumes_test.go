@@ -1,11 +1,17 @@
 package component
 
 import (
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/rycus86/podlike/pkg/volume"
 	"gopkg.in/yaml.v2"
+	"reflect"
+	"strings"
 	"testing"
 )
 
+var createHostPath = true
+
 func TestVolumes_ShortSyntax(t *testing.T) {
 	verifyVolumes(`
 volumes:
@@ -13,7 +19,7 @@ volumes:
   - sample:/c/named:ro
   - /c/target/only`,
 		t,
-		volume.Volume{Source: "/tmp/data", Target: "/c/data"},
+		volume.Volume{Source: "/tmp/data", Target: "/c/data", Bind: volume.BindOptions{CreateHostPath: &createHostPath}},
 		volume.Volume{Source: "sample", Target: "/c/named", Mode: "ro"},
 		volume.Volume{Target: "/c/target/only"})
 }
@@ -35,9 +41,7 @@ volumes:
 		t,
 		volume.Volume{Source: "/tmp/data", Target: "/c/data", Type: "bind"},
 		volume.Volume{Source: "sample", Target: "/c/named", Type: "volume", ReadOnly: true},
-		volume.Volume{Target: "/c/target/only", Type: "tmpfs", Tmpfs: struct {
-			Size string
-		}{"50m"}})
+		volume.Volume{Target: "/c/target/only", Type: "tmpfs", Tmpfs: volume.TmpfsOptions{Size: "50m"}})
 }
 
 func TestVolumes_MixedSyntax(t *testing.T) {
@@ -53,11 +57,199 @@ volumes:
     tmpfs:
       size: 50m`,
 		t,
-		volume.Volume{Source: "/tmp/data", Target: "/c/data"},
+		volume.Volume{Source: "/tmp/data", Target: "/c/data", Bind: volume.BindOptions{CreateHostPath: &createHostPath}},
 		volume.Volume{Source: "sample", Target: "/c/named", Type: "volume", ReadOnly: true},
-		volume.Volume{Target: "/c/target/only", Type: "tmpfs", Tmpfs: struct {
-			Size string
-		}{"50m"}})
+		volume.Volume{Target: "/c/target/only", Type: "tmpfs", Tmpfs: volume.TmpfsOptions{Size: "50m"}})
+}
+
+func TestVolumes_ShortSyntaxModes(t *testing.T) {
+	verifyVolumes(`
+volumes:
+  - /tmp/data:/c/data:ro,z
+  - /tmp/shared:/c/shared:rshared,cached
+  - sample:/c/named:nocopy
+  - C:\data:/c/windows
+  - c:/data:C:\windows:ro
+  - /tmp/with:colon:/c/colon
+  - /a:b:/target`,
+		t,
+		volume.Volume{Source: "/tmp/data", Target: "/c/data", Mode: "ro,z", Bind: volume.BindOptions{CreateHostPath: &createHostPath}},
+		volume.Volume{Source: "/tmp/shared", Target: "/c/shared", Mode: "rshared,cached", Bind: volume.BindOptions{CreateHostPath: &createHostPath}},
+		volume.Volume{Source: "sample", Target: "/c/named", Mode: "nocopy"},
+		volume.Volume{Source: "C:\\data", Target: "/c/windows", Bind: volume.BindOptions{CreateHostPath: &createHostPath}},
+		volume.Volume{Source: "c:/data", Target: "C:\\windows", Mode: "ro", Bind: volume.BindOptions{CreateHostPath: &createHostPath}},
+		volume.Volume{Source: "/tmp/with:colon", Target: "/c/colon", Bind: volume.BindOptions{CreateHostPath: &createHostPath}},
+		volume.Volume{Source: "/a:b", Target: "/target", Bind: volume.BindOptions{CreateHostPath: &createHostPath}})
+}
+
+func TestVolumes_LongSyntaxOptions(t *testing.T) {
+	verifyVolumes(`
+volumes:
+  - type: bind
+    source: /tmp/data
+    target: /c/data
+    consistency: delegated
+    bind:
+      propagation: rslave
+      create_host_path: true
+      selinux: Z
+  - type: volume
+    source: sample
+    target: /c/named
+    volume:
+      nocopy: true
+      driver: local
+      driver_opts:
+        type: nfs
+        o: addr=10.0.0.1
+      labels:
+        purpose: testing
+  - type: tmpfs
+    target: /c/tmp
+    tmpfs:
+      size: 1048576
+      mode: 01777`,
+		t,
+		volume.Volume{Type: "bind", Source: "/tmp/data", Target: "/c/data", Consistency: "delegated",
+			Bind: volume.BindOptions{Propagation: "rslave", CreateHostPath: &createHostPath, SELinux: "Z"}},
+		volume.Volume{Type: "volume", Source: "sample", Target: "/c/named",
+			Volume: volume.VolumeOptions{
+				NoCopy:     true,
+				Driver:     "local",
+				DriverOpts: map[string]string{"type": "nfs", "o": "addr=10.0.0.1"},
+				Labels:     map[string]string{"purpose": "testing"},
+			}},
+		volume.Volume{Type: "tmpfs", Target: "/c/tmp", Tmpfs: volume.TmpfsOptions{Size: "1048576", Mode: 01777}})
+}
+
+func TestVolumes_Mounts(t *testing.T) {
+	var config map[string][]interface{}
+	if err := yaml.Unmarshal([]byte(`
+volumes:
+  - /tmp/data:/c/data:ro,z
+  - C:\data:/c/windows
+  - /tmp/with:colon:/c/colon:ro
+  - type: bind
+    source: /tmp/existing
+    target: /c/existing
+    read_only: true
+    bind:
+      propagation: rshared
+  - sample:/c/named:nocopy
+  - type: volume
+    source: remote
+    target: /c/remote
+    volume:
+      driver: local
+      driver_opts:
+        type: nfs
+  - type: tmpfs
+    target: /c/tmp
+    tmpfs:
+      size: 10k
+      mode: 0700`), &config); err != nil {
+		t.Fatal("Failed to parse the configuration:", err)
+	}
+
+	component, _ := newMockComponent("test", map[string]string{})
+	component.Volumes = config["volumes"]
+
+	mounts, binds, err := component.getMounts()
+	if err != nil {
+		t.Fatal("Failed to get the mounts:", err)
+	}
+
+	if !reflect.DeepEqual(binds, []string{"/tmp/data:/c/data:ro,z", "C:\\data:/c/windows"}) {
+		t.Error("Unexpected binds:", binds)
+	}
+
+	expected := []mount.Mount{
+		{
+			Type: mount.TypeBind, Source: "/tmp/with:colon", Target: "/c/colon", ReadOnly: true,
+		},
+		{
+			Type: mount.TypeBind, Source: "/tmp/existing", Target: "/c/existing", ReadOnly: true,
+			BindOptions: &mount.BindOptions{Propagation: mount.PropagationRShared},
+		},
+		{
+			Type: mount.TypeVolume, Source: "sample", Target: "/c/named",
+			VolumeOptions: &mount.VolumeOptions{NoCopy: true},
+		},
+		{
+			Type: mount.TypeVolume, Source: "remote", Target: "/c/remote",
+			VolumeOptions: &mount.VolumeOptions{
+				DriverConfig: &mount.Driver{Name: "local", Options: map[string]string{"type": "nfs"}},
+			},
+		},
+		{
+			Type: mount.TypeTmpfs, Target: "/c/tmp",
+			TmpfsOptions: &mount.TmpfsOptions{SizeBytes: 10000, Mode: 0700},
+		},
+	}
+
+	if !reflect.DeepEqual(mounts, expected) {
+		t.Errorf("Unexpected mounts:\n%+v\n%+v", mounts, expected)
+	}
+}
+
+func TestVolumes_MountsFrom(t *testing.T) {
+	component, _ := newMockComponent("test", map[string]string{})
+	component.VolumesFrom = []string{"first", "second"}
+
+	mounts, err := component.getMountsFrom(&container.HostConfig{
+		Binds: []string{"/tmp/data:/data:ro"},
+	})
+	if err != nil {
+		t.Fatal("Failed to get the mounts:", err)
+	}
+
+	expected := []mount.Mount{
+		{Type: mount.TypeBind, Source: "/etc/first", Target: "/etc/first"},
+		{Type: mount.TypeBind, Source: "/etc/second", Target: "/etc/second"},
+	}
+
+	if !reflect.DeepEqual(mounts, expected) {
+		t.Errorf("Unexpected mounts:\n%+v\n%+v", mounts, expected)
+	}
+}
+
+func TestVolumes_Invalid(t *testing.T) {
+	for definition, expectedError := range map[string]string{
+		"/tmp/data:/c/data:ro,rw":                                                             "conflicting modes",
+		"/tmp/data:/c/data:nocopy":                                                            "nocopy is only supported for volume mounts",
+		"sample:/c/data:rshared":                                                              "propagation is only supported for bind mounts",
+		"sample:/c/data:Z":                                                                    "SELinux relabeling is only supported for bind mounts",
+		"{type: unknown, target: /c/data}":                                                    "unknown volume type",
+		"{type: bind, target: /c/data}":                                                       "bind mounts need a source",
+		"{type: volume, source: sample}":                                                      "the target is missing",
+		"{type: tmpfs, source: x, target: /c/tmp}":                                            "tmpfs mounts do not support a source",
+		"{type: bind, source: /tmp, target: /c, unknown: 1}":                                  "invalid keys: unknown",
+		"{type: bind, source: /tmp, target: /c, consistency: eventual}":                       "invalid consistency",
+		"{type: bind, source: /tmp, target: /c, bind: {propagation: everywhere}}":             "invalid propagation",
+		"{type: bind, source: /tmp, target: /c, bind: {selinux: x}}":                          "invalid SELinux relabeling",
+		"{type: bind, source: /tmp, target: /c, bind: {selinux: z, create_host_path: false}}": "create_host_path disabled",
+		"/tmp/with:colon:/c/colon:z":                                                          "not supported for paths with colons",
+		"{type: bind, source: /tmp, target: /c, volume: {nocopy: true}}":                      "volume options are only supported",
+		"{type: bind, source: /tmp, target: /c, tmpfs: {size: 1m}}":                           "tmpfs options are only supported",
+		"{type: volume, source: x, target: /c, bind: {propagation: shared}}":                  "bind options are only supported",
+		"{type: volume, source: x, target: /c, volume: {subpath: sub}}":                       "subpaths are not supported",
+		"{type: volume, source: x, target: /c, volume: {driver_opts: {type: nfs}}}":           "driver options need a volume driver",
+		"{type: volume, target: /c, volume: {driver: local}}":                                 "only supported for named volumes",
+		"{type: tmpfs, target: /c, tmpfs: {mode: 077777}}":                                    "invalid mode",
+	} {
+		var item interface{}
+		if err := yaml.Unmarshal([]byte(definition), &item); err != nil {
+			t.Fatal("Failed to parse:", definition, err)
+		}
+
+		component := &Component{Volumes: []interface{}{item}}
+
+		if _, err := component.parseVolumes(); err == nil {
+			t.Error("Expected to fail:", definition)
+		} else if !strings.Contains(err.Error(), expectedError) {
+			t.Error("Unexpected error for", definition, ":", err)
+		}
+	}
 }
 
 func verifyVolumes(yamlConfig string, t *testing.T, expected ...volume.Volume) {
@@ -75,7 +267,7 @@ func verifyVolumes(yamlConfig string, t *testing.T, expected ...volume.Volume) {
 	for idx, exp := range expected {
 		act := *actual[idx]
 
-		if exp != act {
+		if !reflect.DeepEqual(exp, act) {
 			t.Errorf("The actual volume doesn't match the expected:\n(%+v) \n(%+v)", act, exp)
 		}
 	}
@@ -18,6 +18,12 @@ func (c *Component) WaitFor(exitChan chan<- ExitEvent) {
 	for {
 		select {
 		case exit := <-waitChan:
+			if exit.Error == nil && exit.StatusCode == 0 {
+				c.exportFiles(ConditionExited)
+			} else {
+				c.failExports("the component has exited with an error")
+			}
+
 			if exit.Error != nil {
 				exitChan <- ExitEvent{
 					Component: c,
@@ -31,6 +37,8 @@ func (c *Component) WaitFor(exitChan chan<- ExitEvent) {
 			}
 
 		case err := <-errChan:
+			c.failExports("failed to wait for the component")
+
 			exitChan <- ExitEvent{
 				Component: c,
 				Error:     err,
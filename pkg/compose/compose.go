@@ -0,0 +1,384 @@
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rycus86/podlike/pkg/interpolation"
+	"gopkg.in/yaml.v2"
+)
+
+// Project is the merged result of one or more Compose files.
+type Project struct {
+	// the directory of the first file, relative paths are resolved against it
+	WorkingDir string
+
+	Services map[string]map[interface{}]interface{}
+	Volumes  map[string]map[interface{}]interface{}
+	Secrets  map[string]map[interface{}]interface{}
+	Configs  map[string]map[interface{}]interface{}
+}
+
+type loader struct {
+	lookup interpolation.Lookup
+
+	// the parsed files by their absolute paths, for extends
+	files map[string]map[interface{}]interface{}
+}
+
+// Load reads the Compose files, replaces the variables in them,
+// resolves the `extends` references, then merges them in order,
+// so the later files override the earlier ones.
+func Load(files []string, lookup interpolation.Lookup) (*Project, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no Compose files given")
+	}
+
+	project := &Project{
+		WorkingDir: filepath.Dir(files[0]),
+
+		Services: map[string]map[interface{}]interface{}{},
+		Volumes:  map[string]map[interface{}]interface{}{},
+		Secrets:  map[string]map[interface{}]interface{}{},
+		Configs:  map[string]map[interface{}]interface{}{},
+	}
+
+	l := &loader{
+		lookup: lookup,
+		files:  map[string]map[interface{}]interface{}{},
+	}
+
+	for _, filename := range files {
+		contents, err := l.load(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		services, err := asMappings(contents["services"], "services", filename)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range sortedNames(services) {
+			service, err := l.resolveExtends(filename, name, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			if existing, ok := project.Services[name]; ok {
+				project.Services[name] = MergeService(existing, service)
+			} else {
+				project.Services[name] = service
+			}
+		}
+
+		for key, target := range map[string]map[string]map[interface{}]interface{}{
+			"volumes": project.Volumes,
+			"secrets": project.Secrets,
+			"configs": project.Configs,
+		} {
+			definitions, err := asMappings(contents[key], key, filename)
+			if err != nil {
+				return nil, err
+			}
+
+			for name, definition := range definitions {
+				if existing, ok := target[name]; ok {
+					target[name] = MergeNested(existing, definition).(map[interface{}]interface{})
+				} else {
+					target[name] = definition
+				}
+			}
+		}
+	}
+
+	for _, service := range project.Services {
+		for key := range service {
+			// drop the extension fields, like x-defaults
+			if name, ok := key.(string); ok && strings.HasPrefix(name, "x-") {
+				delete(service, key)
+			}
+		}
+	}
+
+	return project, nil
+}
+
+// load parses a Compose file, and replaces the variables in it
+func (l *loader) load(filename string) (map[interface{}]interface{}, error) {
+	absolute, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if contents, ok := l.files[absolute]; ok {
+		return contents, nil
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid Compose file %s : %s", filename, err))
+	}
+
+	interpolated, err := interpolation.InterpolateTree(raw, l.lookup)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("failed to interpolate %s : %s", filename, err))
+	}
+
+	contents, ok := interpolated.(map[interface{}]interface{})
+	if !ok {
+		if interpolated != nil {
+			return nil, errors.New(fmt.Sprintf("invalid Compose file %s : expected a mapping", filename))
+		}
+
+		contents = map[interface{}]interface{}{}
+	}
+
+	l.files[absolute] = contents
+
+	return contents, nil
+}
+
+// resolveExtends returns the service from the file given,
+// merged on top of the service it extends, if any.
+func (l *loader) resolveExtends(filename, name string, chain []string) (map[interface{}]interface{}, error) {
+	link := filename + "#" + name
+
+	for _, previous := range chain {
+		if previous == link {
+			return nil, errors.New(fmt.Sprintf(
+				"circular extends for the %s service: %s", name, strings.Join(append(chain, link), " -> ")))
+		}
+	}
+
+	contents, err := l.load(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := asMappings(contents["services"], "services", filename)
+	if err != nil {
+		return nil, err
+	}
+
+	service, ok := services[name]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("the %s service is not defined in %s", name, filename))
+	}
+
+	extends, hasExtends := service["extends"]
+	if !hasExtends {
+		return resolveEnvFiles(copyMapping(service), filepath.Dir(filename)), nil
+	}
+
+	baseFile, baseName := filename, ""
+
+	switch typed := extends.(type) {
+	case string:
+		baseName = typed
+
+	case map[interface{}]interface{}:
+		baseName, _ = typed["service"].(string)
+
+		if file, ok := typed["file"].(string); ok && file != "" {
+			baseFile = file
+
+			if !filepath.IsAbs(file) {
+				baseFile = filepath.Join(filepath.Dir(filename), file)
+			}
+		}
+
+	}
+
+	if baseName == "" {
+		return nil, errors.New(fmt.Sprintf("invalid extends for the %s service in %s : %+v", name, filename, extends))
+	}
+
+	base, err := l.resolveExtends(baseFile, baseName, append(chain, link))
+	if err != nil {
+		return nil, err
+	}
+
+	// these are not inherited from the extended service
+	for _, key := range []string{"depends_on", "links", "volumes_from"} {
+		delete(base, key)
+	}
+
+	local := resolveEnvFiles(copyMapping(service), filepath.Dir(filename))
+	delete(local, "extends")
+
+	return MergeService(base, local), nil
+}
+
+// EnabledServices returns the services without profiles, and the ones with
+// at least one of the profiles given, and checks that their dependencies are enabled too.
+func (p *Project) EnabledServices(profiles []string) (map[string]map[interface{}]interface{}, error) {
+	active := map[string]bool{}
+	for _, profile := range profiles {
+		active[profile] = true
+	}
+
+	enabled := map[string]map[interface{}]interface{}{}
+
+	for name, service := range p.Services {
+		serviceProfiles := asStrings(service["profiles"])
+
+		isEnabled := len(serviceProfiles) == 0
+
+		for _, profile := range serviceProfiles {
+			if active[profile] {
+				isEnabled = true
+			}
+		}
+
+		if isEnabled {
+			service = copyMapping(service)
+			delete(service, "profiles")
+
+			enabled[name] = service
+		}
+	}
+
+	for _, name := range sortedNames(enabled) {
+		for _, dependency := range getDependencies(enabled[name]) {
+			if _, ok := p.Services[dependency]; !ok {
+				return nil, errors.New(fmt.Sprintf(
+					"the %s service depends on %s, that is not defined", name, dependency))
+			}
+
+			if _, ok := enabled[dependency]; !ok {
+				return nil, errors.New(fmt.Sprintf(
+					"the %s service depends on %s, that is not enabled by the active profiles %v",
+					name, dependency, profiles))
+			}
+		}
+	}
+
+	return enabled, nil
+}
+
+func getDependencies(service map[interface{}]interface{}) []string {
+	if asMap, ok := service["depends_on"].(map[interface{}]interface{}); ok {
+		var dependencies []string
+
+		for key := range asMap {
+			dependencies = append(dependencies, fmt.Sprintf("%v", key))
+		}
+
+		sort.Strings(dependencies)
+
+		return dependencies
+	}
+
+	return asStrings(service["depends_on"])
+}
+
+func asMappings(value interface{}, key, filename string) (map[string]map[interface{}]interface{}, error) {
+	result := map[string]map[interface{}]interface{}{}
+
+	if value == nil {
+		return result, nil
+	}
+
+	asMap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("invalid %s in %s : expected a mapping", key, filename))
+	}
+
+	for name, definition := range asMap {
+		if definition == nil {
+			result[fmt.Sprintf("%v", name)] = map[interface{}]interface{}{}
+		} else if definitionMap, ok := definition.(map[interface{}]interface{}); ok {
+			result[fmt.Sprintf("%v", name)] = definitionMap
+		} else {
+			return nil, errors.New(fmt.Sprintf("invalid %s definition for %v in %s : expected a mapping", key, name, filename))
+		}
+	}
+
+	return result, nil
+}
+
+func asStrings(value interface{}) []string {
+	var result []string
+
+	for _, item := range asSequence(value) {
+		result = append(result, fmt.Sprintf("%v", item))
+	}
+
+	return result
+}
+
+// resolveEnvFiles makes the relative paths in the env_file property of the service
+// relative to the directory of the Compose file it is defined in.
+func resolveEnvFiles(service map[interface{}]interface{}, dir string) map[interface{}]interface{} {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+
+		return filepath.Join(dir, path)
+	}
+
+	switch envFiles := service["env_file"].(type) {
+	case string:
+		service["env_file"] = resolve(envFiles)
+
+	case []interface{}:
+		resolved := make([]interface{}, 0, len(envFiles))
+
+		for _, item := range envFiles {
+			switch typed := item.(type) {
+			case string:
+				resolved = append(resolved, resolve(typed))
+
+			case map[interface{}]interface{}:
+				reference := copyMapping(typed)
+				if path, ok := reference["path"].(string); ok {
+					reference["path"] = resolve(path)
+				}
+
+				resolved = append(resolved, reference)
+
+			default:
+				resolved = append(resolved, item)
+
+			}
+		}
+
+		service["env_file"] = resolved
+	}
+
+	return service
+}
+
+func copyMapping(source map[interface{}]interface{}) map[interface{}]interface{} {
+	copied := make(map[interface{}]interface{}, len(source))
+
+	for key, value := range source {
+		copied[key] = value
+	}
+
+	return copied
+}
+
+func sortedNames(m map[string]map[interface{}]interface{}) []string {
+	names := make([]string, 0, len(m))
+
+	for name := range m {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
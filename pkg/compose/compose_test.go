@@ -0,0 +1,184 @@
+package compose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testLookup(variables map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}
+}
+
+func TestLoad_SingleFile(t *testing.T) {
+	project, err := Load([]string{"testdata/base.yml"}, testLookup(nil))
+	if err != nil {
+		t.Fatal("Failed to load the project:", err)
+	}
+
+	if project.WorkingDir != "testdata" {
+		t.Error("Unexpected working directory:", project.WorkingDir)
+	}
+
+	app := project.Services["app"]
+
+	if app["image"] != "sample/app:1.0" {
+		t.Error("Unexpected image:", app["image"])
+	}
+
+	if _, ok := app["x-notes"]; ok {
+		t.Error("Unexpected extension field:", app)
+	}
+
+	if app["restart"] != "always" {
+		t.Error("Unexpected anchored property:", app["restart"])
+	}
+
+	worker := project.Services["worker"]
+
+	if worker["image"] != "sample/app:1.0" || worker["command"] != "work" {
+		t.Error("Unexpected extended service:", worker)
+	}
+
+	if _, ok := worker["depends_on"]; ok {
+		t.Error("Unexpected dependencies on an extended service:", worker["depends_on"])
+	}
+
+	if env := worker["environment"]; !reflect.DeepEqual(env, []interface{}{"LOG_LEVEL=info", "REGION=eu-west-1", "ROLE=worker"}) {
+		t.Error("Unexpected environment:", env)
+	}
+
+	metrics := project.Services["metrics"]
+
+	if metrics["image"] != "sample/exporter" {
+		t.Error("Unexpected service extended from another file:", metrics)
+	}
+
+	if env := metrics["environment"]; !reflect.DeepEqual(env, []interface{}{"PORT=9100", "TARGET=app"}) {
+		t.Error("Unexpected environment:", env)
+	}
+
+	if _, ok := project.Volumes["data"]; !ok {
+		t.Error("Missing top-level volume:", project.Volumes)
+	}
+}
+
+func TestLoad_Override(t *testing.T) {
+	project, err := Load([]string{"testdata/base.yml", "testdata/override.yml"}, testLookup(map[string]string{
+		"APP_VERSION": "1.5",
+	}))
+	if err != nil {
+		t.Fatal("Failed to load the project:", err)
+	}
+
+	app := project.Services["app"]
+
+	expected := map[string]interface{}{
+		"image":       "sample/app:2.0",
+		"command":     "serve",
+		"environment": []interface{}{"DEBUG", "LOG_LEVEL=debug", "REGION=eu-west-1"},
+		"volumes":     []interface{}{"/tmp/data:/var/data", "./conf:/etc/app:ro", "logs:/var/log"},
+		"cap_add":     []interface{}{"NET_ADMIN", "SYS_TIME"},
+	}
+
+	for key, value := range expected {
+		if !reflect.DeepEqual(app[key], value) {
+			t.Errorf("Unexpected %s : %+v", key, app[key])
+		}
+	}
+
+	healthcheck := project.Services["cache"]["healthcheck"].(map[interface{}]interface{})
+
+	if healthcheck["interval"] != "5s" || healthcheck["retries"] != 3 || healthcheck["test"] == nil {
+		t.Error("Unexpected healthcheck:", healthcheck)
+	}
+
+	if project.Volumes["data"]["name"] != "shared-data" {
+		t.Error("Unexpected volume definition:", project.Volumes["data"])
+	}
+
+	if _, ok := project.Volumes["logs"]; !ok {
+		t.Error("Missing top-level volume:", project.Volumes)
+	}
+}
+
+func TestLoad_EnvFiles(t *testing.T) {
+	project, err := Load([]string{"testdata/env/docker-compose.yml"}, testLookup(nil))
+	if err != nil {
+		t.Fatal("Failed to load:", err)
+	}
+
+	expected := []interface{}{
+		map[interface{}]interface{}{"path": "testdata/env/nested/common.env"},
+		"testdata/env/app.env",
+		map[interface{}]interface{}{"path": "/etc/app/optional.env", "required": false},
+	}
+
+	if envFiles := project.Services["app"]["env_file"]; !reflect.DeepEqual(envFiles, expected) {
+		t.Error("Unexpected env files:", envFiles)
+	}
+
+	if envFile := project.Services["single"]["env_file"]; envFile != "testdata/env/single.env" {
+		t.Error("Unexpected env file:", envFile)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	for _, files := range [][]string{
+		{},
+		{"testdata/missing.yml"},
+		{"testdata/circular.yml"},
+	} {
+		if _, err := Load(files, testLookup(nil)); err == nil {
+			t.Error("Expected to fail:", files)
+		}
+	}
+
+	_, err := Load([]string{"testdata/circular.yml"}, testLookup(nil))
+	if err == nil || !strings.Contains(err.Error(), "circular extends") {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func TestEnabledServices(t *testing.T) {
+	project, err := Load([]string{"testdata/base.yml"}, testLookup(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the exporter depends on an undefined service
+	delete(project.Services, "metrics")
+
+	for _, item := range []struct {
+		Profiles []string
+		Expected []string
+	}{
+		{nil, []string{"app", "cache", "worker"}},
+		{[]string{"other"}, []string{"app", "cache", "worker"}},
+		{[]string{"debug"}, []string{"app", "cache", "debug", "worker"}},
+	} {
+		services, err := project.EnabledServices(item.Profiles)
+		if err != nil {
+			t.Fatal("Failed to get the enabled services:", err)
+		}
+
+		if names := sortedNames(services); !reflect.DeepEqual(names, item.Expected) {
+			t.Error("Unexpected services for", item.Profiles, ":", names)
+		}
+
+		for _, service := range services {
+			if _, ok := service["profiles"]; ok {
+				t.Error("Unexpected profiles:", service)
+			}
+		}
+	}
+
+	project.Services["cache"]["profiles"] = []interface{}{"cache"}
+
+	if _, err := project.EnabledServices(nil); err == nil || !strings.Contains(err.Error(), "not enabled") {
+		t.Error("Expected to fail for a disabled dependency:", err)
+	}
+}
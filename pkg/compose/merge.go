@@ -0,0 +1,342 @@
+package compose
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// the properties merged by their keys, in either the mapping or the list form, and the key separators
+var mappingProperties = map[string]string{
+	"environment": "=",
+	"labels":      "=",
+	"sysctls":     "=",
+	"extra_hosts": ":",
+}
+
+// the list properties concatenated, without duplicates
+var sequenceProperties = map[string]bool{
+	"cap_add":             true,
+	"cap_drop":            true,
+	"device_cgroup_rules": true,
+	"dns":                 true,
+	"dns_opt":             true,
+	"dns_search":          true,
+	"env_file":            true,
+	"expose":              true,
+	"external_links":      true,
+	"group_add":           true,
+	"links":               true,
+	"ports":               true,
+	"security_opt":        true,
+	"tmpfs":               true,
+	"volumes_from":        true,
+}
+
+// the nested properties merged recursively
+var nestedProperties = map[string]bool{
+	"blkio_config": true,
+	"build":        true,
+	"healthcheck":  true,
+	"logging":      true,
+	"storage_opt":  true,
+	"ulimits":      true,
+}
+
+// MergeService merges the override into the base service definition,
+// following the rules of Compose for multiple files:
+//
+//   - single values, like image or command, are replaced
+//   - environment, labels, sysctls and extra_hosts are merged by their keys
+//   - volumes and devices are merged by their target paths in the container
+//   - secrets and configs are merged by their sources
+//   - lists, like cap_add or ports, are concatenated without duplicates
+//   - nested mappings, like healthcheck or ulimits, are merged recursively
+func MergeService(base, override map[interface{}]interface{}) map[interface{}]interface{} {
+	merged := make(map[interface{}]interface{}, len(base)+len(override))
+
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for rawKey, value := range override {
+		key := fmt.Sprintf("%v", rawKey)
+
+		existing, ok := merged[rawKey]
+		if !ok || existing == nil || value == nil {
+			merged[rawKey] = value
+			continue
+		}
+
+		if separator, ok := mappingProperties[key]; ok {
+			merged[rawKey] = mergeMappings(existing, value, separator)
+
+		} else if sequenceProperties[key] {
+			merged[rawKey] = mergeSequences(existing, value)
+
+		} else if key == "volumes" {
+			merged[rawKey] = mergeByKey(existing, value, volumeTarget)
+
+		} else if key == "devices" {
+			merged[rawKey] = mergeByKey(existing, value, deviceTarget)
+
+		} else if key == "secrets" || key == "configs" {
+			merged[rawKey] = mergeByKey(existing, value, referenceSource)
+
+		} else if key == "depends_on" {
+			merged[rawKey] = mergeDependencies(existing, value)
+
+		} else if key == "logging" {
+			merged[rawKey] = mergeLogging(existing, value)
+
+		} else if nestedProperties[key] {
+			merged[rawKey] = MergeNested(existing, value)
+
+		} else {
+			merged[rawKey] = value
+
+		}
+	}
+
+	return merged
+}
+
+// MergeNested merges mappings recursively, other values are replaced by the override.
+func MergeNested(base, override interface{}) interface{} {
+	baseMap, isBaseMap := base.(map[interface{}]interface{})
+	overrideMap, isOverrideMap := override.(map[interface{}]interface{})
+
+	if !isBaseMap || !isOverrideMap {
+		return override
+	}
+
+	merged := make(map[interface{}]interface{}, len(baseMap)+len(overrideMap))
+
+	for key, value := range baseMap {
+		merged[key] = value
+	}
+
+	for key, value := range overrideMap {
+		if existing, ok := merged[key]; ok {
+			merged[key] = MergeNested(existing, value)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// mergeMappings merges the items by key, and returns them in the list form, like KEY=value
+func mergeMappings(base, override interface{}, separator string) interface{} {
+	var (
+		keys   []string
+		values = map[string]*string{}
+	)
+
+	for _, source := range []interface{}{base, override} {
+		for _, item := range asKeyValues(source, separator) {
+			if _, ok := values[item.key]; !ok {
+				keys = append(keys, item.key)
+			}
+
+			values[item.key] = item.value
+		}
+	}
+
+	sort.Strings(keys)
+
+	merged := make([]interface{}, 0, len(keys))
+
+	for _, key := range keys {
+		if value := values[key]; value != nil {
+			merged = append(merged, key+separator+*value)
+		} else {
+			merged = append(merged, key)
+		}
+	}
+
+	return merged
+}
+
+type keyValue struct {
+	key   string
+	value *string
+}
+
+func asKeyValues(source interface{}, separator string) []keyValue {
+	var items []keyValue
+
+	switch typed := source.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range typed {
+			item := keyValue{key: fmt.Sprintf("%v", key)}
+
+			if value != nil {
+				asString := fmt.Sprintf("%v", value)
+				item.value = &asString
+			}
+
+			items = append(items, item)
+		}
+
+		sort.Slice(items, func(i, j int) bool { return items[i].key < items[j].key })
+
+	case []interface{}:
+		for _, value := range typed {
+			parts := strings.SplitN(fmt.Sprintf("%v", value), separator, 2)
+
+			item := keyValue{key: parts[0]}
+			if len(parts) == 2 {
+				item.value = &parts[1]
+			}
+
+			items = append(items, item)
+		}
+
+	}
+
+	return items
+}
+
+func mergeSequences(base, override interface{}) interface{} {
+	var (
+		merged []interface{}
+		seen   = map[string]bool{}
+	)
+
+	for _, source := range []interface{}{base, override} {
+		for _, item := range asSequence(source) {
+			key := fmt.Sprintf("%v", item)
+
+			if !seen[key] {
+				seen[key] = true
+				merged = append(merged, item)
+			}
+		}
+	}
+
+	return merged
+}
+
+// mergeByKey replaces the items of the base with the same key in the override,
+// and adds the new items from the override to the end
+func mergeByKey(base, override interface{}, keyOf func(item interface{}) string) interface{} {
+	merged := append([]interface{}{}, asSequence(base)...)
+
+	positions := map[string]int{}
+	for idx, item := range merged {
+		positions[keyOf(item)] = idx
+	}
+
+	for _, item := range asSequence(override) {
+		key := keyOf(item)
+
+		if idx, ok := positions[key]; ok {
+			merged[idx] = item
+		} else {
+			positions[key] = len(merged)
+			merged = append(merged, item)
+		}
+	}
+
+	return merged
+}
+
+func mergeDependencies(base, override interface{}) interface{} {
+	_, isBaseMap := base.(map[interface{}]interface{})
+	_, isOverrideMap := override.(map[interface{}]interface{})
+
+	if !isBaseMap && !isOverrideMap {
+		return mergeSequences(base, override)
+	}
+
+	return MergeNested(asDependencyMap(base), asDependencyMap(override))
+}
+
+func asDependencyMap(value interface{}) map[interface{}]interface{} {
+	if asMap, ok := value.(map[interface{}]interface{}); ok {
+		return asMap
+	}
+
+	asMap := map[interface{}]interface{}{}
+
+	for _, item := range asSequence(value) {
+		asMap[item] = map[interface{}]interface{}{"condition": "service_started"}
+	}
+
+	return asMap
+}
+
+// mergeLogging drops the options of the base, if the override changes the driver
+func mergeLogging(base, override interface{}) interface{} {
+	baseMap, isBaseMap := base.(map[interface{}]interface{})
+	overrideMap, isOverrideMap := override.(map[interface{}]interface{})
+
+	if isBaseMap && isOverrideMap {
+		if driver, ok := overrideMap["driver"]; ok && driver != baseMap["driver"] {
+			return override
+		}
+	}
+
+	return MergeNested(base, override)
+}
+
+func asSequence(value interface{}) []interface{} {
+	if sequence, ok := value.([]interface{}); ok {
+		return sequence
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	return []interface{}{value}
+}
+
+// volumeTarget returns the target path of the short, or the long volume syntax
+func volumeTarget(item interface{}) string {
+	if asMap, ok := item.(map[interface{}]interface{}); ok {
+		return path.Clean(fmt.Sprintf("%v", asMap["target"]))
+	}
+
+	parts := splitVolume(fmt.Sprintf("%v", item))
+
+	if len(parts) == 1 {
+		return path.Clean(parts[0])
+	}
+
+	return path.Clean(parts[1])
+}
+
+// splitVolume splits the short volume syntax, keeping Windows drive letters in the source
+func splitVolume(definition string) []string {
+	parts := strings.Split(definition, ":")
+
+	if len(parts) > 1 && len(parts[0]) == 1 && strings.HasPrefix(parts[1], "\\") {
+		parts = append([]string{parts[0] + ":" + parts[1]}, parts[2:]...)
+	}
+
+	return parts
+}
+
+// deviceTarget returns the path of the device in the container, like /dev/xvdc for /dev/sdc:/dev/xvdc:rwm
+func deviceTarget(item interface{}) string {
+	parts := strings.Split(fmt.Sprintf("%v", item), ":")
+
+	if len(parts) == 1 {
+		return parts[0]
+	}
+
+	return parts[1]
+}
+
+// referenceSource returns the source of a secret or config reference
+func referenceSource(item interface{}) string {
+	if asMap, ok := item.(map[interface{}]interface{}); ok {
+		return fmt.Sprintf("%v", asMap["source"])
+	}
+
+	return fmt.Sprintf("%v", item)
+}
@@ -0,0 +1,126 @@
+package compose
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func parseService(t *testing.T, definition string) map[interface{}]interface{} {
+	var service map[interface{}]interface{}
+
+	if err := yaml.Unmarshal([]byte(definition), &service); err != nil {
+		t.Fatal("Invalid service definition:", err)
+	}
+
+	return service
+}
+
+func TestMergeService(t *testing.T) {
+	base := parseService(t, `
+image: sample/app:1.0
+command: [serve, --port, "8080"]
+environment:
+  A: "1"
+  B: "2"
+labels: [com.example.team=platform]
+sysctls: {net.core.somaxconn: 1024}
+volumes:
+  - data:/var/data
+  - type: bind
+    source: /etc/app
+    target: /etc/app
+devices: [/dev/sda:/dev/xvda]
+secrets: [db_password, {source: api_key, target: key}]
+cap_add: [NET_ADMIN]
+dns: 8.8.8.8
+depends_on: [db]
+ulimits:
+  nofile: {soft: 1024, hard: 2048}
+  nproc: 512
+logging:
+  driver: json-file
+  options: {max-size: 10m}
+`)
+
+	override := parseService(t, `
+image: sample/app:2.0
+command: work
+environment: [B=3, C]
+labels:
+  com.example.env: prod
+sysctls: [net.core.somaxconn=2048]
+volumes:
+  - type: volume
+    source: other
+    target: /var/data/
+  - logs:/var/log
+devices: [/dev/sdb:/dev/xvda, /dev/sdc:/dev/xvdc]
+secrets: [{source: api_key, target: api}]
+cap_add: [NET_ADMIN, SYS_TIME]
+dns: [8.8.8.8, 1.1.1.1]
+depends_on:
+  cache: {condition: service_healthy}
+ulimits:
+  nofile: {soft: 4096}
+logging:
+  driver: syslog
+`)
+
+	merged := MergeService(base, override)
+
+	expected := parseService(t, `
+image: sample/app:2.0
+command: work
+environment: [A=1, B=3, C]
+labels: [com.example.env=prod, com.example.team=platform]
+sysctls: [net.core.somaxconn=2048]
+volumes:
+  - type: volume
+    source: other
+    target: /var/data/
+  - type: bind
+    source: /etc/app
+    target: /etc/app
+  - logs:/var/log
+devices: [/dev/sdb:/dev/xvda, /dev/sdc:/dev/xvdc]
+secrets: [db_password, {source: api_key, target: api}]
+cap_add: [NET_ADMIN, SYS_TIME]
+dns: [8.8.8.8, 1.1.1.1]
+depends_on:
+  db: {condition: service_started}
+  cache: {condition: service_healthy}
+ulimits:
+  nofile: {soft: 4096, hard: 2048}
+  nproc: 512
+logging:
+  driver: syslog
+`)
+
+	for key, value := range expected {
+		if !reflect.DeepEqual(merged[key], value) {
+			t.Errorf("Unexpected %s : %+v (expected %+v)", key, merged[key], value)
+		}
+	}
+
+	if len(merged) != len(expected) {
+		t.Error("Unexpected properties:", merged)
+	}
+
+	if base["image"] != "sample/app:1.0" {
+		t.Error("The base service has changed:", base["image"])
+	}
+}
+
+func TestMergeService_Logging(t *testing.T) {
+	merged := MergeService(
+		parseService(t, "logging: {driver: json-file, options: {max-size: 10m}}"),
+		parseService(t, "logging: {options: {max-file: '3'}}"))
+
+	expected := parseService(t, "logging: {driver: json-file, options: {max-size: 10m, max-file: '3'}}")
+
+	if !reflect.DeepEqual(merged, expected) {
+		t.Error("Unexpected logging:", merged)
+	}
+}
@@ -0,0 +1,169 @@
+package compose
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Conflict is a property both the base and the patch define, with different values.
+type Conflict struct {
+	Field string
+	Base  interface{}
+	Patch interface{}
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, formatValue(c.Base), formatValue(c.Patch))
+}
+
+// PatchService merges the patch into the base service with the same rules as MergeService,
+// and returns the properties where the patch replaces a different value of the base.
+func PatchService(base, patch map[interface{}]interface{}) (map[interface{}]interface{}, []Conflict) {
+	var conflicts []Conflict
+
+	for _, rawKey := range sortedKeys(patch) {
+		key := fmt.Sprintf("%v", rawKey)
+
+		existing, ok := base[rawKey]
+		if !ok || existing == nil {
+			continue
+		}
+
+		value := patch[rawKey]
+
+		if separator, ok := mappingProperties[key]; ok {
+			conflicts = append(conflicts, findKeyValueConflicts(key, existing, value, separator)...)
+
+		} else if sequenceProperties[key] {
+			// concatenated, these can't conflict
+
+		} else if key == "volumes" {
+			conflicts = append(conflicts, findItemConflicts(key, existing, value, volumeTarget)...)
+
+		} else if key == "devices" {
+			conflicts = append(conflicts, findItemConflicts(key, existing, value, deviceTarget)...)
+
+		} else if key == "secrets" || key == "configs" {
+			conflicts = append(conflicts, findItemConflicts(key, existing, value, referenceSource)...)
+
+		} else if key == "depends_on" {
+			conflicts = append(conflicts, findNestedConflicts(key, asDependencyMap(existing), asDependencyMap(value))...)
+
+		} else {
+			conflicts = append(conflicts, findNestedConflicts(key, existing, value)...)
+
+		}
+	}
+
+	return MergeService(base, patch), conflicts
+}
+
+func findKeyValueConflicts(field string, base, patch interface{}, separator string) []Conflict {
+	var conflicts []Conflict
+
+	values := map[string]*string{}
+
+	for _, item := range asKeyValues(base, separator) {
+		values[item.key] = item.value
+	}
+
+	for _, item := range asKeyValues(patch, separator) {
+		existing, ok := values[item.key]
+		if !ok {
+			continue
+		}
+
+		if (existing == nil) != (item.value == nil) || (existing != nil && *existing != *item.value) {
+			conflicts = append(conflicts, Conflict{
+				Field: field + "." + item.key,
+				Base:  derefOrNil(existing),
+				Patch: derefOrNil(item.value),
+			})
+		}
+	}
+
+	return conflicts
+}
+
+func findItemConflicts(field string, base, patch interface{}, keyOf func(item interface{}) string) []Conflict {
+	var conflicts []Conflict
+
+	items := map[string]interface{}{}
+
+	for _, item := range asSequence(base) {
+		items[keyOf(item)] = item
+	}
+
+	for _, item := range asSequence(patch) {
+		key := keyOf(item)
+
+		if existing, ok := items[key]; ok && !reflect.DeepEqual(existing, item) {
+			conflicts = append(conflicts, Conflict{
+				Field: fmt.Sprintf("%s[%s]", field, key),
+				Base:  existing,
+				Patch: item,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// findNestedConflicts compares mappings recursively, and other values as a whole
+func findNestedConflicts(field string, base, patch interface{}) []Conflict {
+	baseMap, isBaseMap := base.(map[interface{}]interface{})
+	patchMap, isPatchMap := patch.(map[interface{}]interface{})
+
+	if !isBaseMap || !isPatchMap {
+		if reflect.DeepEqual(base, patch) {
+			return nil
+		}
+
+		return []Conflict{{Field: field, Base: base, Patch: patch}}
+	}
+
+	var conflicts []Conflict
+
+	for _, key := range sortedKeys(patchMap) {
+		if existing, ok := baseMap[key]; ok {
+			conflicts = append(conflicts, findNestedConflicts(fmt.Sprintf("%s.%v", field, key), existing, patchMap[key])...)
+		}
+	}
+
+	return conflicts
+}
+
+func derefOrNil(value *string) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	return *value
+}
+
+func formatValue(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "(unset)"
+	case string:
+		return fmt.Sprintf("%q", typed)
+	default:
+		return strings.TrimSpace(fmt.Sprintf("%v", typed))
+	}
+}
+
+func sortedKeys(m map[interface{}]interface{}) []interface{} {
+	keys := make([]interface{}, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprintf("%v", keys[i]) < fmt.Sprintf("%v", keys[j])
+	})
+
+	return keys
+}
@@ -0,0 +1,72 @@
+package compose
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatchService(t *testing.T) {
+	base := parseService(t, `
+image: sample/app:1.0
+environment: [LOG_LEVEL=info, REGION=eu-west-1]
+volumes: [data:/var/data, logs:/var/log]
+cap_add: [NET_ADMIN]
+healthcheck:
+  test: [CMD, check]
+  interval: 10s
+`)
+
+	patch := parseService(t, `
+image: sample/app:2.0
+environment:
+  LOG_LEVEL: debug
+  REGION: eu-west-1
+  EXTRA: "1"
+volumes: [data:/var/data, other:/var/log, cache:/var/cache]
+cap_add: [SYS_TIME]
+healthcheck:
+  interval: 5s
+  retries: 3
+`)
+
+	merged, conflicts := PatchService(base, patch)
+
+	// cap_add is concatenated, so it doesn't conflict
+	expected := []string{
+		`environment.LOG_LEVEL: "info" -> "debug"`,
+		`healthcheck.interval: "10s" -> "5s"`,
+		`image: "sample/app:1.0" -> "sample/app:2.0"`,
+		`volumes[/var/log]: "logs:/var/log" -> "other:/var/log"`,
+	}
+
+	var actual []string
+	for _, conflict := range conflicts {
+		actual = append(actual, conflict.String())
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected conflicts:\n%+v", actual)
+	}
+
+	if merged["image"] != "sample/app:2.0" {
+		t.Error("Unexpected image:", merged["image"])
+	}
+
+	if env := merged["environment"]; !reflect.DeepEqual(env, []interface{}{"EXTRA=1", "LOG_LEVEL=debug", "REGION=eu-west-1"}) {
+		t.Error("Unexpected environment:", env)
+	}
+
+	if caps := merged["cap_add"]; !reflect.DeepEqual(caps, []interface{}{"NET_ADMIN", "SYS_TIME"}) {
+		t.Error("Unexpected capabilities:", caps)
+	}
+}
+
+func TestPatchService_NoConflicts(t *testing.T) {
+	_, conflicts := PatchService(
+		parseService(t, "{image: sample, environment: {A: '1'}, depends_on: [db]}"),
+		parseService(t, "{image: sample, environment: [A=1, B=2], depends_on: {db: {condition: service_started}}, user: app}"))
+
+	if len(conflicts) > 0 {
+		t.Error("Unexpected conflicts:", conflicts)
+	}
+}
@@ -1,15 +1,48 @@
 package config
 
-import "github.com/docker/docker/api/types"
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/rycus86/podlike/pkg/policy"
+)
 
 type Configuration struct {
 	SharePids    bool
 	ShareIpc     bool
 	ShareVolumes bool
 	StreamLogs   bool
+	LogFormat    string
 	AlwaysPull   bool
+	DebugVolumes bool
+
+	// print the create requests of the components instead of starting them,
+	// optionally for the controller in a `docker inspect` output file
+	DryRun      bool
+	InspectFile string
+
+	// fail on the component properties that are not supported, instead of ignoring them
+	Strict bool
+
+	// resolve the component images to digests: "resolve" or "require"
+	PinImages string
+
+	DockerProxyDir   string
+	RegistryAuthFile string
+
+	Policy *policy.Policy
 }
 
+const (
+	PinImagesResolve = "resolve"
+	PinImagesRequire = "require"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
 type RegistryAuth struct {
-	Auths map[string]types.AuthConfig `json:"auths"`
+	Auths       map[string]types.AuthConfig `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
 }
\ No newline at end of file
@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rycus86/podlike/pkg/config"
+	"github.com/rycus86/podlike/pkg/policy"
+	"gopkg.in/yaml.v2"
+)
+
+// ValidateDefinitions checks the pod definitions in the stack, Compose or JSON label
+// files given as parameters, prints the problems found, and returns false if there were any.
+// It doesn't need a controller container, nor access to the Docker engine.
+func ValidateDefinitions(parameters ...string) bool {
+	return validateDefinitions(os.Stdout, parameters...)
+}
+
+func validateDefinitions(out io.Writer, parameters ...string) bool {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() { fmt.Fprintln(out, validateHelp()) }
+
+	policyFile := flags.String("policy", "", "The security policy file to check the components against")
+	strict := flags.Bool("strict", false, "Report the unsupported properties of the components as problems")
+
+	if err := flags.Parse(parameters); err == flag.ErrHelp {
+		return true
+	} else if err != nil {
+		return false
+	}
+
+	if flags.NArg() == 0 {
+		fmt.Fprintln(out, validateHelp())
+		return false
+	}
+
+	configuration := &config.Configuration{}
+
+	if *policyFile != "" {
+		loaded, err := policy.Load(*policyFile)
+		if err != nil {
+			fmt.Fprintln(out, "Failed to load the security policy:", err)
+			return false
+		}
+
+		configuration.Policy = loaded
+	}
+
+	valid := true
+
+	for _, filename := range flags.Args() {
+		pods, err := loadPodLabels(filename)
+		if err != nil {
+			fmt.Fprintf(out, "%s: %s\n", filename, err)
+			valid = false
+			continue
+		}
+
+		for _, pod := range pods {
+			client := NewOfflineClient(pod.labels)
+			client.SetStrict(*strict)
+
+			problems := client.Validate(configuration)
+
+			if len(problems) == 0 {
+				fmt.Fprintf(out, "%s: %s: OK\n", filename, pod.name)
+				continue
+			}
+
+			valid = false
+
+			fmt.Fprintf(out, "%s: %s: %d problem(s)\n", filename, pod.name, len(problems))
+
+			for _, problem := range problems {
+				fmt.Fprintf(out, "  - %s\n", strings.Replace(problem.String(), "\n", "\n    ", -1))
+			}
+		}
+	}
+
+	return valid
+}
+
+type podLabels struct {
+	name   string
+	labels map[string]string
+}
+
+// loadPodLabels reads the labels of the pods from the file, that is either
+// a stack or Compose file with services, or the labels of a single controller
+// as a YAML or JSON object, like `docker inspect -f '{{json .Config.Labels}}'` prints.
+func loadPodLabels(filename string) ([]podLabels, error) {
+	var (
+		contents []byte
+		err      error
+	)
+
+	if filename == "-" {
+		contents, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		contents, err = ioutil.ReadFile(filename)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed map[string]interface{}
+
+	if err := yaml.Unmarshal(contents, &parsed); err != nil {
+		return nil, err
+	}
+
+	services, ok := parsed["services"].(map[interface{}]interface{})
+	if !ok {
+		labels, err := asLabels(parsed)
+		if err != nil {
+			return nil, err
+		}
+
+		return []podLabels{{name: "labels", labels: labels}}, nil
+	}
+
+	var (
+		pods  []podLabels
+		names []string
+	)
+
+	for name := range services {
+		names = append(names, fmt.Sprintf("%v", name))
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		service, ok := services[name].(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+
+		labels, err := asLabels(service["labels"])
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("services.%s.labels: %s", name, err))
+		}
+
+		if !hasPodLabels(labels) {
+			continue
+		}
+
+		pods = append(pods, podLabels{name: "services." + name, labels: labels})
+	}
+
+	if len(pods) == 0 {
+		return nil, errors.New("no services found with pod labels")
+	}
+
+	return pods, nil
+}
+
+func asLabels(value interface{}) (map[string]string, error) {
+	labels := map[string]string{}
+
+	switch typed := value.(type) {
+	case nil:
+
+	case map[string]interface{}:
+		for key, item := range typed {
+			labels[key] = fmt.Sprintf("%v", item)
+		}
+
+	case map[interface{}]interface{}:
+		for key, item := range typed {
+			labels[fmt.Sprintf("%v", key)] = fmt.Sprintf("%v", item)
+		}
+
+	case []interface{}:
+		for _, item := range typed {
+			parts := strings.SplitN(fmt.Sprintf("%v", item), "=", 2)
+			if len(parts) == 2 {
+				labels[parts[0]] = parts[1]
+			} else {
+				labels[parts[0]] = ""
+			}
+		}
+
+	default:
+		return nil, errors.New(fmt.Sprintf("invalid labels: %+v (%T)", value, value))
+
+	}
+
+	return labels, nil
+}
+
+func hasPodLabels(labels map[string]string) bool {
+	for key := range labels {
+		if strings.HasPrefix(key, "pod.component.") || strings.HasPrefix(key, "pod.copy.") ||
+			key == "pod.compose.file" || key == "pod.init.components" || key == "pod.controller.copy" {
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateHelp() string {
+	return strings.TrimSpace(`
+Podlike validate
+----------------
+
+This command checks the pod definitions in the labels of the controllers,
+without starting them, or connecting to the Docker engine.
+It parses the component, init component, copy and Compose file definitions
+the same way the controller does, checks the dependencies of the components,
+and prints all the problems found.
+
+Usage:
+
+  podlike validate [-policy FILE] [-strict] FILE [FILE...]
+
+      Validates the services with pod labels in the stack or Compose FILEs,
+      or the labels of a single controller given as a YAML or JSON object.
+      Use - to read the standard input. Compose files in the pod.compose.file
+      labels are read relative to the current directory.
+      Exits with a non-zero status if any problems are found.
+
+  -policy FILE
+
+      Also checks the components against the security policy in the FILE.
+
+  -strict
+
+      Reports the properties of the components that are not supported
+      as problems, instead of printing warnings for them.
+
+  podlike validate [-h|--help]
+
+      Prints this help string for usage.
+`)
+}
@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rycus86/podlike/pkg/component"
+	"github.com/rycus86/podlike/pkg/compose"
+	"gopkg.in/yaml.v2"
+)
+
+// getComposeFiles returns the files in the `pod.compose.file` label,
+// that is either a single path, a comma-separated list, or a YAML list.
+func (c *Client) getComposeFiles() ([]string, bool) {
+	value, ok := c.container.Config.Labels["pod.compose.file"]
+	if !ok {
+		return nil, false
+	}
+
+	var list []string
+
+	if err := yaml.Unmarshal([]byte(value), &list); err != nil || len(list) == 0 {
+		list = strings.Split(value, ",")
+	}
+
+	var files []string
+
+	for _, item := range list {
+		if item = strings.TrimSpace(item); item != "" {
+			files = append(files, item)
+		}
+	}
+
+	return files, true
+}
+
+const (
+	// the labels override the Compose files, and the conflicts are printed as warnings
+	mergeOverride = "override"
+	// the pod fails to start on conflicts between the labels and the Compose files
+	mergeStrict = "strict"
+)
+
+// getComposeComponents returns the components from the services
+// of the Compose files, enabled by the `pod.compose.profiles` label,
+// patched by the component definitions from the labels with the same name.
+// It also returns the names of the labels for the services that are not enabled,
+// that are skipped with a warning, instead of becoming standalone components.
+func (c *Client) getComposeComponents(files []string, patches map[string]string) ([]*component.Component, []string, error) {
+	strategy := c.container.Config.Labels["pod.compose.merge"]
+	if strategy == "" {
+		strategy = mergeOverride
+	} else if strategy != mergeOverride && strategy != mergeStrict {
+		return nil, nil, errors.New(fmt.Sprintf(
+			"invalid merge strategy in pod.compose.merge: %s (expected %s or %s)", strategy, mergeOverride, mergeStrict))
+	}
+
+	lookup, err := c.getLookup()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	project, err := compose.Load(files, lookup)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var profiles []string
+
+	for _, profile := range strings.Split(c.container.Config.Labels["pod.compose.profiles"], ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+
+	services, err := project.EnabledServices(profiles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var skipped []string
+
+	for _, name := range sortedKeys(patches) {
+		if _, ok := project.Services[name]; !ok {
+			continue
+		}
+
+		if _, ok := services[name]; !ok {
+			fmt.Println(
+				"[Warning] Skipping the pod.component."+name, "label, because the", name,
+				"service is not enabled by the pod.compose.profiles label")
+
+			skipped = append(skipped, name)
+		}
+	}
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var components []*component.Component
+
+	for _, name := range names {
+		service := services[name]
+
+		if err := c.resolveComposeAttachments(project, name, service); err != nil {
+			return nil, nil, err
+		}
+
+		if patch, ok := patches[name]; ok {
+			service, err = c.patchComposeService(name, service, patch, strategy)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		comp, err := c.loadComponent(name, service)
+		if err != nil {
+			return nil, nil, errors.New(fmt.Sprintf("invalid definition for the %s service : %s", name, err))
+		}
+
+		comp.Initialize(name, c, c.engine)
+		comp.EnableLenientMode()
+
+		components = append(components, comp)
+	}
+
+	return components, skipped, nil
+}
+
+// patchComposeService merges the component definition from the label into the service,
+// and reports the properties where they conflict.
+func (c *Client) patchComposeService(
+	name string, service map[interface{}]interface{}, patch string, strategy string) (map[interface{}]interface{}, error) {
+
+	var definition map[interface{}]interface{}
+
+	if err := c.unmarshalYAML([]byte(patch), &definition, true); err != nil {
+		return nil, err
+	}
+
+	merged, conflicts := compose.PatchService(service, definition)
+
+	if len(conflicts) == 0 {
+		return merged, nil
+	}
+
+	if strategy == mergeStrict {
+		messages := make([]string, 0, len(conflicts))
+
+		for _, conflict := range conflicts {
+			messages = append(messages, "  - "+conflict.String())
+		}
+
+		return nil, errors.New(fmt.Sprintf(
+			"the pod.component.%s label conflicts with the Compose files:\n%s", name, strings.Join(messages, "\n")))
+	}
+
+	for _, conflict := range conflicts {
+		fmt.Println("[Warning] The pod.component."+name, "label overrides the Compose files:", conflict.String())
+	}
+
+	return merged, nil
+}
+
+// resolveComposeAttachments points the named volumes, secrets and configs of the service
+// to the ones the controller has, based on the top-level definitions of the project.
+func (c *Client) resolveComposeAttachments(project *compose.Project, name string, service map[interface{}]interface{}) error {
+	if volumes, ok := service["volumes"].([]interface{}); ok {
+		resolved := make([]interface{}, 0, len(volumes))
+
+		for _, item := range volumes {
+			resolved = append(resolved, c.resolveComposeVolume(project, name, item))
+		}
+
+		service["volumes"] = resolved
+	}
+
+	for _, kind := range []struct {
+		key         string
+		definitions map[string]map[interface{}]interface{}
+		controller  string
+		target      string
+	}{
+		{"secrets", project.Secrets, "/run/secrets", "/run/secrets"},
+		{"configs", project.Configs, "/", "/"},
+	} {
+		items, ok := service[kind.key].([]interface{})
+		if !ok {
+			continue
+		}
+
+		resolved := make([]interface{}, 0, len(items))
+
+		for _, item := range items {
+			reference := map[interface{}]interface{}{}
+
+			if asMap, ok := item.(map[interface{}]interface{}); ok {
+				for key, value := range asMap {
+					reference[key] = value
+				}
+			} else {
+				reference["source"] = item
+			}
+
+			source := fmt.Sprintf("%v", reference["source"])
+
+			definition, ok := kind.definitions[source]
+			if !ok {
+				return errors.New(fmt.Sprintf(
+					"the %s service refers to the %s %s, that is not defined in the Compose files",
+					name, kind.key, source))
+			}
+
+			// the path in the controller: either the file, or where Swarm puts it
+			controllerPath := path.Join(kind.controller, source)
+
+			if file, ok := definition["file"].(string); ok {
+				controllerPath = file
+
+				if !filepath.IsAbs(file) {
+					controllerPath = filepath.Join(project.WorkingDir, file)
+				}
+			}
+
+			if _, err := os.Stat(controllerPath); err != nil && !c.offline {
+				return errors.New(fmt.Sprintf(
+					"the %s %s of the %s service is not available in the controller : %s",
+					kind.key, source, name, err))
+			}
+
+			if _, ok := reference["target"]; !ok {
+				reference["target"] = path.Join(kind.target, source)
+			}
+
+			reference["source"] = controllerPath
+
+			resolved = append(resolved, reference)
+		}
+
+		service[kind.key] = resolved
+	}
+
+	return nil
+}
+
+// resolveComposeVolume replaces the source of named volumes with the name
+// in their top-level definition, and warns if the controller doesn't have them.
+func (c *Client) resolveComposeVolume(project *compose.Project, service string, item interface{}) interface{} {
+	var source string
+
+	asMap, isMap := item.(map[interface{}]interface{})
+
+	if isMap {
+		source, _ = asMap["source"].(string)
+	} else if definition, ok := item.(string); ok {
+		source = strings.SplitN(definition, ":", 2)[0]
+
+		if !strings.Contains(definition, ":") {
+			// anonymous volume
+			return item
+		}
+	}
+
+	definition, ok := project.Volumes[source]
+	if !ok {
+		// bind mounts, or volumes not defined at the top level
+		return item
+	}
+
+	name := source
+
+	if explicit, ok := definition["name"].(string); ok && explicit != "" {
+		name = explicit
+	} else if external, ok := definition["external"].(map[interface{}]interface{}); ok {
+		if externalName, ok := external["name"].(string); ok && externalName != "" {
+			name = externalName
+		}
+	}
+
+	if shared, _ := c.GetSharedVolume(name); shared == "" && !c.offline {
+		fmt.Println("[Warning] The", source, "volume of the", service, "service is not attached to the controller,",
+			"it will not be shared with the controller")
+	}
+
+	if isMap {
+		resolved := map[interface{}]interface{}{}
+		for key, value := range asMap {
+			resolved[key] = value
+		}
+
+		resolved["source"] = name
+		return resolved
+	}
+
+	return name + strings.TrimPrefix(item.(string), source)
+}
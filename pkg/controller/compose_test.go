@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rycus86/podlike/pkg/component"
+)
+
+func getComponentNames(components []*component.Component) []string {
+	var names []string
+
+	for _, c := range components {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+func TestCompose_MultipleFiles(t *testing.T) {
+	for _, label := range []string{
+		"testdata/compose/docker-compose.yml, testdata/compose/override.yml",
+		"[testdata/compose/docker-compose.yml, testdata/compose/override.yml]",
+		"- testdata/compose/docker-compose.yml\n- testdata/compose/override.yml",
+	} {
+		components, err := newTestClient(map[string]string{
+			"pod.compose.file": label,
+		}, nil, nil).GetComponents()
+
+		if err != nil {
+			t.Fatal("Failed to get the components:", err)
+		}
+
+		if names := getComponentNames(components); !reflect.DeepEqual(names, []string{"app"}) {
+			t.Error("Unexpected components:", names)
+		}
+
+		if image := components[0].Image; image != "sample/app:2.0" {
+			t.Error("Unexpected image:", image)
+		}
+	}
+}
+
+func TestCompose_Profiles(t *testing.T) {
+	components, err := newTestClient(map[string]string{
+		"pod.compose.file":     "testdata/compose/docker-compose.yml",
+		"pod.compose.profiles": "debug, other",
+	}, nil, nil).GetComponents()
+
+	if err != nil {
+		t.Fatal("Failed to get the components:", err)
+	}
+
+	if names := getComponentNames(components); !reflect.DeepEqual(names, []string{"app", "debug"}) {
+		t.Error("Unexpected components:", names)
+	}
+}
+
+func TestCompose_PatchDisabledService(t *testing.T) {
+	components, err := newTestClient(map[string]string{
+		"pod.compose.file":      "testdata/compose/docker-compose.yml",
+		"pod.component.debug":   "image: sample/debug:2.0",
+		"pod.component.sidecar": "image: sample/sidecar",
+	}, nil, nil).GetComponents()
+
+	if err != nil {
+		t.Fatal("Failed to get the components:", err)
+	}
+
+	if names := getComponentNames(components); !reflect.DeepEqual(names, []string{"app", "sidecar"}) {
+		t.Error("Unexpected components:", names)
+	}
+}
+
+func TestCompose_Attachments(t *testing.T) {
+	components, err := newTestClient(map[string]string{
+		"pod.compose.file": "testdata/compose/docker-compose.yml",
+	}, nil, nil).GetComponents()
+
+	if err != nil {
+		t.Fatal("Failed to get the components:", err)
+	}
+
+	app := components[0]
+
+	if app.Volumes[0] != "shared-data:/var/data" {
+		t.Error("Unexpected volume:", app.Volumes[0])
+	}
+
+	if longSyntax, ok := app.Volumes[1].(map[interface{}]interface{}); !ok || longSyntax["source"] != "shared-cache" {
+		t.Error("Unexpected volume:", app.Volumes[1])
+	}
+
+	secretFile := filepath.Join("testdata", "compose", "db_password.txt")
+
+	expectedSecrets := []interface{}{
+		map[interface{}]interface{}{"source": secretFile, "target": "/run/secrets/db_password"},
+		map[interface{}]interface{}{"source": secretFile, "target": "/etc/app/api.key", "mode": 0400},
+	}
+
+	if !reflect.DeepEqual(app.Secrets, expectedSecrets) {
+		t.Errorf("Unexpected secrets: %+v", app.Secrets)
+	}
+
+	expectedConfigs := []interface{}{
+		map[interface{}]interface{}{"source": secretFile, "target": "/app_config"},
+	}
+
+	if !reflect.DeepEqual(app.Configs, expectedConfigs) {
+		t.Errorf("Unexpected configs: %+v", app.Configs)
+	}
+}
+
+func TestCompose_Errors(t *testing.T) {
+	for label, expected := range map[string]string{
+		"testdata/compose/missing.yml":                              "no such file",
+		"testdata/compose/docker-compose.yml, testdata/missing.yml": "no such file",
+		"testdata/compose/undefined-secret.yml":                     "not defined in the Compose files",
+		"testdata/compose/missing-secret.yml":                       "not available in the controller",
+	} {
+		_, err := newTestClient(map[string]string{
+			"pod.compose.file": label,
+		}, nil, nil).GetComponents()
+
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Error("Unexpected error for", label, ":", err)
+		}
+	}
+}
+
+func TestCompose_MixedWithLabels(t *testing.T) {
+	components, err := newTestClient(map[string]string{
+		"pod.compose.file": "testdata/compose/docker-compose.yml",
+		"pod.component.app": `
+image: sample/app:3.0
+environment:
+  LOG_LEVEL: debug`,
+		"pod.component.sidecar": "image: sample/sidecar",
+	}, nil, nil).GetComponents()
+
+	if err != nil {
+		t.Fatal("Failed to get the components:", err)
+	}
+
+	if names := getComponentNames(components); !reflect.DeepEqual(names, []string{"app", "sidecar"}) {
+		t.Fatal("Unexpected components:", names)
+	}
+
+	app := components[0]
+
+	if app.Image != "sample/app:3.0" {
+		t.Error("Unexpected image:", app.Image)
+	}
+
+	if !reflect.DeepEqual(app.Environment, map[interface{}]interface{}{"LOG_LEVEL": "debug"}) {
+		t.Error("Unexpected environment:", app.Environment)
+	}
+
+	if len(app.Secrets) != 2 || len(app.Volumes) != 2 {
+		t.Error("Missing properties from the Compose file:", app.Secrets, app.Volumes)
+	}
+
+	if components[1].Image != "sample/sidecar" {
+		t.Error("Unexpected image:", components[1].Image)
+	}
+}
+
+func TestCompose_StrictMerge(t *testing.T) {
+	labels := map[string]string{
+		"pod.compose.file":  "testdata/compose/docker-compose.yml",
+		"pod.compose.merge": "strict",
+		"pod.component.app": `
+image: sample/app:3.0
+volumes: [other:/var/data, extra:/var/extra]`,
+	}
+
+	_, err := newTestClient(labels, nil, nil).GetComponents()
+
+	if err == nil {
+		t.Fatal("Expected to fail")
+	}
+
+	for _, expected := range []string{
+		`image: "sample/app:1.0" -> "sample/app:3.0"`,
+		`volumes[/var/data]: "shared-data:/var/data" -> "other:/var/data"`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Error("Missing conflict:", expected, "in", err)
+		}
+	}
+
+	labels["pod.component.app"] = "volumes: [extra:/var/extra]"
+
+	if _, err := newTestClient(labels, nil, nil).GetComponents(); err != nil {
+		t.Error("Unexpected error without conflicts:", err)
+	}
+
+	labels["pod.compose.merge"] = "sometimes"
+
+	if _, err := newTestClient(labels, nil, nil).GetComponents(); err == nil {
+		t.Error("Expected to fail for an invalid strategy")
+	}
+}
+
+func TestCompose_UnsupportedProperties(t *testing.T) {
+	labels := map[string]string{
+		"pod.compose.file": "testdata/compose/unsupported.yml",
+	}
+
+	components, err := newTestClient(labels, nil, nil).GetComponents()
+	if err != nil {
+		t.Fatal("Expected only warnings for the unsupported properties:", err)
+	}
+
+	if len(components) != 1 || components[0].Image != "sample/app" || components[0].Environment == nil {
+		t.Error("Unexpected components:", components)
+	}
+
+	client := newTestClient(labels, nil, nil)
+	client.SetStrict(true)
+
+	_, err = client.GetComponents()
+	if err == nil {
+		t.Fatal("Expected to fail in strict mode")
+	}
+
+	for _, expected := range []string{
+		"  - build: not supported (Only pre-built images are supported)",
+		"  - networks: not supported (Assign networks through the Swarm service)",
+		"  - ports: not supported (Expose ports by publishing them on the Swarm service)",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Error("Missing from the error:", expected, "in", err)
+		}
+	}
+
+	if strings.Contains(err.Error(), "x-notes") {
+		t.Error("Extension properties should be ignored:", err)
+	}
+
+	labels["pod.component.sidecar"] = "{image: sample/sidecar, enviroment: [KEY=value]}"
+
+	if _, err := newTestClient(labels, nil, nil).GetComponents(); err == nil {
+		t.Error("Expected to fail on an unknown property")
+	} else if !strings.Contains(err.Error(), "enviroment: unknown property, did you mean environment?") {
+		t.Error("Unexpected error:", err)
+	}
+}
@@ -3,10 +3,12 @@ package controller
 import (
 	"errors"
 	"fmt"
+	"github.com/docker/docker/api/types"
 	dtc "github.com/docker/docker/api/types/container"
+	"github.com/rycus86/podlike/pkg/api"
 	"github.com/rycus86/podlike/pkg/component"
+	"github.com/rycus86/podlike/pkg/definition"
 	"github.com/rycus86/podlike/pkg/engine"
-	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"strings"
 )
@@ -14,14 +16,35 @@ import (
 func (c *Client) GetInitComponents() ([]*component.Component, error) {
 	var components []*component.Component
 
+	if err := c.interpolateLabels(); err != nil {
+		return nil, err
+	}
+
 	if initConfigs, ok := c.container.Config.Labels["pod.init.components"]; ok {
-		err := yaml.UnmarshalStrict([]byte(initConfigs), &components)
+		initConfigs, err := resolveDefinition("pod.init.components", initConfigs)
+		if err != nil {
+			return nil, err
+		}
+
+		var definitions []interface{}
+
+		err = c.unmarshalYAML([]byte(initConfigs), &definitions, true)
 		if err != nil {
 			return nil, err
 		}
 
+		for idx, item := range definitions {
+			comp, err := c.loadComponent(fmt.Sprintf("init-%d", idx+1), item)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("invalid definition for the init-%d component : %s", idx+1, err))
+			}
+
+			components = append(components, comp)
+		}
+
 		for idx, comp := range components {
 			comp.DisableHealthChecking()
+			comp.MarkAsInitComponent()
 			comp.Initialize(fmt.Sprintf("init-%d", idx+1), c, c.engine)
 
 			if comp.DependsOn != nil {
@@ -35,56 +58,124 @@ func (c *Client) GetInitComponents() ([]*component.Component, error) {
 	return components, nil
 }
 
+// GetComponents returns the components from the `pod.component.<name>` labels,
+// and from the Compose files, where the labels can add new components,
+// or patch the services with the same name.
 func (c *Client) GetComponents() ([]*component.Component, error) {
+	return c.getComponents(nil)
+}
+
+// getComponents returns the components like GetComponents does,
+// but ignores the `pod.component.<name>` labels of the names to skip.
+func (c *Client) getComponents(skip map[string]bool) ([]*component.Component, error) {
 	var components []*component.Component
 
+	if err := c.interpolateLabels(); err != nil {
+		return nil, err
+	}
+
+	fromLabels := map[string]*component.Component{}
+	definitions := map[string]string{}
+
 	for key, value := range c.container.Config.Labels {
 		if strings.HasPrefix(key, "pod.component.") {
-			var comp component.Component
+			name := strings.TrimPrefix(key, "pod.component.")
 
-			err := yaml.UnmarshalStrict([]byte(value), &comp)
+			if skip[name] {
+				continue
+			}
+
+			value, err := resolveDefinition(key, value)
 			if err != nil {
 				return nil, err
 			}
 
-			comp.Initialize(strings.TrimPrefix(key, "pod.component."), c, c.engine)
+			comp, err := c.parseComponent(name, value)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("invalid definition for the %s component : %s", name, err))
+			}
 
-			components = append(components, &comp)
+			fromLabels[name] = comp
+			definitions[name] = value
 		}
 	}
 
-	if composeFile, ok := c.container.Config.Labels["pod.compose.file"]; ok {
-		if len(components) > 0 {
-			return nil, errors.New(
-				"either the individual components or a compose file should be defined, but not both")
-		}
-
-		composeContents, err := ioutil.ReadFile(composeFile)
+	if composeFiles, ok := c.getComposeFiles(); ok {
+		fromCompose, skipped, err := c.getComposeComponents(composeFiles, definitions)
 		if err != nil {
 			return nil, err
 		}
 
-		var project component.ComposeProject
+		for _, name := range skipped {
+			// the label targets a service that is not enabled
+			delete(fromLabels, name)
+		}
 
-		err = yaml.Unmarshal(composeContents, &project)
-		if err != nil {
-			return nil, err
+		for _, comp := range fromCompose {
+			// the label already patched the service
+			delete(fromLabels, comp.Name)
 		}
 
-		for name, item := range project.Services {
-			comp := item
-			comp.Initialize(name, c, c.engine)
+		components = append(components, fromCompose...)
+	}
 
-			components = append(components, &comp)
-		}
+	for _, name := range sortedKeys(fromLabels) {
+		comp := fromLabels[name]
+		comp.Initialize(name, c, c.engine)
+
+		components = append(components, comp)
 	}
 
 	return components, nil
 }
 
+// parseComponent reads the component from its YAML definition in a label.
+func (c *Client) parseComponent(name, definition string) (*component.Component, error) {
+	var parsed interface{}
+
+	if err := c.unmarshalYAML([]byte(definition), &parsed, true); err != nil {
+		return nil, err
+	}
+
+	return c.loadComponent(name, parsed)
+}
+
+// loadComponent checks the properties of the parsed component definition,
+// and prints a warning for each one that is ignored, or fails on them in strict mode.
+func (c *Client) loadComponent(name string, definition interface{}) (*component.Component, error) {
+	comp, warnings, err := component.LoadDefinition(definition, c.strict)
+
+	for _, warning := range warnings {
+		fmt.Println("[Warning] Ignoring the", warning.Field, "property of the", name, "component:", warning.Message)
+	}
+
+	return comp, err
+}
+
+// SetStrict makes the properties of the component definitions that are not supported
+// fail the pod, instead of being ignored with a warning.
+func (c *Client) SetStrict(strict bool) {
+	c.strict = strict
+}
+
+// resolveDefinition decodes compressed definitions,
+// and reads the ones referencing files in the controller.
+func resolveDefinition(label, value string) (string, error) {
+	resolved, err := definition.Resolve(value)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("invalid %s label: %s", label, err))
+	}
+
+	return resolved, nil
+}
+
 func (c *Client) Close() error {
 	c.closed = true
 
+	if c.engine == nil {
+		return nil
+	}
+
 	return c.engine.Close()
 }
 
@@ -96,11 +187,19 @@ func (c *Client) GetContainerName() string {
 	return c.container.Name
 }
 
+func (c *Client) GetHostname() string {
+	return c.container.Config.Hostname
+}
+
 func (c *Client) GetCgroup() string {
 	return c.cgroup
 }
 
 func (c *Client) GetLabels() map[string]string {
+	if c.labels != nil {
+		return c.labels
+	}
+
 	return c.container.Config.Labels
 }
 
@@ -108,7 +207,18 @@ func (c *Client) GetHostConfig() *dtc.HostConfig {
 	return c.container.HostConfig
 }
 
-func NewClient() (*Client, error) {
+// GetExports returns the files copied out of the components of this pod.
+func (c *Client) GetExports() api.Exports {
+	c.exportsInit.Do(func() {
+		c.exports = component.NewExports()
+	})
+
+	return c.exports
+}
+
+// NewClient connects to the Docker engine, using the registry
+// credentials from the auth file given, and inspects the controller container.
+func NewClient(registryAuthFile string) (*Client, error) {
 	cgroup := getOwnCgroup()
 	containerID := getOwnContainerID()
 
@@ -116,22 +226,38 @@ func NewClient() (*Client, error) {
 		return nil, errors.New("the application does not appear to be running in a container")
 	}
 
-	eng, err := engine.NewEngine()
+	eng, err := engine.NewEngine(registryAuthFile)
 	if err != nil {
 		return nil, err
 	}
 
-	c := &Client{
-		engine: eng,
-		cgroup: cgroup,
+	container, err := eng.InspectContainer(containerID)
+	if err != nil {
+		eng.Close()
+		return nil, err
 	}
 
-	container, err := eng.InspectContainer(containerID)
+	c, err := newClient(eng, container, cgroup)
 	if err != nil {
 		eng.Close()
 		return nil, err
 	}
-	c.container = container
+
+	return c, nil
+}
+
+// newClient returns the client for the controller container,
+// with the variables in its labels replaced already, before anything reads them concurrently.
+func newClient(eng *engine.Engine, container *types.ContainerJSON, cgroup string) (*Client, error) {
+	c := &Client{
+		engine:    eng,
+		container: container,
+		cgroup:    cgroup,
+	}
+
+	if err := c.interpolateLabels(); err != nil {
+		return nil, err
+	}
 
 	return c, nil
 }
@@ -8,9 +8,11 @@ import (
 	"github.com/docker/docker/client"
 	"github.com/rycus86/podlike/pkg/config"
 	"github.com/rycus86/podlike/pkg/convert"
+	"github.com/rycus86/podlike/pkg/definition"
 	"github.com/rycus86/podlike/pkg/engine"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -47,6 +49,44 @@ func TestController_OneComponent(t *testing.T) {
 	}
 }
 
+func TestController_EncodedComponents(t *testing.T) {
+	compressed, err := definition.Compress("{image: sample/compressed, command: a b c}")
+	if err != nil {
+		t.Fatal("Failed to compress:", err)
+	}
+
+	definitionFile, _ := filepath.Abs("testdata/definitions/component.yml")
+
+	components, err := newTestClient(map[string]string{
+		"pod.component.compressed": compressed,
+		"pod.component.file":       definition.FilePrefix + definitionFile,
+	}, nil, nil).GetComponents()
+
+	if err != nil {
+		t.Fatal("Failed to get components", err)
+	}
+
+	if len(components) != 2 {
+		t.Fatal("Unexpected number of components:", len(components))
+	}
+
+	if components[0].Name != "compressed" || components[0].Image != "sample/compressed" {
+		t.Error("Invalid component:", components[0].Name, components[0].Image)
+	}
+
+	if components[1].Name != "file" || components[1].Image != "sample/from-file" {
+		t.Error("Invalid component:", components[1].Name, components[1].Image)
+	}
+
+	_, err = newTestClient(map[string]string{
+		"pod.component.missing": definition.FilePrefix + "/not/found.yml",
+	}, nil, nil).GetComponents()
+
+	if err == nil || !strings.Contains(err.Error(), "pod.component.missing") {
+		t.Error("Expected to fail with the label name:", err)
+	}
+}
+
 func TestController_ComposeProject(t *testing.T) {
 	components, err := newTestClient(map[string]string{
 		"pod.compose.file": "testdata/docker-compose.yml",
@@ -148,6 +188,28 @@ func newTestClient(
 			return
 		}
 
+		if strings.Contains(r.RequestURI, "/volumes/create") {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+
+			if createVerifier != nil {
+				(*createVerifier).Verify("volume", body)
+			}
+
+			response, _ := json.Marshal(map[string]interface{}{"Name": body["Name"]})
+
+			w.WriteHeader(201)
+			w.Write(response)
+
+			return
+		}
+
+		if strings.Contains(r.RequestURI, "/volumes/") && r.Method == "DELETE" {
+			w.WriteHeader(204)
+
+			return
+		}
+
 		if strings.HasSuffix(r.RequestURI, "/json") {
 			w.WriteHeader(200)
 			w.Write([]byte("{\"ID\": \"c0001\", \"Config\": {}}"))
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rycus86/podlike/pkg/component"
+	"strings"
+)
+
+type copyError struct {
+	label   string
+	message string
+}
+
+func (e copyError) Error() string {
+	return e.message
+}
+
+func (c *Client) CopyFilesFromComponents() {
+	if definition, ok := c.GetLabels()["pod.controller.copy"]; ok {
+		if err := component.CopyToController(definition, c.GetExports()); err != nil {
+			fmt.Println("Failed to copy files from the components:", err)
+		}
+	}
+}
+
+// CheckCopySources verifies that the `pod.copy.<name>` and `pod.controller.copy` labels
+// only copy files from the components given, that can start before the ones copying them,
+// otherwise the copies would wait for them forever.
+func (c *Client) CheckCopySources(initComponents, components []*component.Component) error {
+	if errs := c.checkCopySources(initComponents, components); len(errs) > 0 {
+		return errors.New(fmt.Sprintf("invalid %s label : %s", errs[0].label, errs[0]))
+	}
+
+	return nil
+}
+
+// checkCopySources returns the errors for the copy sources that are not defined,
+// and for the ones that can not start before the component copying from them:
+// init components only copy from the init components before them,
+// and the other components can not wait for each other in a cycle,
+// through the copies or the `depends_on` properties.
+func (c *Client) checkCopySources(initComponents, components []*component.Component) []copyError {
+	var (
+		errs []copyError
+
+		// the position of the init components, and -1 for the other ones
+		order = map[string]int{}
+
+		// the components the other components wait for before starting
+		waitsFor = map[string][]string{}
+	)
+
+	for idx, comp := range initComponents {
+		order[comp.Name] = idx
+	}
+
+	for _, comp := range components {
+		order[comp.Name] = -1
+
+		if dependencies, err := comp.GetDependencies(); err == nil {
+			for _, dependency := range dependencies {
+				waitsFor[comp.Name] = append(waitsFor[comp.Name], dependency.Name)
+			}
+		}
+	}
+
+	type copyEdge struct {
+		label  string
+		target string
+		source string
+	}
+
+	var copies []copyEdge
+
+	labels := c.GetLabels()
+
+	for _, key := range sortedKeys(labels) {
+		if !strings.HasPrefix(key, "pod.copy.") && key != "pod.controller.copy" {
+			continue
+		}
+
+		sources, err := component.GetCopySources(labels[key])
+		if err != nil {
+			// reported when copying the files
+			continue
+		}
+
+		target := strings.TrimPrefix(key, "pod.copy.")
+		targetPosition, targetDefined := order[target]
+
+		for _, source := range sources {
+			sourcePosition, ok := order[source]
+			if !ok {
+				errs = append(errs, copyError{
+					label:   key,
+					message: fmt.Sprintf("the %s component to copy from is not defined", source),
+				})
+
+				continue
+			}
+
+			if key == "pod.controller.copy" || !targetDefined {
+				continue
+			}
+
+			if source == target {
+				errs = append(errs, copyError{
+					label:   key,
+					message: fmt.Sprintf("the %s component can not copy from itself", target),
+				})
+
+			} else if targetPosition >= 0 && (sourcePosition < 0 || sourcePosition > targetPosition) {
+				errs = append(errs, copyError{
+					label:   key,
+					message: fmt.Sprintf("the %s component can not copy from %s, as it starts after it", target, source),
+				})
+
+			} else if targetPosition < 0 && sourcePosition < 0 {
+				waitsFor[target] = append(waitsFor[target], source)
+				copies = append(copies, copyEdge{label: key, target: target, source: source})
+
+			}
+		}
+	}
+
+	for _, item := range copies {
+		if waitsForComponent(waitsFor, item.source, item.target, map[string]bool{}) {
+			errs = append(errs, copyError{
+				label: item.label,
+				message: fmt.Sprintf(
+					"the %s component can not copy from %s, as %s waits for %s to start first",
+					item.target, item.source, item.source, item.target),
+			})
+		}
+	}
+
+	return errs
+}
+
+// waitsForComponent returns true if the component waits for the other one to start,
+// directly or through the components it waits for.
+func waitsForComponent(waitsFor map[string][]string, name, other string, seen map[string]bool) bool {
+	if seen[name] {
+		return false
+	}
+
+	seen[name] = true
+
+	for _, item := range waitsFor[name] {
+		if item == other || waitsForComponent(waitsFor, item, other, seen) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCopySources(t *testing.T) {
+	cli := newTestClient(map[string]string{
+		"pod.init.components": "[{image: sample/init}]",
+		"pod.component.app":   "image: sample/app",
+		"pod.copy.app":        "[{from: 'init-1:/out/certs', target: /etc/ssl, when: exited}]",
+		"pod.controller.copy": "[{from: 'app:/data', target: /backup, when: healthy}]",
+	}, nil, nil)
+
+	initComponents, err := cli.GetInitComponents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	components, err := cli.GetComponents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cli.CheckCopySources(initComponents, components); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+
+	if err := cli.CheckCopySources(nil, components); err == nil || !strings.Contains(err.Error(), "the init-1 component to copy from") {
+		t.Error("Expected to fail on the unknown source:", err)
+	}
+}
+
+func TestCopySources_Order(t *testing.T) {
+	cli := newTestClient(map[string]string{
+		"pod.init.components": "[{image: sample/init}, {image: sample/init}]",
+		"pod.component.app":   "image: sample/app",
+		"pod.component.db":    "{image: sample/db, depends_on: [proxy]}",
+		"pod.component.proxy": "image: sample/proxy",
+		"pod.copy.init-1":     "[{from: 'init-2:/out', target: /in, when: exited}, {from: 'app:/out', target: /in}]",
+		"pod.copy.init-2":     "[{from: 'init-1:/out', target: /in, when: exited}, {from: 'init-2:/out', target: /in}]",
+		"pod.copy.app":        "[{from: 'init-2:/out', target: /in, when: exited}, {from: 'db:/out', target: /in}]",
+		"pod.copy.proxy":      "[{from: 'app:/out', target: /in}]",
+	}, nil, nil)
+
+	initComponents, err := cli.GetInitComponents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	components, err := cli.GetComponents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var problems []string
+
+	for _, err := range cli.checkCopySources(initComponents, components) {
+		problems = append(problems, err.label+": "+err.Error())
+	}
+
+	expected := []string{
+		"pod.copy.init-1: the init-1 component can not copy from init-2, as it starts after it",
+		"pod.copy.init-1: the init-1 component can not copy from app, as it starts after it",
+		"pod.copy.init-2: the init-2 component can not copy from itself",
+		"pod.copy.app: the app component can not copy from db, as db waits for app to start first",
+		"pod.copy.proxy: the proxy component can not copy from app, as app waits for proxy to start first",
+	}
+
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Error("Unexpected problems:\n" + strings.Join(problems, "\n"))
+	}
+
+	if err := cli.CheckCopySources(initComponents, components); err == nil ||
+		err.Error() != "invalid pod.copy.init-1 label : the init-1 component can not copy from init-2, as it starts after it" {
+
+		t.Error("Unexpected error:", err)
+	}
+}
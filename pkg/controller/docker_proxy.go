@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/rycus86/podlike/pkg/component"
+	"github.com/rycus86/podlike/pkg/proxy"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultDockerSocket = "/var/run/docker.sock"
+
+type dockerProxy struct {
+	proxy *proxy.Proxy
+	mount mount.Mount
+}
+
+type dockerProxyDefinition struct {
+	Target  string
+	PodOnly bool `yaml:"pod_only"`
+	Allow   []string
+}
+
+// StartDockerProxies serves a filtered Docker API on a unix socket
+// for each component configured with a `pod.docker.<component>` label.
+// The sockets are created in the directory given, that has to be mounted
+// into the controller, so they can be shared with the components.
+func (c *Client) StartDockerProxies(directory string) error {
+	return c.prepareDockerProxies(directory, true)
+}
+
+// ResolveDockerProxies only resolves the mounts of the Docker API proxy sockets,
+// without serving them, for printing the configuration of the components.
+func (c *Client) ResolveDockerProxies(directory string) error {
+	return c.prepareDockerProxies(directory, false)
+}
+
+func (c *Client) prepareDockerProxies(directory string, serve bool) error {
+	c.dockerProxies = map[string]*dockerProxy{}
+
+	for key, value := range c.container.Config.Labels {
+		if !strings.HasPrefix(key, "pod.docker.") {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, "pod.docker.")
+
+		policy, target, err := c.parseDockerProxyDefinition(value)
+		if err != nil {
+			return errors.New(fmt.Sprintf("invalid Docker API proxy configuration for %s : %s", name, err))
+		}
+
+		socketPath := filepath.Join(directory, name+".sock")
+
+		hostPath, err := c.getHostPath(socketPath)
+		if err != nil {
+			return err
+		}
+
+		socketMount := mount.Mount{
+			Type:   mount.TypeBind,
+			Source: hostPath,
+			Target: target,
+		}
+
+		if !serve {
+			c.dockerProxies[name] = &dockerProxy{mount: socketMount}
+			continue
+		}
+
+		backend := proxy.NewProxy(
+			&url.URL{Scheme: "http", Host: "docker"},
+			proxy.NewUnixSocketTransport(getDockerSocket()),
+			policy)
+
+		if err := backend.ListenAndServe(socketPath, 0666); err != nil {
+			return errors.New(fmt.Sprintf("failed to start the Docker API proxy for %s : %s", name, err))
+		}
+
+		fmt.Println("Serving the Docker API proxy for", name, "on", socketPath)
+
+		c.dockerProxies[name] = &dockerProxy{
+			proxy: backend,
+			mount: socketMount,
+		}
+	}
+
+	return nil
+}
+
+// StopDockerProxies stops serving the Docker API proxies.
+func (c *Client) StopDockerProxies() {
+	for name, item := range c.dockerProxies {
+		if item.proxy == nil {
+			continue
+		}
+
+		if err := item.proxy.Close(); err != nil {
+			fmt.Println("Failed to stop the Docker API proxy for", name, ":", err)
+		}
+	}
+}
+
+// GetDockerProxyMount returns the mount of the Docker API proxy socket
+// for the component, or nil if it doesn't have one.
+func (c *Client) GetDockerProxyMount(name string) *mount.Mount {
+	if item, ok := c.dockerProxies[name]; ok {
+		mnt := item.mount
+		return &mnt
+	}
+
+	return nil
+}
+
+func (c *Client) parseDockerProxyDefinition(value string) (proxy.Policy, string, error) {
+	var definition dockerProxyDefinition
+
+	if err := yaml.UnmarshalStrict([]byte(value), &definition); err != nil {
+		return proxy.Policy{}, "", err
+	}
+
+	if definition.Target == "" {
+		definition.Target = defaultDockerSocket
+	} else if !filepath.IsAbs(definition.Target) {
+		return proxy.Policy{}, "", errors.New("the target needs to be an absolute path: " + definition.Target)
+	}
+
+	var policy proxy.Policy
+
+	for _, item := range definition.Allow {
+		rule, err := proxy.ParseRule(item)
+		if err != nil {
+			return proxy.Policy{}, "", err
+		}
+
+		policy.Allow = append(policy.Allow, rule)
+	}
+
+	if definition.PodOnly {
+		policy.IsVisible = c.isPodContainer
+	}
+
+	return policy, definition.Target, nil
+}
+
+// isPodContainer checks if the container is the controller or one of its components,
+// either by the controller label of the component, or by its name.
+func (c *Client) isPodContainer(id, name string, labels map[string]string) bool {
+	if id == c.container.ID || name == c.container.Name {
+		return true
+	}
+
+	if labels[component.ControllerLabel] == c.container.ID {
+		return true
+	}
+
+	return strings.HasPrefix(name, c.container.Name+".podlike.")
+}
+
+// getHostPath returns the path on the host for a path in the controller,
+// that is within one of the bind or volume mounts of the controller.
+func (c *Client) getHostPath(path string) (string, error) {
+	var (
+		hostPath string
+		longest  = -1
+	)
+
+	for _, mnt := range c.container.Mounts {
+		if mnt.Type != mount.TypeBind && mnt.Type != mount.TypeVolume {
+			continue
+		}
+
+		relative, err := filepath.Rel(mnt.Destination, path)
+		if err != nil || strings.HasPrefix(relative, "..") {
+			continue
+		}
+
+		// the innermost mount wins
+		if len(mnt.Destination) > longest {
+			hostPath = filepath.Join(mnt.Source, relative)
+			longest = len(mnt.Destination)
+		}
+	}
+
+	if hostPath == "" {
+		return "", errors.New(fmt.Sprintf(
+			"the Docker API proxy socket %s needs to be in a directory mounted into the controller", path))
+	}
+
+	return hostPath, nil
+}
+
+func getDockerSocket() string {
+	if host := os.Getenv("DOCKER_HOST"); strings.HasPrefix(host, "unix://") {
+		return strings.TrimPrefix(host, "unix://")
+	}
+
+	return defaultDockerSocket
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/rycus86/podlike/pkg/component"
+)
+
+func TestDockerProxy_Start(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cli := &Client{container: &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "c0001", Name: "/pod"},
+		Config: &container.Config{Labels: map[string]string{
+			"pod.docker.proxy": `
+allow:
+  - GET /containers/json
+  - GET /events`,
+			"pod.docker.monitor": `
+target: /var/run/docker-api.sock
+pod_only: true`,
+		}},
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeBind, Source: "/var/run/docker.sock", Destination: "/var/run/docker.sock"},
+			{Type: mount.TypeVolume, Source: dir, Destination: "/var/run/podlike"},
+			{Type: mount.TypeBind, Source: dir + "/nested", Destination: "/var/run/podlike/nested"},
+		},
+	}}
+
+	if err := cli.StartDockerProxies(dir); err == nil {
+		t.Error("Expected to fail outside of the controller's mounts")
+	}
+	cli.StopDockerProxies()
+
+	cli.container.Mounts[1].Destination = dir
+
+	if err := cli.StartDockerProxies(dir); err != nil {
+		t.Fatal("Failed to start the proxies:", err)
+	}
+	defer cli.StopDockerProxies()
+
+	if mnt := cli.GetDockerProxyMount("proxy"); mnt == nil ||
+		mnt.Source != filepath.Join(dir, "proxy.sock") || mnt.Target != "/var/run/docker.sock" {
+
+		t.Errorf("Unexpected mount: %+v", mnt)
+	}
+
+	if mnt := cli.GetDockerProxyMount("monitor"); mnt == nil || mnt.Target != "/var/run/docker-api.sock" {
+		t.Errorf("Unexpected mount: %+v", mnt)
+	}
+
+	if mnt := cli.GetDockerProxyMount("other"); mnt != nil {
+		t.Errorf("Unexpected mount: %+v", mnt)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "monitor.sock")); err != nil {
+		t.Error("The socket was not created:", err)
+	}
+}
+
+func TestDockerProxy_HostPath(t *testing.T) {
+	cli := &Client{container: &types.ContainerJSON{
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeVolume, Source: "/var/lib/docker/volumes/x/_data", Destination: "/var/run"},
+			{Type: mount.TypeBind, Source: "/host/sockets", Destination: "/var/run/podlike"},
+			{Type: mount.TypeTmpfs, Destination: "/var/run/podlike/tmp"},
+		},
+	}}
+
+	for path, expected := range map[string]string{
+		"/var/run/app.sock":             "/var/lib/docker/volumes/x/_data/app.sock",
+		"/var/run/podlike/app.sock":     "/host/sockets/app.sock",
+		"/var/run/podlike/tmp/app.sock": "/host/sockets/tmp/app.sock",
+	} {
+		if hostPath, err := cli.getHostPath(path); err != nil || hostPath != expected {
+			t.Error("Unexpected host path for", path, ":", hostPath, err)
+		}
+	}
+}
+
+func TestDockerProxy_PodContainers(t *testing.T) {
+	cli := &Client{container: &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "c0001", Name: "/pod"},
+	}}
+
+	for _, visible := range [][]string{{"c0001", ""}, {"x", "/pod"}, {"x", "/pod.podlike.app"}} {
+		if !cli.isPodContainer(visible[0], visible[1], nil) {
+			t.Error("Expected to be visible:", visible)
+		}
+	}
+
+	for _, hidden := range [][]string{{"x", "/other"}, {"x", "/pod2"}, {"x", "/other.podlike.app"}} {
+		if cli.isPodContainer(hidden[0], hidden[1], nil) {
+			t.Error("Expected to be hidden:", hidden)
+		}
+	}
+
+	if !cli.isPodContainer("x", "/renamed", map[string]string{component.ControllerLabel: "c0001"}) {
+		t.Error("Expected the labelled component to be visible")
+	}
+
+	if cli.isPodContainer("x", "/other", map[string]string{component.ControllerLabel: "c0002"}) {
+		t.Error("Expected the component of another controller to be hidden")
+	}
+}
+
+func TestDockerProxy_InvalidDefinitions(t *testing.T) {
+	cli := &Client{container: &types.ContainerJSON{}}
+
+	for definition, expectedError := range map[string]string{
+		"target: relative.sock":     "needs to be an absolute path",
+		"allow: [/containers/json]": "invalid Docker API rule",
+		"unknown: field":            "field unknown not found",
+	} {
+		if _, _, err := cli.parseDockerProxyDefinition(definition); err == nil {
+			t.Error("Expected to fail:", definition)
+		} else if !strings.Contains(err.Error(), expectedError) {
+			t.Error("Unexpected error for", definition, ":", err)
+		}
+	}
+}
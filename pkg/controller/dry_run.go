@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	dtc "github.com/docker/docker/api/types/container"
+	"github.com/rycus86/podlike/pkg/component"
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+// NewClientFromInspect returns a client for the controller container
+// in the `docker inspect` output file given, that doesn't connect to the engine.
+func NewClientFromInspect(filename string) (*Client, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []types.ContainerJSON
+
+	// the output of `docker inspect` is an array, but accept a single object too
+	if err := json.Unmarshal(contents, &containers); err != nil {
+		var single types.ContainerJSON
+
+		if err := json.Unmarshal(contents, &single); err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid container inspect file %s : %s", filename, err))
+		}
+
+		containers = append(containers, single)
+	}
+
+	if len(containers) != 1 || containers[0].ContainerJSONBase == nil || containers[0].Config == nil {
+		return nil, errors.New(fmt.Sprintf(
+			"expected the inspect information of a single container in %s", filename))
+	}
+
+	controller := containers[0]
+
+	if controller.HostConfig == nil {
+		controller.HostConfig = &dtc.HostConfig{}
+	}
+
+	// the cgroup of the controller, assuming the default cgroupfs driver
+	cgroup := "/docker/" + controller.ID
+	if parent := controller.HostConfig.CgroupParent; parent != "" {
+		cgroup = path.Join("/", parent, controller.ID)
+	}
+
+	return &Client{
+		cgroup:    cgroup,
+		container: &controller,
+		offline:   true,
+		volumes: newVolumeResolver(
+			&offlineVolumes{namespace: controller.Config.Labels["com.docker.stack.namespace"]},
+			controller.Mounts),
+	}, nil
+}
+
+// PrintCreateRequests writes the configuration of the containers of all the init
+// and regular components as JSON, exactly as they would be created, but without
+// creating the containers, the pod volumes, or serving the Docker API proxies.
+func (c *Client) PrintCreateRequests(w io.Writer, configuration *config.Configuration) error {
+	if err := c.ResolvePodVolumes(); err != nil {
+		return err
+	}
+
+	if err := c.ResolveDockerProxies(configuration.DockerProxyDir); err != nil {
+		return err
+	}
+
+	initComponents, err := c.GetInitComponents()
+	if err != nil {
+		return err
+	}
+
+	components, err := c.GetComponents()
+	if err != nil {
+		return err
+	}
+
+	return component.PrintCreateRequests(w, initComponents, components, configuration)
+}
+
+// offlineVolumes returns the information of the controller's volumes
+// it can guess without the engine, using the stack namespace of the controller.
+type offlineVolumes struct {
+	namespace string
+}
+
+func (v *offlineVolumes) InspectVolume(name string) (types.Volume, error) {
+	volume := types.Volume{Name: name}
+
+	if v.namespace != "" && strings.HasPrefix(name, v.namespace+"_") {
+		volume.Labels = map[string]string{"com.docker.stack.namespace": v.namespace}
+	}
+
+	return volume, nil
+}
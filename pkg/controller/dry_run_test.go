@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/rycus86/podlike/pkg/component"
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+func TestDryRun_FromInspect(t *testing.T) {
+	cli, err := NewClientFromInspect("testdata/dry-run/inspect.json")
+	if err != nil {
+		t.Fatal("Failed to load the inspect file:", err)
+	}
+	defer cli.Close()
+
+	var output bytes.Buffer
+
+	if err := cli.PrintCreateRequests(&output, &config.Configuration{
+		SharePids:      true,
+		DockerProxyDir: "/var/run/podlike",
+	}); err != nil {
+		t.Fatal("Failed to print the create requests:", err)
+	}
+
+	var requests []component.CreateRequest
+
+	if err := json.Unmarshal(output.Bytes(), &requests); err != nil {
+		t.Fatal("Invalid output:", err, output.String())
+	}
+
+	if len(requests) != 3 {
+		t.Fatal("Unexpected requests:", output.String())
+	}
+
+	initRequest, app, sidecar := requests[0], requests[1], requests[2]
+
+	if initRequest.Component != "init-1" || !initRequest.Init || initRequest.Name != "/stack_pod.1.xyz.podlike.init-1" {
+		t.Error("Unexpected init component:", initRequest.Component, initRequest.Init, initRequest.Name)
+	}
+
+	if app.Component != "app" || app.Init || sidecar.Component != "sidecar" {
+		t.Error("Unexpected components:", app.Component, sidecar.Component)
+	}
+
+	controllerID := "0123456789abcdef0123456789abcdef"
+
+	if app.Config.Image != "sample/app" {
+		t.Error("Unexpected image:", app.Config.Image)
+	}
+
+	if app.HostConfig.CgroupParent != "/docker/"+controllerID {
+		t.Error("Unexpected cgroup parent:", app.HostConfig.CgroupParent)
+	}
+
+	if string(app.HostConfig.NetworkMode) != "container:"+controllerID ||
+		string(app.HostConfig.PidMode) != "container:"+controllerID ||
+		string(app.HostConfig.IpcMode) != "" {
+
+		t.Error("Unexpected namespaces:", app.HostConfig.NetworkMode, app.HostConfig.PidMode, app.HostConfig.IpcMode)
+	}
+
+	if app.HostConfig.Memory != 64*1024*1024 || app.HostConfig.OomScoreAdj != 500 {
+		t.Error("Unexpected resources:", app.HostConfig.Memory, app.HostConfig.OomScoreAdj)
+	}
+
+	sources := map[string]string{}
+
+	for _, mnt := range app.HostConfig.Mounts {
+		sources[mnt.Target] = mnt.Source
+
+		if mnt.Target == "/var/run/docker.sock" && (mnt.Type != mount.TypeBind || mnt.Source != "/var/run/podlike-stack/app.sock") {
+			t.Error("Unexpected Docker API proxy mount:", mnt)
+		}
+	}
+
+	if sources["/data"] != "stack_shared" {
+		t.Error("The stack volume is not resolved:", sources)
+	}
+
+	if sources["/tmp/scratch"] != "podlike_0123456789ab_scratch" {
+		t.Error("The pod volume is not resolved:", sources)
+	}
+
+	if _, ok := sources["/var/run/docker.sock"]; !ok {
+		t.Error("Missing Docker API proxy mount:", sources)
+	}
+
+	for _, mnt := range sidecar.HostConfig.Mounts {
+		if mnt.Target == "/var/run/docker.sock" {
+			t.Error("Unexpected Docker API proxy mount for the sidecar")
+		}
+	}
+}
+
+func TestDryRun_InvalidInspect(t *testing.T) {
+	for _, filename := range []string{
+		"testdata/dry-run/missing.json",
+		"testdata/compose/docker-compose.yml",
+	} {
+		if _, err := NewClientFromInspect(filename); err == nil {
+			t.Error("Expected to fail:", filename)
+		}
+	}
+}
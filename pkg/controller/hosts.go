@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/rycus86/podlike/pkg/component"
+)
+
+// the hosts file of the controller, that the components share,
+// because they join the network namespace of the controller
+var hostsFile = "/etc/hosts"
+
+type podExtraHost struct {
+	component.ExtraHost
+
+	component string
+	lenient   bool
+}
+
+type extraHostError struct {
+	component string
+	message   string
+}
+
+func (e extraHostError) Error() string {
+	return e.message
+}
+
+// AddExtraHosts appends the `extra_hosts` entries of the components to the hosts file
+// of the controller, and fails if they conflict with each other, or with the existing entries.
+// The conflicting entries of the Compose services are skipped with a warning instead.
+func (c *Client) AddExtraHosts(components []*component.Component) error {
+	for _, comp := range components {
+		if _, err := comp.GetExtraHosts(); err != nil {
+			return errors.New(fmt.Sprintf("invalid extra_hosts for %s : %s", comp.Name, err))
+		}
+	}
+
+	hosts, errs := collectExtraHosts(components)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	if len(hosts) == 0 || c.offline {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(hostsFile)
+	if err != nil {
+		return err
+	}
+
+	existing := parseHostsFile(string(contents))
+
+	var lines []string
+
+	for _, host := range hosts {
+		if addresses, ok := existing[host.Name]; ok {
+			if contains(addresses, host.Address) {
+				continue
+			}
+
+			message := fmt.Sprintf(
+				"the extra host %s of the %s component conflicts with the hosts file: %s:%s",
+				host, host.component, host.Name, strings.Join(addresses, ","))
+
+			if host.lenient {
+				fmt.Println("[Warning] Skipping", message)
+				continue
+			}
+
+			return errors.New(message)
+		}
+
+		lines = append(lines, fmt.Sprintf("%s\t%s\t# podlike: %s", host.Address, host.Name, host.component))
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	if len(contents) > 0 && !strings.HasSuffix(string(contents), "\n") {
+		lines = append([]string{""}, lines...)
+	}
+
+	f, err := os.OpenFile(hostsFile, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(strings.Join(lines, "\n") + "\n")
+	return err
+}
+
+// collectExtraHosts merges the `extra_hosts` entries of the components, leaving out the duplicates,
+// and returns the errors for the hostnames mapped to different addresses by the components.
+// The conflicting entries of the lenient components are skipped with a warning instead.
+func collectExtraHosts(components []*component.Component) ([]podExtraHost, []extraHostError) {
+	var (
+		hosts []podExtraHost
+		errs  []extraHostError
+		known = map[string]podExtraHost{}
+	)
+
+	for _, comp := range components {
+		items, err := comp.GetExtraHosts()
+		if err != nil {
+			// reported with the other properties of the component
+			continue
+		}
+
+		for _, item := range items {
+			if previous, ok := known[item.Name]; ok {
+				if previous.Address == item.Address {
+					continue
+				}
+
+				message := fmt.Sprintf(
+					"the extra host %s of the %s component conflicts with %s of the %s component",
+					item, comp.Name, previous.ExtraHost, previous.component)
+
+				if comp.IsLenient() {
+					fmt.Println("[Warning] Skipping", message)
+				} else {
+					errs = append(errs, extraHostError{component: comp.Name, message: message})
+				}
+
+				continue
+			}
+
+			host := podExtraHost{ExtraHost: item, component: comp.Name, lenient: comp.IsLenient()}
+
+			known[item.Name] = host
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts, errs
+}
+
+// parseHostsFile returns the addresses for each hostname in the hosts file.
+func parseHostsFile(contents string) map[string][]string {
+	entries := map[string][]string{}
+
+	for _, line := range strings.Split(contents, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		for _, name := range fields[1:] {
+			entries[name] = append(entries[name], fields[0])
+		}
+	}
+
+	return entries
+}
+
+func contains(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	dtc "github.com/docker/docker/api/types/container"
+	"github.com/rycus86/podlike/pkg/component"
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+func withHostsFile(t *testing.T, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "podlike-hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := hostsFile
+	hostsFile = filepath.Join(dir, "hosts")
+
+	if err := ioutil.WriteFile(hostsFile, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return hostsFile, func() {
+		hostsFile = original
+		os.RemoveAll(dir)
+	}
+}
+
+func newHostsTestClient(labels map[string]string) *Client {
+	return &Client{
+		container: &types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:         "c0001",
+				Name:       "/controller",
+				HostConfig: &dtc.HostConfig{},
+			},
+			Config: &dtc.Config{Labels: labels},
+		},
+	}
+}
+
+func TestExtraHosts_Added(t *testing.T) {
+	filename, cleanup := withHostsFile(t, "127.0.0.1\tlocalhost\n10.0.0.2\tcontroller")
+	defer cleanup()
+
+	cli := newHostsTestClient(map[string]string{
+		"pod.component.app":     "{image: sample/app, extra_hosts: ['db:10.0.0.5', 'localhost:127.0.0.1']}",
+		"pod.component.sidecar": "{image: sample/sidecar, extra_hosts: {db: 10.0.0.5, cache: 10.0.0.6}}",
+	})
+
+	components, err := cli.GetComponents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cli.AddExtraHosts(components); err != nil {
+		t.Fatal("Failed to add the extra hosts:", err)
+	}
+
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "127.0.0.1\tlocalhost\n10.0.0.2\tcontroller\n" +
+		"10.0.0.5\tdb\t# podlike: app\n" +
+		"10.0.0.6\tcache\t# podlike: sidecar\n"
+
+	if string(contents) != expected {
+		t.Errorf("Unexpected hosts file:\n%s", contents)
+	}
+}
+
+func TestExtraHosts_Conflicts(t *testing.T) {
+	filename, cleanup := withHostsFile(t, "127.0.0.1\tlocalhost\n")
+	defer cleanup()
+
+	for _, labels := range []map[string]string{
+		{
+			"pod.component.app":     "{image: sample/app, extra_hosts: ['db:10.0.0.5']}",
+			"pod.component.sidecar": "{image: sample/sidecar, extra_hosts: ['db:10.0.0.9']}",
+		},
+		{
+			"pod.component.app": "{image: sample/app, extra_hosts: ['localhost:10.0.0.1']}",
+		},
+	} {
+		cli := newHostsTestClient(labels)
+
+		components, err := cli.GetComponents()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := cli.AddExtraHosts(components); err == nil {
+			t.Error("Expected to fail for", labels)
+		} else if !strings.Contains(err.Error(), "conflicts with") {
+			t.Error("Unexpected error:", err)
+		}
+	}
+
+	if contents, _ := ioutil.ReadFile(filename); string(contents) != "127.0.0.1\tlocalhost\n" {
+		t.Errorf("The hosts file should not change:\n%s", contents)
+	}
+}
+
+func TestExtraHosts_LenientCompose(t *testing.T) {
+	filename, cleanup := withHostsFile(t, "127.0.0.1\tlocalhost\n")
+	defer cleanup()
+
+	first := component.Component{Image: "sample", ExtraHosts: []interface{}{"db:10.0.0.5"}}
+	first.Initialize("first", newHostsTestClient(nil), nil)
+
+	second := component.Component{Image: "sample", ExtraHosts: []interface{}{"db:10.0.0.9", "localhost:10.0.0.1"}}
+	second.Initialize("second", newHostsTestClient(nil), nil)
+	second.EnableLenientMode()
+
+	cli := newHostsTestClient(nil)
+
+	if err := cli.AddExtraHosts([]*component.Component{&first, &second}); err != nil {
+		t.Fatal("Expected only warnings for the lenient component:", err)
+	}
+
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(contents) != "127.0.0.1\tlocalhost\n10.0.0.5\tdb\t# podlike: first\n" {
+		t.Errorf("Unexpected hosts file:\n%s", contents)
+	}
+}
+
+func TestValidate_ExtraHostsAndHostname(t *testing.T) {
+	problems := NewOfflineClient(map[string]string{
+		"pod.component.app":     "{image: sample/app, init: true, hostname: app, extra_hosts: ['db:10.0.0.5']}",
+		"pod.component.sidecar": "{image: sample/sidecar, hostname: 'not valid', extra_hosts: ['db:10.0.0.9', 'x:y']}",
+		"pod.component.worker":  "{image: sample/worker, extra_hosts: ['db:10.0.0.9']}",
+	}).Validate(&config.Configuration{})
+
+	expected := []string{
+		"sidecar > extra_hosts: invalid IP address in the extra host x:y",
+		"sidecar > hostname: invalid hostname: not valid",
+		"worker > extra_hosts: the extra host db:10.0.0.9 of the worker component conflicts with db:10.0.0.5 of the app component",
+	}
+
+	if len(problems) != len(expected) {
+		t.Error("Unexpected problems:", problems)
+	}
+
+	for idx, problem := range problems {
+		if idx < len(expected) && problem.String() != expected[idx] {
+			t.Errorf("Unexpected problem #%d: %s (expected: %s)", idx+1, problem, expected[idx])
+		}
+	}
+}
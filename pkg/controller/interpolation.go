@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rycus86/podlike/pkg/interpolation"
+	"gopkg.in/yaml.v2"
+)
+
+// unmarshalYAML parses the YAML contents into the target,
+// after replacing the variables in the string values, like `${VAR:-default}`.
+func (c *Client) unmarshalYAML(contents []byte, target interface{}, strict bool) error {
+	if bytes.Contains(contents, []byte("$")) {
+		interpolated, err := c.interpolateYAML(contents, strict)
+		if err != nil {
+			return err
+		}
+
+		contents = interpolated
+	}
+
+	if strict {
+		return yaml.UnmarshalStrict(contents, target)
+	} else {
+		return yaml.Unmarshal(contents, target)
+	}
+}
+
+func (c *Client) interpolateYAML(contents []byte, strict bool) ([]byte, error) {
+	var raw interface{}
+
+	if strict {
+		if err := yaml.UnmarshalStrict(contents, &raw); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := yaml.Unmarshal(contents, &raw); err != nil {
+			return nil, err
+		}
+	}
+
+	lookup, err := c.getLookup()
+	if err != nil {
+		return nil, err
+	}
+
+	interpolated, err := interpolation.InterpolateTree(raw, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(interpolated)
+}
+
+// interpolateLabels replaces the variables in the `pod.copy.<component>`
+// and `pod.controller.copy` labels, returned by GetLabels afterwards.
+func (c *Client) interpolateLabels() error {
+	if c.labels != nil {
+		return nil
+	}
+
+	labels := make(map[string]string, len(c.container.Config.Labels))
+
+	for key, value := range c.container.Config.Labels {
+		labels[key] = value
+
+		if !strings.HasPrefix(key, "pod.copy.") && key != "pod.controller.copy" {
+			continue
+		}
+
+		if !strings.Contains(value, "$") {
+			continue
+		}
+
+		interpolated, err := c.interpolateYAML([]byte(value), false)
+		if err != nil {
+			return errors.New(fmt.Sprintf("failed to interpolate the %s label : %s", key, err))
+		}
+
+		labels[key] = string(interpolated)
+	}
+
+	c.labels = labels
+
+	return nil
+}
+
+// getLookup returns the variables for the interpolation, from the environment
+// of the controller first, then from the .env file.
+func (c *Client) getLookup() (interpolation.Lookup, error) {
+	if c.variables == nil {
+		variables, err := c.loadEnvFile()
+		if err != nil {
+			return nil, err
+		}
+
+		c.variables = variables
+	}
+
+	return interpolation.NewLookup(c.variables), nil
+}
+
+// loadEnvFile reads the file in the `pod.env.file` label, or the optional .env file
+// next to the first Compose file, or in the working directory of the controller.
+func (c *Client) loadEnvFile() (map[string]string, error) {
+	if envFile, ok := c.container.Config.Labels["pod.env.file"]; ok {
+		return interpolation.LoadEnvFile(envFile)
+	}
+
+	envFile := ".env"
+
+	if composeFiles, ok := c.getComposeFiles(); ok && len(composeFiles) > 0 {
+		envFile = filepath.Join(filepath.Dir(composeFiles[0]), ".env")
+	}
+
+	variables, err := interpolation.LoadEnvFile(envFile)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+
+	return variables, err
+}
@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rycus86/podlike/pkg/convert"
+)
+
+func TestInterpolation_Components(t *testing.T) {
+	os.Setenv("PODLIKE_TEST_SLOT", "2")
+	defer os.Unsetenv("PODLIKE_TEST_SLOT")
+
+	cli := newTestClient(map[string]string{
+		"pod.env.file": "testdata/interpolation/.env",
+		"pod.component.app": `
+image: sample/app:${APP_VERSION}
+environment:
+  SLOT: ${PODLIKE_TEST_SLOT}
+  PRICE: 5$$`,
+		"pod.init.components": `
+- image: sample/init:${INIT_VERSION:-${APP_VERSION}}`,
+		"pod.copy.app":        "/var/conf/${REGION}.conf:/etc/conf/app.conf",
+		"pod.controller.copy": "app:/data/${PODLIKE_TEST_SLOT}:/backup",
+		"pod.copy.other":      "/var/conf/literal.conf:/etc/conf/$$HOME.conf",
+	}, nil, nil)
+
+	initComponents, err := cli.GetInitComponents()
+	if err != nil {
+		t.Fatal("Failed to get the init components:", err)
+	}
+
+	if image := initComponents[0].Image; image != "sample/init:1.2" {
+		t.Error("Unexpected init image:", image)
+	}
+
+	components, err := cli.GetComponents()
+	if err != nil {
+		t.Fatal("Failed to get the components:", err)
+	}
+
+	app := components[0]
+
+	if app.Image != "sample/app:1.2" {
+		t.Error("Unexpected image:", app.Image)
+	}
+
+	if env, err := convert.ToStringToStringMap(app.Environment); err != nil || env["SLOT"] != "2" || env["PRICE"] != "5$" {
+		t.Error("Unexpected environment:", app.Environment, err)
+	}
+
+	labels := cli.GetLabels()
+
+	if copied := strings.TrimSpace(labels["pod.copy.app"]); copied != "/var/conf/eu-west-1.conf:/etc/conf/app.conf" {
+		t.Error("Unexpected copy label:", copied)
+	}
+
+	if copied := strings.TrimSpace(labels["pod.controller.copy"]); copied != "app:/data/2:/backup" {
+		t.Error("Unexpected controller copy label:", copied)
+	}
+
+	if copied := strings.TrimSpace(labels["pod.copy.other"]); copied != "/var/conf/literal.conf:/etc/conf/$HOME.conf" {
+		t.Error("Unexpected copy label:", copied)
+	}
+}
+
+func TestInterpolation_NonStringFields(t *testing.T) {
+	os.Setenv("PODLIKE_TEST_SHARES", "512")
+	defer os.Unsetenv("PODLIKE_TEST_SHARES")
+
+	components, err := newTestClient(map[string]string{
+		"pod.component.app": `
+image: sample/app
+cpu_shares: ${PODLIKE_TEST_SHARES}
+privileged: ${PODLIKE_TEST_PRIVILEGED:-true}
+stop_grace_period: ${PODLIKE_TEST_GRACE:-45s}
+labels:
+  shares: ${PODLIKE_TEST_SHARES}
+healthcheck:
+  retries: ${PODLIKE_TEST_RETRIES:-3}`,
+	}, nil, nil).GetComponents()
+
+	if err != nil {
+		t.Fatal("Failed to get the components:", err)
+	}
+
+	app := components[0]
+
+	if app.CPUShares != 512 {
+		t.Error("Unexpected CPU shares:", app.CPUShares)
+	}
+
+	if !app.Privileged {
+		t.Error("Expected to be privileged")
+	}
+
+	if app.StopGracePeriod != 45*time.Second {
+		t.Error("Unexpected stop grace period:", app.StopGracePeriod)
+	}
+
+	if app.Healthcheck == nil || app.Healthcheck.Retries != 3 {
+		t.Error("Unexpected healthcheck:", app.Healthcheck)
+	}
+
+	if labels, err := convert.ToStringToStringMap(app.Labels); err != nil || labels["shares"] != "512" {
+		t.Error("Unexpected labels:", app.Labels, err)
+	}
+}
+
+func TestInterpolation_ComposeFile(t *testing.T) {
+	components, err := newTestClient(map[string]string{
+		"pod.compose.file":  "testdata/interpolation/docker-compose.yml",
+		"pod.component.app": "cpu_shares: ${CPU_SHARES:-256}",
+	}, nil, nil).GetComponents()
+
+	if err != nil {
+		t.Fatal("Failed to get the components:", err)
+	}
+
+	app := components[0]
+
+	if app.Image != "sample/app:1.2" {
+		t.Error("Unexpected image:", app.Image)
+	}
+
+	env, err := convert.ToStringToStringMap(app.Environment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if env["REGION"] != "eu-west-1" || env["LITERAL"] != "${NOT_INTERPOLATED}" {
+		t.Error("Unexpected environment:", app.Environment)
+	}
+
+	if app.OomScoreAdj == nil || *app.OomScoreAdj != 100 {
+		t.Error("Unexpected OOM score:", app.OomScoreAdj)
+	}
+
+	if !app.Privileged || app.CPUs != 0.5 || app.CPUShares != 256 {
+		t.Error("Unexpected component:", app)
+	}
+}
+
+func TestInterpolation_Errors(t *testing.T) {
+	for _, labels := range []map[string]string{
+		{"pod.component.app": "image: sample/app:${MISSING_VERSION:?the version is required}"},
+		{"pod.component.app": "image: sample/app:${INVALID"},
+		{"pod.component.app": "image: sample", "pod.copy.app": "/src/${MISSING:?required}:/target"},
+		{"pod.component.app": "image: sample/app:${APP_VERSION}", "pod.env.file": "testdata/interpolation/missing.env"},
+	} {
+		if _, err := newTestClient(labels, nil, nil).GetComponents(); err == nil {
+			t.Error("Expected to fail:", labels)
+		}
+	}
+
+	_, err := newTestClient(map[string]string{
+		"pod.init.components": "[{image: 'sample/init:${INIT_VERSION:?missing init version}'}]",
+	}, nil, nil).GetInitComponents()
+
+	if err == nil || !strings.Contains(err.Error(), "missing init version") {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func TestInterpolation_LabelsOfNewClient(t *testing.T) {
+	os.Setenv("PODLIKE_TEST_SLOT", "3")
+	defer os.Unsetenv("PODLIKE_TEST_SLOT")
+
+	test := newTestClient(map[string]string{
+		"pod.controller.copy": "app:/data/${PODLIKE_TEST_SLOT}:/backup",
+	}, nil, nil)
+
+	cli, err := newClient(test.engine, test.container, "")
+	if err != nil {
+		t.Fatal("Failed to create the client:", err)
+	}
+
+	// read by CopyFilesFromComponents in the background, before the components are loaded
+	if copied := strings.TrimSpace(cli.GetLabels()["pod.controller.copy"]); copied != "app:/data/3:/backup" {
+		t.Error("Unexpected controller copy label:", copied)
+	}
+
+	test = newTestClient(map[string]string{
+		"pod.controller.copy": "app:/data/${PODLIKE_TEST_MISSING:?required}:/backup",
+	}, nil, nil)
+
+	if _, err := newClient(test.engine, test.container, ""); err == nil {
+		t.Error("Expected to fail on the missing variable")
+	}
+}
@@ -2,57 +2,328 @@ package controller
 
 import (
 	"fmt"
-	"github.com/docker/docker/api/types/mount"
+	"io"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
 )
 
-var (
-	volumeInitializer sync.Once
-	volumeMappings    map[string]string
+const (
+	resolvedByLabel   = "label"
+	resolvedByStack   = "stack namespace"
+	resolvedByCompose = "compose label"
+	resolvedByName    = "name"
+
+	defaultVolumeInspectRetries = 3
+	defaultVolumeInspectDelay   = 200 * time.Millisecond
 )
 
-func (c *Client) GetSharedVolumeSource(source string) string {
-	volumeInitializer.Do(c.ensureVolumesAreMapped)
+type volumeInspector interface {
+	InspectVolume(name string) (types.Volume, error)
+}
+
+// volumeReference is a volume attached to the controller,
+// that can be referenced by a name, for the reason given.
+type volumeReference struct {
+	Name   string
+	Driver string
+	Reason string
+}
+
+func (r volumeReference) String() string {
+	return fmt.Sprintf("%s (driver: %s, by %s)", r.Name, r.Driver, r.Reason)
+}
+
+// volumeResolver maps the references used in the component definitions
+// to the actual names of the volumes attached to the controller.
+type volumeResolver struct {
+	lock sync.Mutex
+
+	inspector volumeInspector
+	mounts    []types.MountPoint
+
+	retries    int
+	retryDelay time.Duration
+
+	initialized bool
+
+	// the possible references of each volume, keyed by the volume name
+	claims map[string]map[string]volumeReference
+	// the last errors of the volumes failed to inspect
+	failed map[string]error
+
+	mappings  map[string]volumeReference
+	ambiguous map[string][]volumeReference
+}
+
+func newVolumeResolver(inspector volumeInspector, mounts []types.MountPoint) *volumeResolver {
+	return &volumeResolver{
+		inspector:  inspector,
+		mounts:     mounts,
+		retries:    defaultVolumeInspectRetries,
+		retryDelay: defaultVolumeInspectDelay,
+	}
+}
+
+func (c *Client) getVolumeResolver() *volumeResolver {
+	c.resolverInit.Do(func() {
+		if c.volumes == nil {
+			c.volumes = newVolumeResolver(c.engine, c.container.Mounts)
+		}
+	})
+
+	return c.volumes
+}
+
+// GetSharedVolume returns the name and the driver of the pod or controller volume
+// the source refers to, or empty strings if it doesn't refer to any.
+func (c *Client) GetSharedVolume(source string) (string, string) {
+	if podVolume, ok := c.podVolumes[source]; ok {
+		return podVolume, ""
+	}
+
+	if ref, ok := c.getVolumeResolver().lookup(source); ok {
+		return ref.Name, ref.Driver
+	}
 
-	return volumeMappings[source]
+	return "", ""
 }
 
-func (c *Client) ensureVolumesAreMapped() {
-	volumeMappings = map[string]string{}
+// RefreshVolumeMappings discards the resolved volume references,
+// so they are resolved again on the next lookup.
+func (c *Client) RefreshVolumeMappings() {
+	c.getVolumeResolver().reset()
+}
+
+// DumpVolumeMappings writes the resolved volume references
+// in a human readable format for debugging.
+func (c *Client) DumpVolumeMappings(w io.Writer) {
+	fmt.Fprintln(w, "Pod volumes:")
+
+	for _, ref := range sortedKeys(c.podVolumes) {
+		fmt.Fprintf(w, "  %s -> %s\n", ref, c.podVolumes[ref])
+	}
+
+	c.getVolumeResolver().dump(w)
+}
+
+func (r *volumeResolver) lookup(source string) (volumeReference, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.initialized {
+		r.resolve(r.mountedVolumes())
+		r.initialized = true
+	} else if _, ok := r.mappings[source]; !ok && len(r.failed) > 0 {
+		// give the volumes that failed previously another chance
+		var retry []types.MountPoint
+
+		for _, mnt := range r.mountedVolumes() {
+			if _, failed := r.failed[mnt.Name]; failed {
+				retry = append(retry, mnt)
+			}
+		}
+
+		r.resolve(retry)
+	}
+
+	ref, ok := r.mappings[source]
+	return ref, ok
+}
+
+func (r *volumeResolver) reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.initialized = false
+	r.claims = nil
+	r.failed = nil
+	r.mappings = nil
+	r.ambiguous = nil
+}
+
+func (r *volumeResolver) mountedVolumes() []types.MountPoint {
+	var volumes []types.MountPoint
+
+	for _, mnt := range r.mounts {
+		if mnt.Type == mount.TypeVolume && mnt.Name != "" {
+			volumes = append(volumes, mnt)
+		}
+	}
+
+	return volumes
+}
+
+// resolve inspects the volumes of the mounts given, and rebuilds the mappings.
+func (r *volumeResolver) resolve(mounts []types.MountPoint) {
+	if r.claims == nil {
+		r.claims = map[string]map[string]volumeReference{}
+		r.failed = map[string]error{}
+	}
+
+	for _, mnt := range mounts {
+		claims := map[string]volumeReference{}
 
-	for _, mnt := range c.container.Mounts {
-		if mnt.Type != mount.TypeVolume {
+		// volumes can always be referenced by their own name,
+		// which also covers the ones attached with --mount and an explicit driver
+		claims[mnt.Name] = volumeReference{Name: mnt.Name, Driver: mnt.Driver, Reason: resolvedByName}
+
+		volume, err := r.inspect(mnt.Name)
+		if err != nil {
+			fmt.Println("[Warning] Failed to get volume information for", mnt.Name, ":", err)
+
+			r.failed[mnt.Name] = err
+			r.claims[mnt.Name] = claims
 			continue
 		}
 
-		norm := c.fetchNormalizedVolumeName(mnt.Name)
-		if norm != "" {
-			volumeMappings[norm] = mnt.Name
+		delete(r.failed, mnt.Name)
+
+		driver := volume.Driver
+		if driver == "" {
+			driver = mnt.Driver
+		}
+
+		for reason, ref := range getVolumeReferences(volume) {
+			claims[ref] = volumeReference{Name: volume.Name, Driver: driver, Reason: reason}
+		}
+
+		if self, ok := claims[mnt.Name]; ok && self.Reason == resolvedByName {
+			self.Driver = driver
+			claims[mnt.Name] = self
 		}
+
+		r.claims[mnt.Name] = claims
 	}
+
+	r.buildMappings()
 }
 
-func (c *Client) fetchNormalizedVolumeName(name string) string {
-	volume, err := c.engine.InspectVolume(name)
-	if err != nil {
-		fmt.Println("Failed to get volume information for", name)
-		return ""
+func (r *volumeResolver) inspect(name string) (types.Volume, error) {
+	var (
+		volume types.Volume
+		err    error
+	)
+
+	for attempt := 0; attempt <= r.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(r.retryDelay * time.Duration(attempt))
+		}
+
+		volume, err = r.inspector.InspectVolume(name)
+		if err == nil || client.IsErrNotFound(err) {
+			break
+		}
 	}
 
-	if explicitName, ok := volume.Labels["com.github.rycus86.podlike.volume-ref"]; ok {
-		return explicitName
+	return volume, err
+}
+
+func getVolumeReferences(volume types.Volume) map[string]string {
+	references := map[string]string{}
+
+	if explicitName, ok := volume.Labels[VolumeRefLabel]; ok {
+		references[resolvedByLabel] = explicitName
+		return references
 	}
 
 	if swarmNamespace, ok := volume.Labels["com.docker.stack.namespace"]; ok {
 		if strings.HasPrefix(volume.Name, swarmNamespace+"_") {
-			return strings.TrimPrefix(volume.Name, swarmNamespace+"_")
+			references[resolvedByStack] = strings.TrimPrefix(volume.Name, swarmNamespace+"_")
 		}
 	}
 
 	if composeName, ok := volume.Labels["com.docker.compose.volume"]; ok {
-		return composeName
+		references[resolvedByCompose] = composeName
+	}
+
+	return references
+}
+
+// buildMappings resolves each reference to a single volume.
+// Explicit reference labels take precedence, otherwise references
+// claimed by more than one volume are ambiguous, and are not resolved.
+func (r *volumeResolver) buildMappings() {
+	candidates := map[string][]volumeReference{}
+
+	for _, volumeName := range sortedKeys(r.claims) {
+		for ref, claim := range r.claims[volumeName] {
+			candidates[ref] = append(candidates[ref], claim)
+		}
+	}
+
+	r.mappings = map[string]volumeReference{}
+	r.ambiguous = map[string][]volumeReference{}
+
+	for ref, claims := range candidates {
+		var labelled []volumeReference
+
+		for _, claim := range claims {
+			if claim.Reason == resolvedByLabel {
+				labelled = append(labelled, claim)
+			}
+		}
+
+		if len(labelled) > 0 {
+			claims = labelled
+		}
+
+		if len(claims) == 1 {
+			r.mappings[ref] = claims[0]
+		} else {
+			fmt.Println("[Warning] The volume reference", ref, "is ambiguous, it can refer to any of:", claims)
+
+			r.ambiguous[ref] = claims
+		}
+	}
+}
+
+func (r *volumeResolver) dump(w io.Writer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.initialized {
+		r.resolve(r.mountedVolumes())
+		r.initialized = true
+	}
+
+	fmt.Fprintln(w, "Volume mappings:")
+
+	for _, ref := range sortedKeys(r.mappings) {
+		fmt.Fprintf(w, "  %s -> %s\n", ref, r.mappings[ref])
 	}
 
-	return ""
+	if len(r.ambiguous) > 0 {
+		fmt.Fprintln(w, "Ambiguous volume references:")
+
+		for _, ref := range sortedKeys(r.ambiguous) {
+			fmt.Fprintf(w, "  %s -> %v\n", ref, r.ambiguous[ref])
+		}
+	}
+
+	if len(r.failed) > 0 {
+		fmt.Fprintln(w, "Failed volume lookups:")
+
+		for _, name := range sortedKeys(r.failed) {
+			fmt.Fprintf(w, "  %s : %s\n", name, r.failed[name])
+		}
+	}
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+
+	sort.Strings(keys)
+
+	return keys
 }
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+)
+
+type stubInspector struct {
+	volumes map[string]types.Volume
+	// the number of failures to return for each volume before succeeding
+	failures map[string]int
+	calls    map[string]int
+}
+
+func (s *stubInspector) InspectVolume(name string) (types.Volume, error) {
+	s.calls[name]++
+
+	if s.failures[name] > 0 {
+		s.failures[name]--
+		return types.Volume{}, errors.New("temporary failure")
+	}
+
+	if volume, ok := s.volumes[name]; ok {
+		return volume, nil
+	}
+
+	return types.Volume{}, errors.New("unexpected volume: " + name)
+}
+
+func newStubResolver(inspector *stubInspector, names ...string) *volumeResolver {
+	var mounts []types.MountPoint
+
+	for _, name := range names {
+		mounts = append(mounts, types.MountPoint{Type: mount.TypeVolume, Name: name, Destination: "/" + name})
+	}
+
+	inspector.calls = map[string]int{}
+
+	resolver := newVolumeResolver(inspector, mounts)
+	resolver.retryDelay = 0
+
+	return resolver
+}
+
+func TestVolumeResolver_References(t *testing.T) {
+	resolver := newStubResolver(&stubInspector{volumes: map[string]types.Volume{
+		"explicit-vol": {Name: "explicit-vol", Driver: "local", Labels: map[string]string{
+			VolumeRefLabel: "explicit", "com.docker.stack.namespace": "explicit"}},
+		"stack_data": {Name: "stack_data", Driver: "local", Labels: map[string]string{
+			"com.docker.stack.namespace": "stack"}},
+		"project_logs": {Name: "project_logs", Driver: "local", Labels: map[string]string{
+			"com.docker.compose.volume": "logs"}},
+		"remote": {Name: "remote", Driver: "nfs-plugin"},
+	}}, "explicit-vol", "stack_data", "project_logs", "remote")
+
+	for source, expected := range map[string]volumeReference{
+		"explicit":     {Name: "explicit-vol", Driver: "local", Reason: resolvedByLabel},
+		"data":         {Name: "stack_data", Driver: "local", Reason: resolvedByStack},
+		"logs":         {Name: "project_logs", Driver: "local", Reason: resolvedByCompose},
+		"remote":       {Name: "remote", Driver: "nfs-plugin", Reason: resolvedByName},
+		"project_logs": {Name: "project_logs", Driver: "local", Reason: resolvedByName},
+	} {
+		if ref, ok := resolver.lookup(source); !ok || ref != expected {
+			t.Errorf("Unexpected reference for %s: %+v (%v)", source, ref, ok)
+		}
+	}
+
+	if _, ok := resolver.lookup("unknown"); ok {
+		t.Error("Unexpected reference for an unknown volume")
+	}
+}
+
+func TestVolumeResolver_Ambiguous(t *testing.T) {
+	resolver := newStubResolver(&stubInspector{volumes: map[string]types.Volume{
+		"stack_data": {Name: "stack_data", Labels: map[string]string{
+			"com.docker.stack.namespace": "stack"}},
+		"project_data": {Name: "project_data", Labels: map[string]string{
+			"com.docker.compose.volume": "data"}},
+		"first": {Name: "first", Labels: map[string]string{VolumeRefLabel: "labelled"}},
+		"second": {Name: "second", Labels: map[string]string{
+			"com.docker.compose.volume": "labelled"}},
+	}}, "stack_data", "project_data", "first", "second")
+
+	if ref, ok := resolver.lookup("data"); ok {
+		t.Error("Expected an ambiguous reference, got:", ref)
+	}
+
+	if len(resolver.ambiguous["data"]) != 2 {
+		t.Error("Unexpected ambiguous references:", resolver.ambiguous)
+	}
+
+	if ref, ok := resolver.lookup("labelled"); !ok || ref.Name != "first" {
+		t.Error("Expected the explicit label to take precedence, got:", ref)
+	}
+}
+
+func TestVolumeResolver_Retries(t *testing.T) {
+	inspector := &stubInspector{
+		volumes: map[string]types.Volume{
+			"stack_flaky":  {Name: "stack_flaky", Labels: map[string]string{"com.docker.stack.namespace": "stack"}},
+			"stack_broken": {Name: "stack_broken", Labels: map[string]string{"com.docker.stack.namespace": "stack"}},
+		},
+		failures: map[string]int{"stack_flaky": 2, "stack_broken": 8},
+	}
+
+	resolver := newStubResolver(inspector, "stack_flaky", "stack_broken")
+
+	if ref, ok := resolver.lookup("flaky"); !ok || ref.Name != "stack_flaky" {
+		t.Error("Expected to resolve after retries, got:", ref)
+	}
+
+	if _, ok := resolver.lookup("broken"); ok {
+		t.Error("Expected to fail resolving the broken volume")
+	}
+
+	if inspector.calls["stack_flaky"] != 3 {
+		t.Error("Unexpected number of inspections:", inspector.calls)
+	}
+
+	// the failed volume is retried on the next lookup
+	if ref, ok := resolver.lookup("broken"); !ok || ref.Name != "stack_broken" {
+		t.Error("Expected to resolve on a later lookup, got:", ref)
+	}
+
+	if len(resolver.failed) != 0 {
+		t.Error("Unexpected failures:", resolver.failed)
+	}
+
+	resolver.reset()
+
+	if ref, ok := resolver.lookup("flaky"); !ok || ref.Name != "stack_flaky" {
+		t.Error("Expected to resolve after a reset, got:", ref)
+	}
+
+	if inspector.calls["stack_flaky"] != 4 {
+		t.Error("Expected to inspect again after a reset:", inspector.calls)
+	}
+}
+
+func TestVolumeResolver_Dump(t *testing.T) {
+	inspector := &stubInspector{
+		volumes: map[string]types.Volume{
+			"stack_data": {Name: "stack_data", Driver: "local", Labels: map[string]string{
+				"com.docker.stack.namespace": "stack"}},
+		},
+		failures: map[string]int{"broken": 10},
+	}
+
+	cli := &Client{
+		podVolumes: map[string]string{"cache": "podlike_01234_cache"},
+		volumes:    newStubResolver(inspector, "stack_data", "broken"),
+	}
+
+	var output bytes.Buffer
+	cli.DumpVolumeMappings(&output)
+
+	for _, expected := range []string{
+		"cache -> podlike_01234_cache",
+		"data -> stack_data (driver: local, by stack namespace)",
+		"broken : temporary failure",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected %q in the output:\n%s", expected, output.String())
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/rycus86/podlike/pkg/component"
+)
+
+// RemoveOrphanedComponents removes the containers of the components left behind
+// by the earlier controllers of the same pod, that are not running anymore.
+// These are the controllers of the earlier tasks of the same Swarm service,
+// or outside Swarm, the earlier controllers with the same container name.
+func (c *Client) RemoveOrphanedComponents() error {
+	scope := component.ControllerNameLabel + "=" + strings.TrimPrefix(c.container.Name, "/")
+
+	if serviceID := c.container.Config.Labels["com.docker.swarm.service.id"]; serviceID != "" {
+		scope = "com.docker.swarm.service.id=" + serviceID
+	}
+
+	containers, err := c.engine.ListContainers(component.ControllerLabel, scope)
+	if err != nil {
+		return err
+	}
+
+	running := map[string]bool{}
+
+	for _, item := range containers {
+		controllerID := item.Labels[component.ControllerLabel]
+		if controllerID == c.container.ID {
+			continue
+		}
+
+		isRunning, checked := running[controllerID]
+		if !checked {
+			isRunning, err = c.isControllerRunning(controllerID)
+			if err != nil {
+				return err
+			}
+
+			running[controllerID] = isRunning
+		}
+
+		if isRunning {
+			continue
+		}
+
+		fmt.Printf("Removing the orphaned %s component of the %s controller ...\n",
+			item.Labels[component.ComponentLabel], item.Labels[component.ControllerNameLabel])
+
+		if err := c.engine.RemoveContainer(item.ID); err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) isControllerRunning(containerID string) (bool, error) {
+	controller, err := c.engine.InspectContainer(containerID)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return controller.State != nil && controller.State.Running, nil
+}
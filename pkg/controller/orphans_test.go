@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/rycus86/podlike/pkg/component"
+	"github.com/rycus86/podlike/pkg/engine"
+)
+
+func TestOrphans_Removed(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		filters string
+		removed []string
+	)
+
+	containers := []types.Container{
+		{ID: "own", Labels: map[string]string{component.ControllerLabel: "01234", component.ComponentLabel: "app"}},
+		{ID: "alive", Labels: map[string]string{component.ControllerLabel: "running", component.ComponentLabel: "app"}},
+		{ID: "stopped", Labels: map[string]string{component.ControllerLabel: "exited", component.ComponentLabel: "app"}},
+		{ID: "gone", Labels: map[string]string{component.ControllerLabel: "missing", component.ComponentLabel: "app"}},
+		{ID: "sidecar", Labels: map[string]string{component.ControllerLabel: "missing", component.ComponentLabel: "sidecar"}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/json"):
+			filters = r.URL.Query().Get("filters")
+			json.NewEncoder(w).Encode(containers)
+
+		case strings.HasSuffix(r.URL.Path, "/containers/running/json"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"Id": "running", "State": map[string]interface{}{"Running": true}})
+
+		case strings.HasSuffix(r.URL.Path, "/containers/exited/json"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"Id": "exited", "State": map[string]interface{}{"Running": false}})
+
+		case r.Method == "DELETE":
+			removed = append(removed, strings.TrimPrefix(r.URL.Path[strings.LastIndex(r.URL.Path, "/containers/"):], "/containers/"))
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "No such container"})
+
+		}
+	}))
+	defer server.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHTTPClient(server.Client()), client.WithHost(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Client{
+		engine: engine.NewEngineWithDockerClient(cli),
+		container: &types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "01234", Name: "/pod_app.1.xyz"},
+			Config: &container.Config{Labels: map[string]string{
+				"com.docker.swarm.service.id": "s0001",
+			}},
+		},
+	}
+
+	if err := c.RemoveOrphanedComponents(); err != nil {
+		t.Fatal("Failed to remove the orphaned components:", err)
+	}
+
+	sort.Strings(removed)
+
+	if !reflect.DeepEqual(removed, []string{"gone", "sidecar", "stopped"}) {
+		t.Error("Unexpected removed containers:", removed)
+	}
+
+	for _, expected := range []string{component.ControllerLabel, "com.docker.swarm.service.id=s0001"} {
+		if !strings.Contains(filters, expected) {
+			t.Error("Missing filter:", expected, "in", filters)
+		}
+	}
+
+	c.container.Config.Labels = nil
+
+	if err := c.RemoveOrphanedComponents(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(filters, component.ControllerNameLabel+"=pod_app.1.xyz") {
+		t.Error("Expected to filter by the controller name outside Swarm:", filters)
+	}
+}
@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"sync"
+
 	"github.com/docker/docker/api/types"
+	"github.com/rycus86/podlike/pkg/api"
 	"github.com/rycus86/podlike/pkg/engine"
 )
 
@@ -10,5 +13,25 @@ type Client struct {
 	cgroup    string
 	container *types.ContainerJSON
 
+	// the labels with the variables replaced, and the variables from the .env file
+	labels    map[string]string
+	variables map[string]string
+
+	podVolumes map[string]string
+
+	volumes      *volumeResolver
+	resolverInit sync.Once
+
+	dockerProxies map[string]*dockerProxy
+
+	exports     api.Exports
+	exportsInit sync.Once
+
+	// validating the definitions only, without a container or an engine
+	offline bool
+
+	// fail on the component properties that are not supported, instead of ignoring them
+	strict bool
+
 	closed bool
 }
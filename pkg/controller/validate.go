@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	dtc "github.com/docker/docker/api/types/container"
+	"github.com/rycus86/podlike/pkg/component"
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+// Problem is an invalid part of the pod definition,
+// with the component and the field, or label, it belongs to.
+type Problem struct {
+	Component string
+	Field     string
+	Message   string
+}
+
+func (p Problem) String() string {
+	if p.Component == "" {
+		return p.Field + ": " + p.Message
+	}
+
+	return p.Component + " > " + p.Field + ": " + p.Message
+}
+
+// NewOfflineClient returns a client for validating the labels given,
+// without a controller container, or a connection to the Docker engine.
+func NewOfflineClient(labels map[string]string) *Client {
+	return &Client{
+		container: &types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:         "offline",
+				Name:       "/podlike",
+				HostConfig: &dtc.HostConfig{},
+			},
+			Config: &dtc.Config{Labels: labels},
+		},
+		offline: true,
+	}
+}
+
+// Validate parses all the pod definitions in the labels the same way as starting
+// the pod would, then checks the components and their dependencies,
+// and returns all the problems found.
+func (c *Client) Validate(configuration *config.Configuration) []Problem {
+	var problems []Problem
+
+	add := func(component, field string, err error) {
+		problems = append(problems, Problem{Component: component, Field: field, Message: err.Error()})
+	}
+
+	if err := c.interpolateLabels(); err != nil {
+		add("", "labels", err)
+		return problems
+	}
+
+	labels := c.container.Config.Labels
+
+	initComponents, err := c.GetInitComponents()
+	if err != nil {
+		add("", "pod.init.components", err)
+	}
+
+	// the components of the invalid labels, to check the references to them still
+	invalid := map[string]bool{}
+
+	// parse the labels one by one first, to report all the invalid ones
+	for _, key := range sortedKeys(labels) {
+		if !strings.HasPrefix(key, "pod.component.") {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, "pod.component.")
+
+		value, err := resolveDefinition(key, labels[key])
+		if err != nil {
+			add(name, key, err)
+			invalid[name] = true
+			continue
+		}
+
+		if _, err := c.parseComponent(name, value); err != nil {
+			if properties, ok := err.(*component.PropertiesError); ok {
+				for _, problem := range properties.Problems {
+					problems = append(problems, Problem{Component: name, Field: problem.Field, Message: problem.Message})
+				}
+			} else {
+				add(name, key, err)
+			}
+
+			invalid[name] = true
+		}
+	}
+
+	components, err := c.getComponents(invalid)
+	if err != nil {
+		if _, ok := c.getComposeFiles(); ok {
+			add("", "pod.compose.file", err)
+		} else {
+			add("", "labels", err)
+		}
+
+		return problems
+	}
+
+	if len(components)+len(invalid) == 0 {
+		add("", "labels", errors.New("no components found"))
+	}
+
+	names := map[string]bool{}
+
+	for _, comp := range append(initComponents, components...) {
+		names[comp.Name] = true
+
+		for _, problem := range comp.Validate(configuration) {
+			problems = append(problems, Problem{Component: comp.Name, Field: problem.Field, Message: problem.Message})
+		}
+	}
+
+	_, hostErrors := collectExtraHosts(append(initComponents, components...))
+
+	for _, name := range sortedKeys(invalid) {
+		if names[name] {
+			// a Compose service the invalid label would patch
+			continue
+		}
+
+		// stands in for the invalid component in the checks of the references to it
+		placeholder := &component.Component{}
+		placeholder.Initialize(name, c, c.engine)
+
+		names[name] = true
+		components = append(components, placeholder)
+	}
+
+	for _, err := range checkDependencies(components) {
+		add(err.component, "depends_on", err)
+	}
+
+	for _, err := range hostErrors {
+		add(err.component, "extra_hosts", err)
+	}
+
+	for _, key := range sortedKeys(labels) {
+		if strings.HasPrefix(key, "pod.copy.") {
+			if target := strings.TrimPrefix(key, "pod.copy."); !names[target] {
+				add("", key, errors.New(fmt.Sprintf("the %s component is not defined", target)))
+			}
+
+		} else if key == "pod.controller.copy" {
+			// the errors for the component labels are reported with the components already
+			if _, err := component.ValidateControllerCopy(c.GetLabels()[key]); err != nil {
+				add("", key, err)
+			}
+
+		}
+	}
+
+	for _, err := range c.checkCopySources(initComponents, components) {
+		add("", err.label, err)
+	}
+
+	return problems
+}
+
+type dependencyError struct {
+	component string
+	message   string
+}
+
+func (e dependencyError) Error() string {
+	return e.message
+}
+
+// checkDependencies verifies that the components only depend on defined components,
+// and that there are no circular dependencies between them.
+func checkDependencies(components []*component.Component) []dependencyError {
+	var (
+		errs         []dependencyError
+		dependencies = map[string][]string{}
+	)
+
+	for _, comp := range components {
+		dependencies[comp.Name] = []string{}
+	}
+
+	for _, comp := range components {
+		items, err := comp.GetDependencies()
+		if err != nil {
+			// reported with the other properties of the component
+			continue
+		}
+
+		for _, dependency := range items {
+			if _, ok := dependencies[dependency.Name]; !ok {
+				errs = append(errs, dependencyError{
+					component: comp.Name,
+					message:   fmt.Sprintf("the %s component is not defined", dependency.Name),
+				})
+
+				continue
+			}
+
+			dependencies[comp.Name] = append(dependencies[comp.Name], dependency.Name)
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := map[string]int{}
+
+	var visit func(name string, path []string)
+
+	visit = func(name string, path []string) {
+		switch state[name] {
+		case visiting:
+			// only report the components in the cycle
+			for idx, item := range path {
+				if item == name {
+					path = path[idx:]
+					break
+				}
+			}
+
+			errs = append(errs, dependencyError{
+				component: name,
+				message:   "circular dependency: " + strings.Join(append(path, name), " -> "),
+			})
+			return
+		case visited:
+			return
+		}
+
+		state[name] = visiting
+
+		sort.Strings(dependencies[name])
+
+		for _, dependency := range dependencies[name] {
+			visit(dependency, append(path, name))
+		}
+
+		state[name] = visited
+	}
+
+	for _, name := range sortedKeys(dependencies) {
+		visit(name, nil)
+	}
+
+	return errs
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rycus86/podlike/pkg/config"
+	"github.com/rycus86/podlike/pkg/policy"
+)
+
+func TestValidate_Valid(t *testing.T) {
+	problems := NewOfflineClient(map[string]string{
+		"pod.component.app":     "{image: sample/app, depends_on: [sidecar]}",
+		"pod.component.sidecar": "{image: sample/sidecar, mem_limit: 64m}",
+		"pod.init.components":   "[{image: sample/init}]",
+		"pod.copy.app":          "/etc/app.conf:/etc/app/app.conf",
+		"pod.controller.copy":   "[{from: 'sidecar:/var/log/sidecar.log', target: /var/log/sidecar.log}]",
+	}).Validate(&config.Configuration{})
+
+	if len(problems) > 0 {
+		t.Error("Unexpected problems:", problems)
+	}
+}
+
+func TestValidate_Problems(t *testing.T) {
+	problems := NewOfflineClient(map[string]string{
+		"pod.component.app":    "{image: sample/app, mem_limit: lots, depends_on: [missing, worker]}",
+		"pod.component.worker": "{depends_on: [app]}",
+		"pod.copy.unknown":     "/etc/a.conf:/etc/a.conf",
+		"pod.controller.copy":  "[{from: 'ghost:/etc/b.conf', target: /etc/b.conf}]",
+	}).Validate(&config.Configuration{})
+
+	expected := []string{
+		"app > mem_limit: invalid size: 'lots'",
+		"worker > image: the image is required",
+		"app > depends_on: the missing component is not defined",
+		"app > depends_on: circular dependency: app -> worker -> app",
+		"pod.copy.unknown: the unknown component is not defined",
+		"pod.controller.copy: the ghost component to copy from is not defined",
+	}
+
+	if len(problems) != len(expected) {
+		t.Error("Unexpected problems:", problems)
+	}
+
+	for idx, problem := range problems {
+		if idx < len(expected) && problem.String() != expected[idx] {
+			t.Errorf("Unexpected problem #%d: %s (expected: %s)", idx+1, problem, expected[idx])
+		}
+	}
+}
+
+func TestValidate_ReportsAllLabels(t *testing.T) {
+	problems := NewOfflineClient(map[string]string{
+		"pod.component.one":   "{image: sample, unknown: true}",
+		"pod.component.two":   "gzip+base64:invalid",
+		"pod.component.three": "{image: sample}",
+	}).Validate(&config.Configuration{})
+
+	if len(problems) != 2 {
+		t.Fatal("Unexpected problems:", problems)
+	}
+
+	if problems[0].String() != "one > unknown: unknown property" {
+		t.Error("Unexpected problem:", problems[0])
+	}
+
+	if problems[1].Component != "two" || problems[1].Field != "pod.component.two" {
+		t.Error("Unexpected problem:", problems[1])
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	problems := NewOfflineClient(map[string]string{
+		"pod.component.broken": "{image: sample, unknown: true}",
+		"pod.component.app":    "{image: sample/app, depends_on: [broken, missing], extra_hosts: ['db:10.0.0.1']}",
+		"pod.component.other":  "{image: sample/other, extra_hosts: ['db:10.0.0.2']}",
+		"pod.copy.app":         "[{from: 'broken:/out', target: /in}, {from: 'ghost:/out', target: /in}]",
+	}).Validate(&config.Configuration{})
+
+	expected := []string{
+		"broken > unknown: unknown property",
+		"app > depends_on: the missing component is not defined",
+		"other > extra_hosts: the extra host db:10.0.0.2 of the other component conflicts with db:10.0.0.1 of the app component",
+		"pod.copy.app: the ghost component to copy from is not defined",
+	}
+
+	if len(problems) != len(expected) {
+		t.Error("Unexpected problems:", problems)
+	}
+
+	for idx, problem := range problems {
+		if idx < len(expected) && problem.String() != expected[idx] {
+			t.Errorf("Unexpected problem #%d: %s (expected: %s)", idx+1, problem, expected[idx])
+		}
+	}
+}
+
+func TestValidate_Policy(t *testing.T) {
+	securityPolicy, err := policy.Parse([]byte("{forbidden: [privileged], read_only: true}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := NewOfflineClient(map[string]string{
+		"pod.component.app": "{image: sample, privileged: true}",
+	}).Validate(&config.Configuration{Policy: securityPolicy})
+
+	if len(problems) != 2 || problems[0].Field != "privileged" || problems[1].Field != "read_only" {
+		t.Error("Unexpected problems:", problems)
+	}
+}
+
+func TestValidate_Compose(t *testing.T) {
+	problems := NewOfflineClient(map[string]string{
+		"pod.compose.file":  "testdata/validate/docker-compose.yml",
+		"pod.component.app": "{depends_on: [db]}",
+	}).Validate(&config.Configuration{})
+
+	if len(problems) != 1 || problems[0].String() != "app > depends_on: the db component is not defined" {
+		t.Error("Unexpected problems:", problems)
+	}
+
+	problems = NewOfflineClient(map[string]string{
+		"pod.compose.file": "testdata/compose/undefined-secret.yml",
+	}).Validate(&config.Configuration{})
+
+	if len(problems) != 1 || problems[0].Field != "pod.compose.file" {
+		t.Error("Unexpected problems:", problems)
+	}
+}
+
+func TestValidate_Command(t *testing.T) {
+	var output bytes.Buffer
+
+	if validateDefinitions(&output, "testdata/validate/stack.yml", "testdata/validate/labels.json") {
+		t.Error("Expected to fail")
+	}
+
+	for _, expected := range []string{
+		"testdata/validate/stack.yml: services.valid: OK",
+		"testdata/validate/stack.yml: services.invalid: 6 problem(s)",
+		"  - app > healthcheck.test: invalid string or slice: int 42",
+		"  - app > depends_on: circular dependency: app -> worker -> app",
+		"testdata/validate/stack.yml: services.typo: 1 problem(s)",
+		"  - app > enviroment: unknown property, did you mean environment?",
+		"testdata/validate/stack.yml: services.init-only: 2 problem(s)",
+		"  - init-1 > mem_limit: invalid size: 'lots'",
+		"testdata/validate/stack.yml: services.copy-only: 2 problem(s)",
+		"  - pod.copy.app: the app component is not defined",
+		"testdata/validate/labels.json: labels: 1 problem(s)",
+		"  - app > pull_policy: invalid pull policy for app : sometimes",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Error("Missing from the output:", expected)
+		}
+	}
+
+	if strings.Contains(output.String(), "services.plain") {
+		t.Error("Services without pod labels should be skipped")
+	}
+
+	output.Reset()
+
+	if !validateDefinitions(&output, "-h") || !strings.Contains(output.String(), "podlike validate") {
+		t.Error("Expected to print the help")
+	}
+
+	if validateDefinitions(&output, "testdata/validate/missing.yml") {
+		t.Error("Expected to fail for a missing file")
+	}
+
+	if validateDefinitions(&output) {
+		t.Error("Expected to fail without files")
+	}
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/go-units"
+	"github.com/rycus86/podlike/pkg/component"
+	pv "github.com/rycus86/podlike/pkg/volume"
+	"gopkg.in/yaml.v2"
+	"strings"
+	"time"
+)
+
+const (
+	MediumMemory = "memory"
+
+	VolumeRefLabel        = "com.github.rycus86.podlike.volume-ref"
+	VolumeControllerLabel = component.ControllerLabel
+)
+
+// CreatePodVolumes creates the ephemeral volumes defined in the `pod.volumes` label,
+// that are only used by the components of this controller.
+func (c *Client) CreatePodVolumes() error {
+	return c.preparePodVolumes(true)
+}
+
+// ResolvePodVolumes only resolves the names of the volumes in the `pod.volumes` label,
+// without creating them, for printing the configuration of the components.
+func (c *Client) ResolvePodVolumes() error {
+	return c.preparePodVolumes(false)
+}
+
+func (c *Client) preparePodVolumes(create bool) error {
+	definitions, err := c.getPodVolumeDefinitions()
+	if err != nil {
+		return err
+	}
+
+	// validate all the definitions first, so no volume is left behind on an invalid one
+	volumes := map[string]volume.VolumesCreateBody{}
+
+	for _, reference := range sortedKeys(definitions) {
+		options, err := c.newPodVolumeOptions(reference, definitions[reference])
+		if err != nil {
+			return err
+		}
+
+		volumes[reference] = options
+	}
+
+	c.podVolumes = map[string]string{}
+
+	for _, reference := range sortedKeys(volumes) {
+		options := volumes[reference]
+
+		if !create {
+			c.podVolumes[reference] = options.Name
+			continue
+		}
+
+		created, err := c.engine.CreateVolume(options)
+		if err != nil {
+			c.RemovePodVolumes()
+			return err
+		}
+
+		fmt.Println("Created pod volume", reference, "as", created.Name)
+
+		c.podVolumes[reference] = created.Name
+	}
+
+	return nil
+}
+
+func (c *Client) newPodVolumeOptions(reference string, definition pv.PodVolume) (volume.VolumesCreateBody, error) {
+	options := volume.VolumesCreateBody{
+		Name:   c.getPodVolumeName(reference),
+		Driver: "local",
+		Labels: map[string]string{
+			VolumeRefLabel:        reference,
+			VolumeControllerLabel: c.container.ID,
+		},
+	}
+
+	if definition.Medium == MediumMemory {
+		options.DriverOpts = map[string]string{
+			"type":   "tmpfs",
+			"device": "tmpfs",
+		}
+
+		if definition.Size != "" {
+			size, err := units.RAMInBytes(definition.Size)
+			if err != nil {
+				return options, err
+			}
+
+			options.DriverOpts["o"] = fmt.Sprintf("size=%d", size)
+		}
+
+	} else if definition.Medium != "" {
+		return options, errors.New(fmt.Sprintf("invalid medium for the %s pod volume: %s", reference, definition.Medium))
+
+	} else if definition.Size != "" {
+		return options, errors.New(fmt.Sprintf("size is only supported for memory pod volumes: %s", reference))
+
+	}
+
+	return options, nil
+}
+
+// RemovePodVolumes removes the ephemeral volumes of the pod,
+// waiting for the components using them to be removed first.
+func (c *Client) RemovePodVolumes() {
+	for reference, name := range c.podVolumes {
+		var err error
+
+		for attempt := 0; attempt < 10; attempt++ {
+			if err = c.engine.RemoveVolume(name); err == nil {
+				break
+			}
+
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		if err != nil {
+			fmt.Println("Failed to remove the", reference, "pod volume:", err)
+		} else {
+			fmt.Println("Removed pod volume", reference)
+		}
+
+		delete(c.podVolumes, reference)
+	}
+}
+
+func (c *Client) getPodVolumeName(reference string) string {
+	id := c.container.ID
+	if len(id) > 12 {
+		id = id[:12]
+	}
+
+	return "podlike_" + id + "_" + reference
+}
+
+func (c *Client) getPodVolumeDefinitions() (map[string]pv.PodVolume, error) {
+	definition, ok := c.container.Config.Labels["pod.volumes"]
+	if !ok {
+		return nil, nil
+	}
+
+	var value interface{}
+
+	if err := yaml.Unmarshal([]byte(definition), &value); err != nil {
+		return nil, err
+	}
+
+	volumes := map[string]pv.PodVolume{}
+
+	// a sequence of names, like:
+	//  pod.volumes: [scratch, cache]
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if name, ok := item.(string); ok && strings.TrimSpace(name) != "" {
+				volumes[strings.TrimSpace(name)] = pv.PodVolume{}
+			} else {
+				return nil, errors.New(fmt.Sprintf("invalid pod volume name: %+v (%T)", item, item))
+			}
+		}
+
+		return volumes, nil
+	}
+
+	// a mapping, like:
+	//  pod.volumes: |
+	//    scratch:
+	//    cache:
+	//      medium: memory
+	var mapped map[string]*pv.PodVolume
+
+	if err := yaml.UnmarshalStrict([]byte(definition), &mapped); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid pod.volumes configuration: %s", err))
+	}
+
+	for name, item := range mapped {
+		if item == nil {
+			item = &pv.PodVolume{}
+		}
+
+		volumes[name] = *item
+	}
+
+	return volumes, nil
+}
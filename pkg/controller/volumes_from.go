@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+)
+
+const controllerVolumesSource = "controller"
+
+// GetMountsFrom returns explicit mounts for the controller's volumes
+// selected by a `volumes_from` style definition, like:
+//
+//	controller
+//	controller:ro
+//	controller:/data
+//	controller:/data:ro
+//	controller except docker.sock
+//	controller except /var/run/docker.sock, /secret:ro
+func (c *Client) GetMountsFrom(definition string) ([]mount.Mount, error) {
+	source, selection := definition, ""
+	if idx := strings.IndexAny(definition, ": "); idx >= 0 {
+		source, selection = definition[:idx], definition[idx:]
+	}
+
+	if source != controllerVolumesSource {
+		return nil, errors.New(fmt.Sprintf(
+			"invalid volumes_from definition, only %s is supported as the source: %s",
+			controllerVolumesSource, definition))
+	}
+
+	readOnly := false
+
+	if strings.HasSuffix(selection, ":ro") {
+		readOnly = true
+		selection = strings.TrimSuffix(selection, ":ro")
+	} else if strings.HasSuffix(selection, ":rw") {
+		selection = strings.TrimSuffix(selection, ":rw")
+	}
+
+	var (
+		include = func(mnt types.MountPoint) bool { return true }
+		exclude []string
+	)
+
+	if strings.HasPrefix(selection, ":") {
+		target := strings.TrimPrefix(selection, ":")
+		if !path.IsAbs(target) {
+			return nil, errors.New(fmt.Sprintf(
+				"invalid volumes_from definition, expected an absolute path: %s", definition))
+		}
+
+		include = func(mnt types.MountPoint) bool { return mnt.Destination == path.Clean(target) }
+
+	} else if strings.HasPrefix(selection, " except ") {
+		for _, pattern := range strings.Split(strings.TrimPrefix(selection, " except "), ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				exclude = append(exclude, pattern)
+			}
+		}
+
+		if len(exclude) == 0 {
+			return nil, errors.New(fmt.Sprintf(
+				"invalid volumes_from definition, nothing to exclude: %s", definition))
+		}
+
+	} else if selection != "" {
+		return nil, errors.New(fmt.Sprintf("invalid volumes_from definition: %s", definition))
+	}
+
+	var mounts []mount.Mount
+
+	for _, mnt := range c.container.Mounts {
+		if !include(mnt) || matchesAnyMount(mnt, exclude) {
+			continue
+		}
+
+		if mnt.Type != mount.TypeBind && mnt.Type != mount.TypeVolume {
+			fmt.Println("[Warning] Not sharing the", mnt.Type, "mount of the controller at", mnt.Destination)
+			continue
+		}
+
+		converted := mount.Mount{
+			Type:     mnt.Type,
+			Source:   mnt.Source,
+			Target:   mnt.Destination,
+			ReadOnly: readOnly || !mnt.RW,
+		}
+
+		if mnt.Type == mount.TypeVolume {
+			converted.Source = mnt.Name
+			converted.VolumeOptions = &mount.VolumeOptions{NoCopy: true}
+		} else if mnt.Propagation != "" {
+			converted.BindOptions = &mount.BindOptions{Propagation: mnt.Propagation}
+		}
+
+		mounts = append(mounts, converted)
+	}
+
+	if len(mounts) == 0 && len(exclude) == 0 {
+		return nil, errors.New(fmt.Sprintf(
+			"no matching volumes found on the controller for volumes_from: %s", definition))
+	}
+
+	return mounts, nil
+}
+
+// matchesAnyMount checks if the mount's destination or source, or their base names,
+// match any of the (glob) patterns given.
+func matchesAnyMount(mnt types.MountPoint, patterns []string) bool {
+	for _, pattern := range patterns {
+		for _, candidate := range []string{mnt.Destination, mnt.Source, path.Base(mnt.Destination), path.Base(mnt.Source)} {
+			if candidate == "" {
+				continue
+			}
+
+			if matched, err := path.Match(pattern, candidate); err == nil && matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
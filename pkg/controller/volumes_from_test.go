@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+)
+
+func TestController_MountsFrom(t *testing.T) {
+	cli := &Client{container: &types.ContainerJSON{
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeBind, Source: "/var/run/docker.sock", Destination: "/var/run/docker.sock", RW: true},
+			{Type: mount.TypeVolume, Name: "stack_data", Source: "/var/lib/docker/volumes/stack_data/_data", Destination: "/data", RW: true},
+			{Type: mount.TypeBind, Source: "/etc/shared", Destination: "/shared", RW: false, Propagation: mount.PropagationRShared},
+			{Type: mount.TypeTmpfs, Destination: "/tmp"},
+		},
+	}}
+
+	var (
+		socket = mount.Mount{Type: mount.TypeBind, Source: "/var/run/docker.sock", Target: "/var/run/docker.sock"}
+		data   = mount.Mount{Type: mount.TypeVolume, Source: "stack_data", Target: "/data",
+			VolumeOptions: &mount.VolumeOptions{NoCopy: true}}
+		shared = mount.Mount{Type: mount.TypeBind, Source: "/etc/shared", Target: "/shared", ReadOnly: true,
+			BindOptions: &mount.BindOptions{Propagation: mount.PropagationRShared}}
+		dataRO = data
+	)
+
+	dataRO.ReadOnly = true
+
+	for definition, expected := range map[string][]mount.Mount{
+		"controller":                                    {socket, data, shared},
+		"controller:/data":                              {data},
+		"controller:/data:ro":                           {dataRO},
+		"controller except docker.sock":                 {data, shared},
+		"controller except /var/run/docker.sock, /data": {shared},
+		"controller except *.sock, shared":              {data},
+		"controller except *":                           nil,
+	} {
+		mounts, err := cli.GetMountsFrom(definition)
+		if err != nil {
+			t.Error("Failed to get the mounts for", definition, ":", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(mounts, expected) {
+			t.Errorf("Unexpected mounts for %s:\n%+v\n%+v", definition, mounts, expected)
+		}
+	}
+
+	for definition, expectedError := range map[string]string{
+		"app:/data":           "only controller is supported",
+		"controller:data":     "expected an absolute path",
+		"controller:/missing": "no matching volumes found",
+		"controller except ":  "nothing to exclude",
+		"controller only":     "invalid volumes_from definition",
+	} {
+		if _, err := cli.GetMountsFrom(definition); err == nil {
+			t.Error("Expected to fail:", definition)
+		} else if !strings.Contains(err.Error(), expectedError) {
+			t.Error("Unexpected error for", definition, ":", err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"testing"
+)
+
+func TestController_PodVolumes(t *testing.T) {
+	requested := map[string]map[string]interface{}{}
+
+	cli := newTestClient(map[string]string{
+		"pod.volumes": `
+scratch:
+cache:
+  medium: memory
+  size: 1m`,
+	}, &verifyCreate{
+		Verify: func(name string, body map[string]interface{}) {
+			if name == "volume" {
+				requested[body["Name"].(string)] = body
+			}
+		},
+	}, nil)
+
+	if err := cli.CreatePodVolumes(); err != nil {
+		t.Fatal("Failed to create the pod volumes:", err)
+	}
+
+	if len(requested) != 2 {
+		t.Fatal("Unexpected volumes created:", requested)
+	}
+
+	scratch := requested["podlike_01234_scratch"]
+	if scratch == nil || scratch["DriverOpts"] != nil && len(scratch["DriverOpts"].(map[string]interface{})) > 0 {
+		t.Error("Unexpected scratch volume:", scratch)
+	} else if labels := scratch["Labels"].(map[string]interface{}); labels[VolumeRefLabel] != "scratch" ||
+		labels[VolumeControllerLabel] != "01234" {
+
+		t.Error("Unexpected labels:", labels)
+	}
+
+	cache := requested["podlike_01234_cache"]
+	if cache == nil {
+		t.Error("The cache volume was not created")
+	} else if opts := cache["DriverOpts"].(map[string]interface{}); opts["type"] != "tmpfs" ||
+		opts["device"] != "tmpfs" || opts["o"] != "size=1048576" {
+
+		t.Error("Unexpected driver options:", opts)
+	}
+
+	if source, _ := cli.GetSharedVolume("cache"); source != "podlike_01234_cache" {
+		t.Error("Unexpected volume source:", source)
+	}
+
+	cli.RemovePodVolumes()
+
+	if len(cli.podVolumes) != 0 {
+		t.Error("The pod volumes were not removed:", cli.podVolumes)
+	}
+}
+
+func TestController_PodVolumesAsList(t *testing.T) {
+	cli := newTestClient(map[string]string{"pod.volumes": "[first, second]"}, nil, nil)
+
+	if err := cli.CreatePodVolumes(); err != nil {
+		t.Fatal("Failed to create the pod volumes:", err)
+	}
+
+	if len(cli.podVolumes) != 2 || cli.podVolumes["first"] != "podlike_01234_first" {
+		t.Error("Unexpected pod volumes:", cli.podVolumes)
+	}
+}
+
+func TestController_InvalidPodVolumes(t *testing.T) {
+	for _, definition := range []string{
+		"{scratch: {medium: disk}}",
+		"{scratch: {size: 1m}}",
+		"{scratch: {medium: memory, size: lots}}",
+		"{scratch: {unknown: value}}",
+		"[1, 2]",
+	} {
+		cli := newTestClient(map[string]string{"pod.volumes": definition}, nil, nil)
+
+		if err := cli.CreatePodVolumes(); err == nil {
+			t.Error("Expected to fail:", definition)
+		}
+	}
+}
+
+func TestController_InvalidPodVolumesCreateNothing(t *testing.T) {
+	var requested []string
+
+	for _, definition := range []string{
+		"{first: {}, second: {medium: memory}, third: {medium: disk}}",
+		"{first: {}, second: {size: 1m}}",
+		"{first: {}, second: {medium: memory, size: lots}}",
+	} {
+		cli := newTestClient(map[string]string{"pod.volumes": definition}, &verifyCreate{
+			Verify: func(name string, body map[string]interface{}) {
+				if name == "volume" {
+					requested = append(requested, body["Name"].(string))
+				}
+			},
+		}, nil)
+
+		if err := cli.CreatePodVolumes(); err == nil {
+			t.Error("Expected to fail:", definition)
+		}
+
+		if len(requested) > 0 {
+			t.Error("Unexpected volumes created:", requested)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package definition
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// CompressedPrefix marks gzip compressed, then base64 encoded definitions.
+	CompressedPrefix = "gzip+base64:"
+	// FilePrefix marks definitions that reference a file inside the controller.
+	FilePrefix = "@file:"
+)
+
+// Resolve returns the plain YAML definition of a label value,
+// that can be compressed, or reference a file, that itself can be compressed.
+func Resolve(value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+
+	if strings.HasPrefix(trimmed, FilePrefix) {
+		path := strings.TrimSpace(strings.TrimPrefix(trimmed, FilePrefix))
+
+		if !filepath.IsAbs(path) {
+			return "", errors.New(fmt.Sprintf("the definition file needs to be an absolute path: %s", path))
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", errors.New(fmt.Sprintf("failed to read the definition file %s : %s", path, err))
+		}
+
+		value = string(contents)
+		trimmed = strings.TrimSpace(value)
+	}
+
+	if strings.HasPrefix(trimmed, CompressedPrefix) {
+		return Decompress(trimmed)
+	}
+
+	return value, nil
+}
+
+// Compress returns the definition gzip compressed and base64 encoded, with the marker prefix.
+func Compress(value string) (string, error) {
+	var buffer bytes.Buffer
+
+	writer, err := gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := writer.Write([]byte(value)); err != nil {
+		return "", err
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return CompressedPrefix + base64.StdEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+// Decompress returns the original definition of a compressed one.
+func Decompress(value string) (string, error) {
+	encoded := strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(value), CompressedPrefix)), "")
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("invalid compressed definition: %s", err))
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("invalid compressed definition: %s", err))
+	}
+	defer reader.Close()
+
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("invalid compressed definition: %s", err))
+	}
+
+	return string(contents), nil
+}
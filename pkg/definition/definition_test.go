@@ -0,0 +1,78 @@
+package definition
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sample = `
+image: sample/app
+environment:
+  - KEY=value
+`
+
+func TestCompress_RoundTrip(t *testing.T) {
+	compressed, err := Compress(sample)
+	if err != nil {
+		t.Fatal("Failed to compress:", err)
+	}
+
+	if !strings.HasPrefix(compressed, CompressedPrefix) {
+		t.Error("Missing prefix:", compressed)
+	}
+
+	// labels may be wrapped over multiple lines
+	wrapped := compressed[:20] + "\n  " + compressed[20:] + "\n"
+
+	for _, value := range []string{compressed, wrapped} {
+		if resolved, err := Resolve(value); err != nil {
+			t.Error("Failed to resolve:", err)
+		} else if resolved != sample {
+			t.Error("Unexpected definition:", resolved)
+		}
+	}
+}
+
+func TestResolve_Plain(t *testing.T) {
+	if resolved, err := Resolve(sample); err != nil || resolved != sample {
+		t.Error("Unexpected result:", resolved, err)
+	}
+}
+
+func TestResolve_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-definition")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	compressed, _ := Compress(sample)
+
+	plainFile := filepath.Join(dir, "plain.yml")
+	compressedFile := filepath.Join(dir, "compressed.yml")
+
+	ioutil.WriteFile(plainFile, []byte(sample), 0644)
+	ioutil.WriteFile(compressedFile, []byte(compressed), 0644)
+
+	for _, path := range []string{plainFile, compressedFile} {
+		if resolved, err := Resolve(FilePrefix + path); err != nil {
+			t.Error("Failed to resolve:", path, err)
+		} else if resolved != sample {
+			t.Error("Unexpected definition:", resolved)
+		}
+	}
+
+	for _, value := range []string{
+		FilePrefix + "relative.yml",
+		FilePrefix + filepath.Join(dir, "missing.yml"),
+		CompressedPrefix + "not-base64!",
+		CompressedPrefix + "bm90LWd6aXA=",
+	} {
+		if _, err := Resolve(value); err == nil {
+			t.Error("Expected to fail:", value)
+		}
+	}
+}
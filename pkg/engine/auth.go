@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/rycus86/podlike/pkg/registry"
+)
+
+const (
+	dockerHubDomain = "docker.io"
+	// the key of Docker Hub in the auth file, and for credential helpers
+	dockerHubServer = "https://index.docker.io/v1/"
+)
+
+// credentialHelperOutput is the response of `docker-credential-<helper> get`
+type credentialHelperOutput struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// getRegistryDomain returns the registry domain of an image reference,
+// like docker.io for nginx, or registry.example.com:5000 for registry.example.com:5000/app
+func getRegistryDomain(image string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+
+	return reference.Domain(named), nil
+}
+
+// getAuthConfig returns the credentials for the registry domain,
+// from the credential helpers first, then from the auths in the auth file.
+func (e *Engine) getAuthConfig(domain string) (types.AuthConfig, bool) {
+	serverAddress := domain
+	if domain == dockerHubDomain {
+		serverAddress = dockerHubServer
+	}
+
+	helper := e.auth.CredsStore
+	if specific, ok := e.auth.CredHelpers[domain]; ok {
+		helper = specific
+	} else if specific, ok := e.auth.CredHelpers[serverAddress]; ok {
+		helper = specific
+	}
+
+	if helper != "" {
+		auth, err := getFromCredentialHelper(helper, serverAddress)
+		if err == nil {
+			return auth, true
+		} else if err != errCredentialsNotFound {
+			fmt.Println("[Warning] Failed to get the credentials for", domain, "from", helper, ":", err)
+		}
+	}
+
+	for key, auth := range e.auth.Auths {
+		if normalizeRegistryHost(key) != domain {
+			continue
+		}
+
+		if auth.Username == "" && auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				fmt.Println("[Warning] Invalid credentials for", key, ":", err)
+				continue
+			}
+
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				fmt.Println("[Warning] Invalid credentials for", key, ": expected username:password")
+				continue
+			}
+
+			auth.Username, auth.Password = parts[0], parts[1]
+		}
+
+		auth.Auth = ""
+		auth.ServerAddress = serverAddress
+
+		return auth, true
+	}
+
+	return types.AuthConfig{}, false
+}
+
+func (e *Engine) getRegistryCredentials(domain string) (registry.Credential, bool) {
+	auth, ok := e.getAuthConfig(domain)
+	if !ok {
+		return registry.Credential{}, false
+	}
+
+	return registry.Credential{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		IdentityToken: auth.IdentityToken,
+	}, true
+}
+
+var errCredentialsNotFound = errors.New("credentials not found")
+
+// getFromCredentialHelper runs `docker-credential-<helper> get` for the server address,
+// see https://github.com/docker/docker-credential-helpers
+func getFromCredentialHelper(helper, serverAddress string) (types.AuthConfig, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(serverAddress)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(stdout.String() + stderr.String())
+
+		if strings.Contains(output, "credentials not found") {
+			return types.AuthConfig{}, errCredentialsNotFound
+		}
+
+		return types.AuthConfig{}, errors.New(fmt.Sprintf("%s %s", err, output))
+	}
+
+	var output credentialHelperOutput
+
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return types.AuthConfig{}, errors.New(fmt.Sprintf("invalid output : %s", err))
+	}
+
+	auth := types.AuthConfig{ServerAddress: serverAddress}
+
+	// helpers return identity tokens with the <token> username
+	if output.Username == "<token>" {
+		auth.IdentityToken = output.Secret
+	} else {
+		auth.Username = output.Username
+		auth.Password = output.Secret
+	}
+
+	return auth, nil
+}
+
+// normalizeRegistryHost converts the keys of the auth file to registry domains,
+// like https://index.docker.io/v1/ to docker.io, or https://registry.example.com/v2/ to registry.example.com
+func normalizeRegistryHost(key string) string {
+	host := key
+
+	if strings.Contains(key, "://") {
+		if parsed, err := url.Parse(key); err == nil {
+			host = parsed.Host
+		}
+	} else {
+		host = strings.SplitN(key, "/", 2)[0]
+	}
+
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHubDomain
+	}
+
+	return host
+}
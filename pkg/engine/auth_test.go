@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rycus86/podlike/pkg/config"
+)
+
+func TestGetRegistryDomain(t *testing.T) {
+	for image, expected := range map[string]string{
+		"nginx":                             "docker.io",
+		"nginx:1.15":                        "docker.io",
+		"rycus86/podlike":                   "docker.io",
+		"docker.io/library/nginx":           "docker.io",
+		"localhost/app":                     "localhost",
+		"registry.example.com/team/app:1.0": "registry.example.com",
+		"registry.example.com:5000/app:1.0": "registry.example.com:5000",
+		"registry.example.com:5000/app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef": "registry.example.com:5000",
+	} {
+		if domain, err := getRegistryDomain(image); err != nil || domain != expected {
+			t.Error("Unexpected domain for", image, ":", domain, err)
+		}
+	}
+
+	if _, err := getRegistryDomain("Invalid:Image"); err == nil {
+		t.Error("Expected to fail for an invalid reference")
+	}
+}
+
+func TestNormalizeRegistryHost(t *testing.T) {
+	for key, expected := range map[string]string{
+		"https://index.docker.io/v1/":      "docker.io",
+		"index.docker.io":                  "docker.io",
+		"registry-1.docker.io":             "docker.io",
+		"registry.example.com":             "registry.example.com",
+		"https://registry.example.com/v2/": "registry.example.com",
+		"http://localhost:5000":            "localhost:5000",
+		"registry.example.com:5000/v1/":    "registry.example.com:5000",
+	} {
+		if host := normalizeRegistryHost(key); host != expected {
+			t.Error("Unexpected host for", key, ":", host)
+		}
+	}
+}
+
+func loadTestAuth(t *testing.T, contents string) *Engine {
+	var auth config.RegistryAuth
+
+	if err := json.Unmarshal([]byte(contents), &auth); err != nil {
+		t.Fatal("Invalid auth file:", err)
+	}
+
+	return &Engine{auth: &auth}
+}
+
+func TestGetAuthConfig_Auths(t *testing.T) {
+	e := loadTestAuth(t, `{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": "aHViOnNlY3JldA=="},
+			"registry.example.com": {"username": "user", "password": "pass"},
+			"https://tokens.example.com": {"identitytoken": "r3fr3sh"}
+		}
+	}`)
+
+	if auth, ok := e.getAuthConfig("docker.io"); !ok || auth.Username != "hub" || auth.Password != "secret" {
+		t.Error("Unexpected credentials for Docker Hub:", auth, ok)
+	} else if auth.ServerAddress != "https://index.docker.io/v1/" || auth.Auth != "" {
+		t.Error("Unexpected server address:", auth)
+	}
+
+	if auth, ok := e.getAuthConfig("registry.example.com"); !ok || auth.Username != "user" || auth.Password != "pass" {
+		t.Error("Unexpected credentials:", auth, ok)
+	}
+
+	if cred, ok := e.getRegistryCredentials("tokens.example.com"); !ok || cred.IdentityToken != "r3fr3sh" {
+		t.Error("Unexpected identity token:", cred, ok)
+	}
+
+	if _, ok := e.getAuthConfig("other.example.com"); ok {
+		t.Error("Unexpected credentials for an unknown registry")
+	}
+}
+
+func TestGetAuthConfig_CredentialHelpers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-creds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	helper := `#!/bin/sh
+read server
+case "$server" in
+  https://index.docker.io/v1/) echo '{"ServerURL": "'$server'", "Username": "store-user", "Secret": "store-pass"}' ;;
+  registry.example.com) echo '{"ServerURL": "'$server'", "Username": "<token>", "Secret": "r3fr3sh"}' ;;
+  *) echo "credentials not found in native keychain"; exit 1 ;;
+esac
+`
+
+	for _, name := range []string{"docker-credential-store", "docker-credential-ecr"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(helper), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	e := loadTestAuth(t, `{
+		"auths": {
+			"fallback.example.com": {"username": "fallback", "password": "pass"}
+		},
+		"credsStore": "store",
+		"credHelpers": {
+			"registry.example.com": "ecr"
+		}
+	}`)
+
+	if auth, ok := e.getAuthConfig("docker.io"); !ok || auth.Username != "store-user" || auth.Password != "store-pass" {
+		t.Error("Unexpected credentials from the store:", auth, ok)
+	}
+
+	if auth, ok := e.getAuthConfig("registry.example.com"); !ok || auth.IdentityToken != "r3fr3sh" || auth.Username != "" {
+		t.Error("Unexpected credentials from the helper:", auth, ok)
+	}
+
+	if auth, ok := e.getAuthConfig("fallback.example.com"); !ok || auth.Username != "fallback" {
+		t.Error("Unexpected fallback credentials:", auth, ok)
+	}
+
+	if _, ok := e.getAuthConfig("other.example.com"); ok {
+		t.Error("Unexpected credentials for an unknown registry")
+	}
+}
@@ -10,3 +10,8 @@ func (e *Engine) CopyToContainer(containerID string, destPath string, content io
 	// TODO is context.Background() appropriate here?
 	return e.api.CopyToContainer(context.Background(), containerID, destPath, content, types.CopyToContainerOptions{})
 }
+
+func (e *Engine) CopyFromContainer(containerID string, srcPath string) (io.ReadCloser, error) {
+	reader, _, err := e.api.CopyFromContainer(context.Background(), containerID, srcPath)
+	return reader, err
+}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// GetLocalImageDigests returns the repository digests of the image,
+// if it is available locally, like nginx@sha256:...
+func (e *Engine) GetLocalImageDigests(image string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	inspected, _, err := e.api.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return inspected.RepoDigests, nil
+}
+
+// GetRemoteImageDigest returns the digest of the manifest
+// the image reference points to in its registry.
+func (e *Engine) GetRemoteImageDigest(image string) (string, error) {
+	return e.registry.GetDigest(image)
+}
@@ -9,8 +9,13 @@ import (
 
 	docker "github.com/docker/docker/client"
 	"github.com/rycus86/podlike/pkg/config"
+	"github.com/rycus86/podlike/pkg/registry"
 )
 
+// DefaultRegistryAuthFile is where the registry credentials are loaded from by default,
+// usually a Swarm secret.
+const DefaultRegistryAuthFile = "/var/run/secrets/podlike/dockerregistryauth.json"
+
 func getRegistryAuth(filename string) *config.RegistryAuth {
 	contents, err := ioutil.ReadFile(filename)
 
@@ -28,20 +33,34 @@ func getRegistryAuth(filename string) *config.RegistryAuth {
 	return &auth
 }
 
+// NewEngineWithDockerClient returns an engine using the client given,
+// with the registry credentials from the default auth file.
 func NewEngineWithDockerClient(client *docker.Client) *Engine {
-	return &Engine{
+	e := &Engine{
 		api:  client,
-		auth: getRegistryAuth("/var/run/secrets/podlike/dockerregistryauth.json"),
+		auth: getRegistryAuth(DefaultRegistryAuthFile),
 	}
+
+	e.registry = registry.NewClient(e.getRegistryCredentials)
+
+	return e
 }
 
-func NewEngine() (*Engine, error) {
+// NewEngine connects to the Docker engine, and loads the registry credentials
+// from the auth file given, in the format of the Docker CLI's config.json.
+func NewEngine(authFile string) (*Engine, error) {
 	cli, err := newDockerClient()
 	if err != nil {
 		return nil, err
 	}
 
-	return NewEngineWithDockerClient(cli), nil
+	e := NewEngineWithDockerClient(cli)
+
+	if authFile != DefaultRegistryAuthFile {
+		e.auth = getRegistryAuth(authFile)
+	}
+
+	return e, nil
 }
 
 func newDockerClient() (*docker.Client, error) {
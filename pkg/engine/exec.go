@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"context"
+	"github.com/docker/docker/api/types"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+func (e *Engine) ExecInContainer(containerID string, command []string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	created, err := e.api.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          command,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	attached, err := e.api.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{})
+	if err != nil {
+		return -1, err
+	}
+	defer attached.Close()
+
+	// wait for the command to finish
+	if _, err := io.Copy(ioutil.Discard, attached.Reader); err != nil {
+		return -1, err
+	}
+
+	inspected, err := e.api.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return -1, err
+	}
+
+	return inspected.ExitCode, nil
+}
@@ -0,0 +1,23 @@
+package engine
+
+import (
+	"context"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"time"
+)
+
+// ListContainers returns all the containers, including the stopped ones,
+// that have all the labels given, either as `key` or as `key=value`.
+func (e *Engine) ListContainers(labels ...string) ([]types.Container, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	args := filters.NewArgs()
+
+	for _, label := range labels {
+		args.Add("label", label)
+	}
+
+	return e.api.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
+}
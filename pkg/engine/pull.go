@@ -5,21 +5,21 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"io"
-	"net/url"
+	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
 )
 
 func (e *Engine) PullImage(reference string) (io.ReadCloser, error) {
 	var options types.ImagePullOptions
 
-	registryHost := "https://index.docker.io/v1/"
-
-	if parsed, err := url.Parse("docker://" + reference); err == nil {
-		registryHost = parsed.Host
+	domain, err := getRegistryDomain(reference)
+	if err != nil {
+		return nil, err
 	}
 
-	if auth, ok := e.auth.Auths[registryHost]; ok {
+	if auth, ok := e.getAuthConfig(domain); ok {
 		authJson, _ := json.Marshal(auth)
 		encoded := base64.URLEncoding.EncodeToString(authJson)
 		options.RegistryAuth = encoded
@@ -28,3 +28,18 @@ func (e *Engine) PullImage(reference string) (io.ReadCloser, error) {
 	// TODO is context.Background() appropriate here?
 	return e.api.ImagePull(context.Background(), reference, options)
 }
+
+func (e *Engine) HasImage(image string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if _, _, err := e.api.ImageInspectWithRaw(ctx, image); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
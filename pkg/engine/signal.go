@@ -0,0 +1,13 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+func (e *Engine) SignalContainer(containerID string, signal string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return e.api.ContainerKill(ctx, containerID, signal)
+}
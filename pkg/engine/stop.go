@@ -23,6 +23,7 @@ func (e *Engine) StopContainer(containerID string, timeout *time.Duration) error
 
 func (e *Engine) RemoveContainer(containerID string) error {
 	return e.api.ContainerRemove(context.Background(), containerID, types.ContainerRemoveOptions{
-		Force: true,
+		Force:         true,
+		RemoveVolumes: true,
 	})
 }
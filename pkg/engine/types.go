@@ -5,11 +5,14 @@ import (
 
 	"github.com/docker/docker/client"
 	"github.com/rycus86/podlike/pkg/config"
+	"github.com/rycus86/podlike/pkg/registry"
 )
 
 type Engine struct {
 	api *client.Client
 	auth *config.RegistryAuth
 
+	registry *registry.Client
+
 	cancelEvents context.CancelFunc
 }
@@ -0,0 +1,22 @@
+package engine
+
+import (
+	"context"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+	"time"
+)
+
+func (e *Engine) CreateVolume(options volume.VolumesCreateBody) (types.Volume, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return e.api.VolumeCreate(ctx, options)
+}
+
+func (e *Engine) RemoveVolume(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return e.api.VolumeRemove(ctx, name, false)
+}
@@ -4,14 +4,19 @@ import (
 	"flag"
 	"fmt"
 	"github.com/rycus86/podlike/pkg/config"
+	"github.com/rycus86/podlike/pkg/controller"
+	"github.com/rycus86/podlike/pkg/engine"
 	"github.com/rycus86/podlike/pkg/healthcheck"
+	"github.com/rycus86/podlike/pkg/policy"
 	"github.com/rycus86/podlike/pkg/template"
 	"github.com/rycus86/podlike/pkg/version"
 	"os"
 )
 
 var (
-	pids, ipc, volumes, logs, pull bool
+	pids, ipc, volumes, logs, pull, debugVolumes, dryRun, strict bool
+
+	logFormat, dockerProxyDir, policyFile, pinImages, registryAuthFile, inspectFile string
 )
 
 func init() {
@@ -23,7 +28,22 @@ func setupVariables() {
 	flag.BoolVar(&ipc, "ipc", true, "Enable (default) or disable IPC sharing")
 	flag.BoolVar(&volumes, "volumes", false, "Enable volume sharing from the controller")
 	flag.BoolVar(&logs, "logs", false, "Stream logs from the components")
+	flag.StringVar(&logFormat, "log-format", config.LogFormatText, "The output format of the streamed logs: text or json")
 	flag.BoolVar(&pull, "pull", false, "Always pull the images for the components when starting")
+	flag.StringVar(&dockerProxyDir, "docker-proxy-dir", "/var/run/podlike",
+		"The directory for the Docker API proxy sockets, mounted into the controller")
+	flag.StringVar(&policyFile, "policy", "", "The security policy file to check the components against")
+	flag.StringVar(&registryAuthFile, "registry-auth", engine.DefaultRegistryAuthFile,
+		"The registry credentials file, in the format of the Docker CLI's config.json")
+	flag.StringVar(&pinImages, "pin-images", "",
+		"Resolve the component images to digests: resolve, or require a pinned digest")
+	flag.BoolVar(&debugVolumes, "debug-volumes", false, "Print the resolved volume references on startup")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Print the create requests of the components as JSON, without creating anything")
+	flag.StringVar(&inspectFile, "inspect", "",
+		"The docker inspect output of a controller to use with -dry-run, instead of the current container")
+	flag.BoolVar(&strict, "strict", false,
+		"Fail on the unsupported properties of the components, instead of ignoring them with a warning")
 }
 
 func Parse() *config.Configuration {
@@ -41,6 +61,14 @@ func Parse() *config.Configuration {
 			template.PrintTemplatedStack(os.Args[2:]...)
 			os.Exit(0)
 
+		} else if os.Args[1] == "validate" {
+
+			if controller.ValidateDefinitions(os.Args[2:]...) {
+				os.Exit(0)
+			} else {
+				os.Exit(1)
+			}
+
 		} else if os.Args[1] == "version" {
 
 			v := version.Parse()
@@ -57,11 +85,45 @@ func Parse() *config.Configuration {
 		panic(fmt.Sprintf("Invalid command line argument: %s", flag.Arg(0)))
 	}
 
+	if pinImages != "" && pinImages != config.PinImagesResolve && pinImages != config.PinImagesRequire {
+		panic(fmt.Sprintf("Invalid image pinning mode: %s (expected resolve or require)", pinImages))
+	}
+
+	if logFormat != config.LogFormatText && logFormat != config.LogFormatJSON {
+		panic(fmt.Sprintf("Invalid log format: %s (expected text or json)", logFormat))
+	}
+
+	if inspectFile != "" && !dryRun {
+		panic("The -inspect flag is only supported with -dry-run")
+	}
+
+	var securityPolicy *policy.Policy
+
+	if policyFile != "" {
+		loaded, err := policy.Load(policyFile)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to load the security policy: %s", err))
+		}
+
+		securityPolicy = loaded
+	}
+
 	return &config.Configuration{
 		SharePids:    pids,
 		ShareIpc:     ipc,
 		ShareVolumes: volumes,
 		StreamLogs:   logs,
+		LogFormat:    logFormat,
 		AlwaysPull:   pull,
+		DebugVolumes: debugVolumes,
+		PinImages:    pinImages,
+		DryRun:       dryRun,
+		InspectFile:  inspectFile,
+		Strict:       strict,
+
+		DockerProxyDir:   dockerProxyDir,
+		RegistryAuthFile: registryAuthFile,
+
+		Policy: securityPolicy,
 	}
 }
@@ -0,0 +1,300 @@
+package interpolation
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// LoadEnvFile reads the variables from an env file, like a .env file,
+// with the variables in the values replaced from the environment.
+func LoadEnvFile(filename string) (map[string]string, error) {
+	return LoadEnvFileWithLookup(filename, os.LookupEnv)
+}
+
+// LoadEnvFileWithLookup reads the variables from an env file, using the lookup given
+// for the variables in the values, and for the ones without a value.
+func LoadEnvFileWithLookup(filename string, lookup Lookup) (map[string]string, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := ParseEnvFile(string(contents), lookup)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid env file %s : %s", filename, err))
+	}
+
+	return variables, nil
+}
+
+// ParseEnvFile parses the contents of an env file the same way Compose does:
+//
+//   - empty lines, and lines starting with # are ignored
+//   - the KEY=VALUE lines can have an `export` prefix, and spaces around the =
+//   - unquoted values end at the end of the line, or at a # after a whitespace
+//   - single quoted values are used as they are, and can span multiple lines
+//   - double quoted values can also span multiple lines, and can have
+//     \n, \r, \t, \\, \" and \$ escapes in them
+//   - variables are replaced in the unquoted and double quoted values,
+//     from the lookup first, then from the earlier lines of the file
+//   - a KEY on its own inherits the value from the lookup,
+//     or it's left out if the lookup doesn't have it
+func ParseEnvFile(contents string, lookup Lookup) (map[string]string, error) {
+	p := &envFileParser{
+		input:     strings.Replace(contents, "\r\n", "\n", -1),
+		line:      1,
+		variables: map[string]string{},
+	}
+
+	p.lookup = func(name string) (string, bool) {
+		if value, ok := lookup(name); ok {
+			return value, true
+		}
+
+		value, ok := p.variables[name]
+		return value, ok
+	}
+
+	for {
+		p.skipBlank()
+
+		if p.done() {
+			return p.variables, nil
+		}
+
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		if err := p.parseVariable(); err != nil {
+			return nil, errors.New(fmt.Sprintf("line %d: %s", p.line, err))
+		}
+	}
+}
+
+type envFileParser struct {
+	input string
+	pos   int
+	line  int
+
+	lookup    Lookup
+	variables map[string]string
+}
+
+func (p *envFileParser) parseVariable() error {
+	if strings.HasPrefix(p.input[p.pos:], "export") &&
+		p.pos+6 < len(p.input) && (p.input[p.pos+6] == ' ' || p.input[p.pos+6] == '\t') {
+
+		p.pos += 6
+		p.skipSpaces()
+	}
+
+	start := p.pos
+
+	for !p.done() && isEnvKeyChar(p.peek()) {
+		p.pos++
+	}
+
+	key := p.input[start:p.pos]
+	if key == "" {
+		return errors.New(fmt.Sprintf("unexpected character %q, expected a variable name", p.peek()))
+	}
+
+	p.skipSpaces()
+
+	if p.done() || p.peek() == '\n' || p.peek() == '#' {
+		// a key without a value inherits it
+		if value, ok := p.lookup(key); ok {
+			p.variables[key] = value
+		}
+
+		p.skipLine()
+		return nil
+	}
+
+	if p.peek() != '=' && p.peek() != ':' {
+		return errors.New(fmt.Sprintf("unexpected character %q after the variable name %s", p.peek(), key))
+	}
+
+	p.pos++
+	p.skipSpaces()
+
+	var (
+		value string
+		err   error
+	)
+
+	switch p.peek() {
+	case '\'':
+		value, err = p.parseSingleQuoted()
+	case '"':
+		value, err = p.parseDoubleQuoted()
+	default:
+		value, err = p.parseUnquoted()
+	}
+
+	if err != nil {
+		return errors.New(fmt.Sprintf("invalid value for %s : %s", key, err))
+	}
+
+	p.variables[key] = value
+
+	return nil
+}
+
+func (p *envFileParser) parseUnquoted() (string, error) {
+	start := p.pos
+
+	for !p.done() && p.peek() != '\n' {
+		// inline comments need a whitespace before them
+		if p.peek() == '#' && p.pos > start && isSpace(p.input[p.pos-1]) {
+			break
+		}
+
+		p.pos++
+	}
+
+	value := strings.TrimSpace(p.input[start:p.pos])
+
+	p.skipLine()
+
+	return Interpolate(value, p.lookup)
+}
+
+func (p *envFileParser) parseSingleQuoted() (string, error) {
+	end := strings.IndexByte(p.input[p.pos+1:], '\'')
+	if end < 0 {
+		return "", errors.New("unterminated single quoted value")
+	}
+
+	value := p.input[p.pos+1 : p.pos+1+end]
+
+	p.line += strings.Count(value, "\n")
+	p.pos += end + 2
+
+	return value, p.endOfValue()
+}
+
+func (p *envFileParser) parseDoubleQuoted() (string, error) {
+	var value strings.Builder
+
+	for p.pos++; ; p.pos++ {
+		if p.done() {
+			return "", errors.New("unterminated double quoted value")
+		}
+
+		ch := p.peek()
+
+		if ch == '"' {
+			p.pos++
+			break
+		}
+
+		if ch == '\n' {
+			p.line++
+		}
+
+		if ch == '\\' && p.pos+1 < len(p.input) {
+			p.pos++
+
+			switch escaped := p.peek(); escaped {
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case '$':
+				// escaped for the interpolation
+				value.WriteString("$$")
+			case '\\', '"':
+				value.WriteByte(escaped)
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(escaped)
+			}
+
+			continue
+		}
+
+		value.WriteByte(ch)
+	}
+
+	if err := p.endOfValue(); err != nil {
+		return "", err
+	}
+
+	return Interpolate(value.String(), p.lookup)
+}
+
+// endOfValue allows only whitespace, or a comment after a quoted value.
+func (p *envFileParser) endOfValue() error {
+	p.skipSpaces()
+
+	if !p.done() && p.peek() != '\n' && p.peek() != '#' {
+		return errors.New(fmt.Sprintf("unexpected character %q after the quoted value", p.peek()))
+	}
+
+	p.skipLine()
+
+	return nil
+}
+
+func (p *envFileParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *envFileParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+
+	return p.input[p.pos]
+}
+
+func (p *envFileParser) skipSpaces() {
+	for !p.done() && isSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *envFileParser) skipBlank() {
+	for !p.done() && (isSpace(p.peek()) || p.peek() == '\n') {
+		if p.peek() == '\n' {
+			p.line++
+		}
+
+		p.pos++
+	}
+}
+
+func (p *envFileParser) skipLine() {
+	for !p.done() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\r'
+}
+
+func isEnvKeyChar(ch byte) bool {
+	return isNameChar(ch) || ch == '.' || ch == '-'
+}
+
+// NewLookup returns a Lookup that prefers the environment variables,
+// and falls back to the variables given, like the ones from a .env file.
+func NewLookup(variables map[string]string) Lookup {
+	return func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+
+		value, ok := variables[name]
+		return value, ok
+	}
+}
@@ -0,0 +1,276 @@
+package interpolation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Lookup returns the value of a variable, and whether it is set.
+type Lookup func(name string) (string, bool)
+
+// Interpolate replaces the variables in the input, the same way Compose does:
+//
+//	$VAR or ${VAR}    the value of the variable, or an empty string if unset
+//	${VAR:-default}   the default if the variable is unset or empty
+//	${VAR-default}    the default if the variable is unset
+//	${VAR:?error}     fails with the error if the variable is unset or empty
+//	${VAR?error}      fails with the error if the variable is unset
+//	${VAR:+replace}   the replacement if the variable is set and not empty
+//	${VAR+replace}    the replacement if the variable is set
+//	$$                a literal $ sign
+//
+// The defaults, errors and replacements can contain variables too.
+func Interpolate(input string, lookup Lookup) (string, error) {
+	var result strings.Builder
+
+	for idx := 0; idx < len(input); {
+		if input[idx] != '$' {
+			result.WriteByte(input[idx])
+			idx++
+			continue
+		}
+
+		if idx+1 >= len(input) {
+			return "", invalidFormat(input)
+		}
+
+		next := input[idx+1]
+
+		switch {
+		case next == '$':
+			result.WriteByte('$')
+			idx += 2
+
+		case next == '{':
+			end := findClosingBrace(input, idx+2)
+			if end < 0 {
+				return "", invalidFormat(input)
+			}
+
+			value, err := substitute(input[idx+2:end], input, lookup)
+			if err != nil {
+				return "", err
+			}
+
+			result.WriteString(value)
+			idx = end + 1
+
+		case isNameStart(next):
+			end := idx + 1
+			for end < len(input) && isNameChar(input[end]) {
+				end++
+			}
+
+			value, _ := lookupOrWarn(input[idx+1:end], lookup)
+			result.WriteString(value)
+			idx = end
+
+		default:
+			return "", invalidFormat(input)
+
+		}
+	}
+
+	return result.String(), nil
+}
+
+// substitute evaluates the expression between the braces of ${...}
+func substitute(expression, input string, lookup Lookup) (string, error) {
+	nameEnd := 0
+	for nameEnd < len(expression) && isNameChar(expression[nameEnd]) {
+		nameEnd++
+	}
+
+	name, rest := expression[:nameEnd], expression[nameEnd:]
+
+	if name == "" || !isNameStart(name[0]) {
+		return "", invalidFormat(input)
+	}
+
+	if rest == "" {
+		value, _ := lookupOrWarn(name, lookup)
+		return value, nil
+	}
+
+	value, isSet := lookup(name)
+
+	var operator string
+
+	for _, candidate := range []string{":-", ":?", ":+", "-", "?", "+"} {
+		if strings.HasPrefix(rest, candidate) {
+			operator = candidate
+			break
+		}
+	}
+
+	if operator == "" {
+		return "", invalidFormat(input)
+	}
+
+	operand := func() (string, error) {
+		return Interpolate(rest[len(operator):], lookup)
+	}
+
+	isEmpty := !isSet || value == ""
+
+	switch operator {
+	case ":-":
+		if isEmpty {
+			return operand()
+		}
+
+	case "-":
+		if !isSet {
+			return operand()
+		}
+
+	case ":?", "?":
+		if (operator == ":?" && isEmpty) || (operator == "?" && !isSet) {
+			message, err := operand()
+			if err != nil {
+				return "", err
+			}
+
+			return "", errors.New(fmt.Sprintf("required variable %s is missing a value: %s", name, message))
+		}
+
+	case ":+":
+		if isEmpty {
+			return "", nil
+		}
+
+		return operand()
+
+	case "+":
+		if !isSet {
+			return "", nil
+		}
+
+		return operand()
+
+	}
+
+	return value, nil
+}
+
+// findClosingBrace returns the index of the } closing the expression starting at the index given,
+// skipping over nested expressions, or -1 if it is not closed.
+func findClosingBrace(input string, start int) int {
+	depth := 1
+
+	for idx := start; idx < len(input); idx++ {
+		switch {
+		case strings.HasPrefix(input[idx:], "$$"):
+			idx++
+
+		case strings.HasPrefix(input[idx:], "${"):
+			depth++
+			idx++
+
+		case input[idx] == '}':
+			depth--
+
+			if depth == 0 {
+				return idx
+			}
+
+		}
+	}
+
+	return -1
+}
+
+func lookupOrWarn(name string, lookup Lookup) (string, bool) {
+	value, ok := lookup(name)
+	if !ok {
+		fmt.Println("[Warning] The", name, "variable is not set. Defaulting to a blank string.")
+	}
+
+	return value, ok
+}
+
+func invalidFormat(input string) error {
+	return errors.New(fmt.Sprintf("invalid interpolation format for %q, you may need to escape any $ with another $", input))
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isNameChar(ch byte) bool {
+	return isNameStart(ch) || (ch >= '0' && ch <= '9')
+}
+
+// InterpolateTree interpolates the string values in a parsed YAML document,
+// leaving the keys of the mappings as they are.
+func InterpolateTree(value interface{}, lookup Lookup) (interface{}, error) {
+	return interpolateTree(value, "", lookup)
+}
+
+func interpolateTree(value interface{}, path string, lookup Lookup) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		result, err := Interpolate(v, lookup)
+		if err != nil && path != "" {
+			return nil, errors.New(fmt.Sprintf("%s : %s", path, err))
+		}
+
+		return result, err
+
+	case map[interface{}]interface{}:
+		result := make(map[interface{}]interface{}, len(v))
+
+		// iterate in order, so that the first error is reported consistently
+		keys := make([]string, 0, len(v))
+		byName := make(map[string]interface{}, len(v))
+
+		for key := range v {
+			name := fmt.Sprintf("%v", key)
+			keys = append(keys, name)
+			byName[name] = key
+		}
+
+		sort.Strings(keys)
+
+		for _, name := range keys {
+			key := byName[name]
+
+			item, err := interpolateTree(v[key], joinPath(path, name), lookup)
+			if err != nil {
+				return nil, err
+			}
+
+			result[key] = item
+		}
+
+		return result, nil
+
+	case []interface{}:
+		result := make([]interface{}, len(v))
+
+		for idx, item := range v {
+			interpolated, err := interpolateTree(item, joinPath(path, fmt.Sprintf("[%d]", idx)), lookup)
+			if err != nil {
+				return nil, err
+			}
+
+			result[idx] = interpolated
+		}
+
+		return result, nil
+
+	default:
+		return value, nil
+
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" || strings.HasPrefix(key, "[") {
+		return path + key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,262 @@
+package interpolation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func testLookup(name string) (string, bool) {
+	value, ok := map[string]string{
+		"NAME":  "podlike",
+		"EMPTY": "",
+		"SLOT":  "3",
+		"_X1":   "x",
+	}[name]
+
+	return value, ok
+}
+
+func TestInterpolate(t *testing.T) {
+	for input, expected := range map[string]string{
+		"plain text":                    "plain text",
+		"$NAME":                         "podlike",
+		"${NAME}":                       "podlike",
+		"app-$SLOT.log":                 "app-3.log",
+		"app-${SLOT}x":                  "app-3x",
+		"$_X1-$NAME":                    "x-podlike",
+		"$MISSING":                      "",
+		"${MISSING}":                    "",
+		"$$NAME":                        "$NAME",
+		"$${NAME}":                      "${NAME}",
+		"cost: 5$$":                     "cost: 5$",
+		"${NAME:-default}":              "podlike",
+		"${EMPTY:-default}":             "default",
+		"${MISSING:-default}":           "default",
+		"${EMPTY-default}":              "",
+		"${MISSING-default}":            "default",
+		"${MISSING:-}":                  "",
+		"${MISSING:-a b:c}":             "a b:c",
+		"${NAME:+set}":                  "set",
+		"${EMPTY:+set}":                 "",
+		"${EMPTY+set}":                  "set",
+		"${MISSING+set}":                "",
+		"${MISSING:-${NAME}}":           "podlike",
+		"${MISSING:-${OTHER:-nested}}":  "nested",
+		"${MISSING:-$SLOT-${NAME}}":     "3-podlike",
+		"${MISSING:-$${NOT_A_VAR}}":     "${NOT_A_VAR}",
+		"${NAME:-${MISSING:?not used}}": "podlike",
+		"${NAME:?required}":             "podlike",
+		"${EMPTY?required}":             "",
+		"{NAME} ${NAME} {{.Task.Slot}}": "{NAME} podlike {{.Task.Slot}}",
+	} {
+		if result, err := Interpolate(input, testLookup); err != nil {
+			t.Error("Failed to interpolate", input, ":", err)
+		} else if result != expected {
+			t.Errorf("Unexpected result for %s : %q", input, result)
+		}
+	}
+}
+
+func TestInterpolate_Errors(t *testing.T) {
+	for input, expected := range map[string]string{
+		"${EMPTY:?the value is required}":    "required variable EMPTY is missing a value: the value is required",
+		"${MISSING?needs ${NAME}}":           "required variable MISSING is missing a value: needs podlike",
+		"${MISSING:-${OTHER:?nested error}}": "required variable OTHER is missing a value: nested error",
+		"${NAME":                             "invalid interpolation format",
+		"${}":                                "invalid interpolation format",
+		"${1NAME}":                           "invalid interpolation format",
+		"${NAME*x}":                          "invalid interpolation format",
+		"cost: 5$":                           "invalid interpolation format",
+		"$ 5":                                "invalid interpolation format",
+	} {
+		if _, err := Interpolate(input, testLookup); err == nil {
+			t.Error("Expected to fail:", input)
+		} else if !strings.Contains(err.Error(), expected) {
+			t.Error("Unexpected error for", input, ":", err)
+		}
+	}
+}
+
+func TestInterpolateTree(t *testing.T) {
+	var document interface{}
+
+	err := yaml.Unmarshal([]byte(`
+image: sample/${NAME}:${TAG:-latest}
+environment:
+  SLOT: ${SLOT}
+  PORT: 8080
+command: [echo, "$${NAME}", "$NAME"]
+`), &document)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := InterpolateTree(document, testLookup)
+	if err != nil {
+		t.Fatal("Failed to interpolate:", err)
+	}
+
+	expected := map[interface{}]interface{}{
+		"image": "sample/podlike:latest",
+		"environment": map[interface{}]interface{}{
+			"SLOT": "3",
+			"PORT": 8080,
+		},
+		"command": []interface{}{"echo", "${NAME}", "podlike"},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	_, err = InterpolateTree(map[interface{}]interface{}{
+		"services": map[interface{}]interface{}{
+			"app": map[interface{}]interface{}{
+				"command": []interface{}{"ok", "${MISSING:?oops}"},
+			},
+		},
+	}, testLookup)
+
+	if err == nil || !strings.HasPrefix(err.Error(), "services.app.command[1] : required variable MISSING") {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podlike-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, ".env")
+
+	ioutil.WriteFile(filename, []byte(`
+# comment
+TAG=1.0
+export REGION = eu-west-1
+QUOTED="with spaces"
+SINGLE='$NOT_EXPANDED'
+EMPTY=
+`), 0644)
+
+	variables, err := LoadEnvFile(filename)
+	if err != nil {
+		t.Fatal("Failed to load the file:", err)
+	}
+
+	expected := map[string]string{
+		"TAG":    "1.0",
+		"REGION": "eu-west-1",
+		"QUOTED": "with spaces",
+		"SINGLE": "$NOT_EXPANDED",
+		"EMPTY":  "",
+	}
+
+	if !reflect.DeepEqual(variables, expected) {
+		t.Error("Unexpected variables:", variables)
+	}
+
+	ioutil.WriteFile(filename, []byte("INVALID LINE\n"), 0644)
+
+	if _, err := LoadEnvFile(filename); err == nil {
+		t.Error("Expected to fail for an invalid line")
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	variables, err := ParseEnvFile("# comment\r\n"+
+		"PLAIN=value\r\n"+
+		"  export EXPORTED=yes\n"+
+		"SPACES = around the equals  \n"+
+		"COLON: separated\n"+
+		"COMMENT=value # inline comment\n"+
+		"HASH=value#not-a-comment\n"+
+		"EMPTY=\n"+
+		"SINGLE='$NAME \\n # kept'  # comment\n"+
+		"DOUBLE=\"$NAME\\t\\\"quoted\\\" \\$NAME\"\n"+
+		"MULTI=\"first\nsecond\"\n"+
+		"MULTI_SINGLE='first\nsecond'\n"+
+		"REFERENCE=${PLAIN}-${SLOT:-1}\n"+
+		"OVERRIDDEN=${NAME}\n"+
+		"NAME\n"+
+		"MISSING\n"+
+		"dotted.key-name=ok\n",
+		testLookup)
+
+	if err != nil {
+		t.Fatal("Failed to parse:", err)
+	}
+
+	expected := map[string]string{
+		"PLAIN":           "value",
+		"EXPORTED":        "yes",
+		"SPACES":          "around the equals",
+		"COLON":           "separated",
+		"COMMENT":         "value",
+		"HASH":            "value#not-a-comment",
+		"EMPTY":           "",
+		"SINGLE":          "$NAME \\n # kept",
+		"DOUBLE":          "podlike\t\"quoted\" $NAME",
+		"MULTI":           "first\nsecond",
+		"MULTI_SINGLE":    "first\nsecond",
+		"REFERENCE":       "value-3",
+		"OVERRIDDEN":      "podlike",
+		"NAME":            "podlike",
+		"dotted.key-name": "ok",
+	}
+
+	if !reflect.DeepEqual(variables, expected) {
+		for key, value := range variables {
+			if expected[key] != value {
+				t.Errorf("Unexpected value for %s : %q (expected: %q)", key, value, expected[key])
+			}
+		}
+
+		t.Error("Unexpected variables:", variables)
+	}
+
+	for input, message := range map[string]string{
+		"A=1\nINVALID LINE\n":   "line 2: unexpected character 'L' after the variable name INVALID",
+		"A='unterminated\n":     "line 1: invalid value for A : unterminated single quoted value",
+		"A=\"unterminated\n\n":  "invalid value for A : unterminated double quoted value",
+		"A=\"quoted\" trailing": "line 1: invalid value for A : unexpected character 't' after the quoted value",
+		"=value":                "line 1: unexpected character '=', expected a variable name",
+		"A=${B":                 "line 1: invalid value for A : invalid interpolation format",
+	} {
+		if _, err := ParseEnvFile(input, testLookup); err == nil {
+			t.Error("Expected to fail:", input)
+		} else if !strings.Contains(err.Error(), message) {
+			t.Errorf("Unexpected error for %q : %s", input, err)
+		}
+	}
+}
+
+func TestNewLookup(t *testing.T) {
+	os.Setenv("PODLIKE_TEST_LOOKUP", "from-env")
+	defer os.Unsetenv("PODLIKE_TEST_LOOKUP")
+
+	lookup := NewLookup(map[string]string{
+		"PODLIKE_TEST_LOOKUP": "from-file",
+		"PODLIKE_TEST_FILE":   "file-only",
+	})
+
+	if value, ok := lookup("PODLIKE_TEST_LOOKUP"); !ok || value != "from-env" {
+		t.Error("Unexpected value:", value, ok)
+	}
+
+	if value, ok := lookup("PODLIKE_TEST_FILE"); !ok || value != "file-only" {
+		t.Error("Unexpected value:", value, ok)
+	}
+
+	if _, ok := lookup("PODLIKE_TEST_MISSING"); ok {
+		t.Error("Unexpected value for a missing variable")
+	}
+}
@@ -0,0 +1,249 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"gopkg.in/yaml.v2"
+)
+
+// Policy restricts what the component definitions are allowed to do.
+// Lists left empty (nil) do not restrict anything.
+type Policy struct {
+	// the registries the images can be pulled from, like docker.io
+	Registries []string
+	// patterns for the allowed images, like docker.io/library/* or registry.example.com/team/**
+	Images []string
+	// the names of the component properties that must not be set, like privileged or devices
+	Forbidden []string
+	// the host paths, and their subdirectories, bind mounts can use
+	BindPaths []string `yaml:"bind_paths"`
+	// the capabilities cap_add can add
+	Capabilities []string
+
+	ReadOnly        bool `yaml:"read_only"`
+	NoNewPrivileges bool `yaml:"no_new_privileges"`
+}
+
+// Subject contains the properties of a component to check.
+type Subject struct {
+	Name  string
+	Image string
+
+	// the component definition, with the property names as keys
+	Properties map[string]interface{}
+
+	BindSources []string
+	CapAdd      []string
+	SecurityOpt []string
+	ReadOnly    bool
+}
+
+// Violation is a single property of a component that is not allowed by the policy.
+type Violation struct {
+	Field   string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Field + ": " + v.Message
+}
+
+// ViolationError lists all the violations of a component.
+type ViolationError struct {
+	Component  string
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+
+	for _, violation := range e.Violations {
+		messages = append(messages, "  - "+violation.String())
+	}
+
+	return fmt.Sprintf("the %s component violates the security policy:\n%s",
+		e.Component, strings.Join(messages, "\n"))
+}
+
+// Load reads the policy from the YAML file given.
+func Load(filename string) (*Policy, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(contents)
+}
+
+// Parse reads the policy from its YAML definition.
+func Parse(contents []byte) (*Policy, error) {
+	var policy Policy
+
+	if err := yaml.UnmarshalStrict(contents, &policy); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid security policy: %s", err))
+	}
+
+	for _, pattern := range policy.Images {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), "x"); err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid image pattern in the security policy: %s", pattern))
+		}
+	}
+
+	for _, bindPath := range policy.BindPaths {
+		if !filepath.IsAbs(bindPath) {
+			return nil, errors.New(fmt.Sprintf(
+				"invalid bind path in the security policy, expected an absolute path: %s", bindPath))
+		}
+	}
+
+	return &policy, nil
+}
+
+// Check returns a *ViolationError listing all the violations of the subject, or nil.
+func (p *Policy) Check(subject *Subject) error {
+	var violations []Violation
+
+	add := func(field, format string, args ...interface{}) {
+		violations = append(violations, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if p.Registries != nil || p.Images != nil {
+		if named, err := reference.ParseNormalizedNamed(subject.Image); err != nil {
+			add("image", "invalid image reference %s : %s", subject.Image, err)
+		} else {
+			if p.Registries != nil && !contains(p.Registries, reference.Domain(named)) {
+				add("image", "the %s registry is not allowed, expected one of %v", reference.Domain(named), p.Registries)
+			}
+
+			if p.Images != nil && !matchesImage(p.Images, named.Name()) {
+				add("image", "%s is not allowed, expected one of %v", named.Name(), p.Images)
+			}
+		}
+	}
+
+	var forbidden []string
+
+	for _, property := range p.Forbidden {
+		if value, ok := subject.Properties[property]; ok && !isEmpty(value) {
+			forbidden = append(forbidden, property)
+		}
+	}
+
+	sort.Strings(forbidden)
+
+	for _, property := range forbidden {
+		add(property, "forbidden by the security policy")
+	}
+
+	if p.BindPaths != nil {
+		for _, source := range subject.BindSources {
+			if !isWithinAny(p.BindPaths, source) {
+				add("volumes", "bind mounting %s is not allowed, expected paths within %v", source, p.BindPaths)
+			}
+		}
+	}
+
+	if p.Capabilities != nil {
+		allowed := normalizeCapabilities(p.Capabilities)
+
+		for _, capability := range normalizeCapabilities(subject.CapAdd) {
+			if !contains(allowed, capability) {
+				add("cap_add", "%s is not allowed, expected some of %v", capability, p.Capabilities)
+			}
+		}
+	}
+
+	if p.ReadOnly && !subject.ReadOnly {
+		add("read_only", "the root filesystem is required to be read-only")
+	}
+
+	if p.NoNewPrivileges && !hasNoNewPrivileges(subject.SecurityOpt) {
+		add("security_opt", "no-new-privileges is required")
+	}
+
+	if len(violations) > 0 {
+		return &ViolationError{Component: subject.Name, Violations: violations}
+	}
+
+	return nil
+}
+
+func matchesImage(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/**") {
+			if strings.HasPrefix(name, strings.TrimSuffix(pattern, "**")) {
+				return true
+			}
+		} else if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isWithinAny(directories []string, source string) bool {
+	for _, directory := range directories {
+		relative, err := filepath.Rel(directory, filepath.Clean(source))
+		if err == nil && relative != ".." && !strings.HasPrefix(relative, "../") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeCapabilities(capabilities []string) []string {
+	normalized := make([]string, 0, len(capabilities))
+
+	for _, capability := range capabilities {
+		normalized = append(normalized, strings.TrimPrefix(strings.ToUpper(capability), "CAP_"))
+	}
+
+	return normalized
+}
+
+func hasNoNewPrivileges(securityOpt []string) bool {
+	for _, option := range securityOpt {
+		if option == "no-new-privileges" || option == "no-new-privileges:true" || option == "no-new-privileges=true" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+
+	return reflect.DeepEqual(value, reflect.Zero(v.Type()).Interface())
+}
@@ -0,0 +1,161 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicy_Parse(t *testing.T) {
+	policy, err := Parse([]byte(`
+registries: [docker.io, registry.example.com]
+images:
+  - docker.io/library/*
+  - registry.example.com/team/**
+forbidden: [privileged, devices]
+bind_paths: [/var/log]
+capabilities: [NET_BIND_SERVICE]
+read_only: true
+no_new_privileges: true
+`))
+	if err != nil {
+		t.Fatal("Failed to parse:", err)
+	}
+
+	if len(policy.Registries) != 2 || len(policy.Images) != 2 || len(policy.Forbidden) != 2 ||
+		len(policy.BindPaths) != 1 || len(policy.Capabilities) != 1 || !policy.ReadOnly || !policy.NoNewPrivileges {
+
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+
+	for _, invalid := range []string{
+		"unknown: field",
+		"images: ['docker.io/[library']",
+		"bind_paths: [relative/path]",
+	} {
+		if _, err := Parse([]byte(invalid)); err == nil {
+			t.Error("Expected to fail:", invalid)
+		}
+	}
+}
+
+func TestPolicy_Images(t *testing.T) {
+	policy := &Policy{
+		Registries: []string{"docker.io", "registry.example.com"},
+		Images:     []string{"docker.io/library/*", "registry.example.com/team/**"},
+	}
+
+	for _, image := range []string{
+		"nginx",
+		"nginx:1.15",
+		"library/alpine",
+		"docker.io/library/redis@sha256:" + strings.Repeat("a", 64),
+		"registry.example.com/team/app:v1",
+		"registry.example.com/team/group/app",
+	} {
+		if err := policy.Check(&Subject{Name: "test", Image: image}); err != nil {
+			t.Error("Expected to be allowed:", image, err)
+		}
+	}
+
+	for image, expectedError := range map[string]string{
+		"rycus86/podlike":                 "docker.io/rycus86/podlike is not allowed",
+		"quay.io/coreos/etcd":             "the quay.io registry is not allowed",
+		"registry.example.com/other/app":  "registry.example.com/other/app is not allowed",
+		"registry.example.com/teamwork/x": "registry.example.com/teamwork/x is not allowed",
+		"Invalid:Image":                   "invalid image reference",
+	} {
+		if err := policy.Check(&Subject{Name: "test", Image: image}); err == nil {
+			t.Error("Expected to fail:", image)
+		} else if !strings.Contains(err.Error(), expectedError) {
+			t.Error("Unexpected error for", image, ":", err)
+		}
+	}
+}
+
+func TestPolicy_Violations(t *testing.T) {
+	policy := &Policy{
+		Forbidden:       []string{"privileged", "devices", "pid"},
+		BindPaths:       []string{"/var/log", "/etc/app"},
+		Capabilities:    []string{"NET_BIND_SERVICE", "CHOWN"},
+		ReadOnly:        true,
+		NoNewPrivileges: true,
+	}
+
+	err := policy.Check(&Subject{
+		Name:  "app",
+		Image: "nginx",
+		Properties: map[string]interface{}{
+			"privileged": true,
+			"devices":    []interface{}{"/dev/fuse"},
+			"pid":        "",
+			"tty":        true,
+		},
+		BindSources: []string{"/var/log/app", "/etc/application", "/var/run/docker.sock", "/etc/app/../shadow"},
+		CapAdd:      []string{"cap_chown", "SYS_ADMIN"},
+		SecurityOpt: []string{"label:disable"},
+	})
+
+	if err == nil {
+		t.Fatal("Expected to fail")
+	}
+
+	violationError, ok := err.(*ViolationError)
+	if !ok {
+		t.Fatalf("Unexpected error: %T %s", err, err)
+	}
+
+	expected := []string{
+		"devices: forbidden by the security policy",
+		"privileged: forbidden by the security policy",
+		"volumes: bind mounting /etc/application is not allowed, expected paths within [/var/log /etc/app]",
+		"volumes: bind mounting /var/run/docker.sock is not allowed, expected paths within [/var/log /etc/app]",
+		"volumes: bind mounting /etc/app/../shadow is not allowed, expected paths within [/var/log /etc/app]",
+		"cap_add: SYS_ADMIN is not allowed, expected some of [NET_BIND_SERVICE CHOWN]",
+		"read_only: the root filesystem is required to be read-only",
+		"security_opt: no-new-privileges is required",
+	}
+
+	if len(violationError.Violations) != len(expected) {
+		t.Fatal("Unexpected violations:\n", err)
+	}
+
+	for idx, violation := range violationError.Violations {
+		if violation.String() != expected[idx] {
+			t.Errorf("Unexpected violation:\n%s\n%s", violation, expected[idx])
+		}
+	}
+
+	if !strings.HasPrefix(err.Error(), "the app component violates the security policy:\n  - devices:") {
+		t.Error("Unexpected error message:", err)
+	}
+
+	if err := policy.Check(&Subject{
+		Name:        "app",
+		Properties:  map[string]interface{}{"privileged": false, "devices": []interface{}{}},
+		BindSources: []string{"/var/log", "/etc/app/config"},
+		CapAdd:      []string{"NET_BIND_SERVICE"},
+		SecurityOpt: []string{"no-new-privileges:true"},
+		ReadOnly:    true,
+	}); err != nil {
+		t.Error("Expected to be allowed:", err)
+	}
+}
+
+func TestPolicy_EmptyLists(t *testing.T) {
+	policy, err := Parse([]byte("bind_paths: []\ncapabilities: []"))
+	if err != nil {
+		t.Fatal("Failed to parse:", err)
+	}
+
+	if err := policy.Check(&Subject{Name: "app", BindSources: []string{"/tmp"}}); err == nil {
+		t.Error("Expected bind mounts to be forbidden")
+	}
+
+	if err := policy.Check(&Subject{Name: "app", CapAdd: []string{"CHOWN"}}); err == nil {
+		t.Error("Expected capabilities to be forbidden")
+	}
+
+	if err := policy.Check(&Subject{Name: "app", Image: "anything/goes", Properties: map[string]interface{}{"privileged": true}}); err != nil {
+		t.Error("Expected to be allowed:", err)
+	}
+}
@@ -0,0 +1,364 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionPrefix = regexp.MustCompile(`^/v[0-9.]+/`)
+
+// the endpoints Docker clients need to negotiate the API version
+var alwaysAllowed = []Rule{
+	{Methods: []string{"GET", "HEAD"}, Path: "/_ping"},
+	{Methods: []string{"GET"}, Path: "/version"},
+}
+
+// Rule allows requests with one of the methods to the paths matching the pattern.
+type Rule struct {
+	Methods []string
+	Path    string
+}
+
+// ParseRule parses rules like `GET /containers/json`, `GET,HEAD /_ping` or `* /events`.
+// The path can contain `*` wildcards matching a single path segment.
+func ParseRule(definition string) (Rule, error) {
+	parts := strings.Fields(definition)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "/") {
+		return Rule{}, errors.New(fmt.Sprintf(
+			"invalid Docker API rule, expected METHOD /path : %s", definition))
+	}
+
+	if _, err := path.Match(parts[1], "/"); err != nil {
+		return Rule{}, errors.New(fmt.Sprintf("invalid path pattern in %s : %s", definition, err))
+	}
+
+	return Rule{
+		Methods: strings.Split(strings.ToUpper(parts[0]), ","),
+		Path:    parts[1],
+	}, nil
+}
+
+func (r Rule) matches(method, requestPath string) bool {
+	if matched, _ := path.Match(r.Path, requestPath); !matched {
+		return false
+	}
+
+	for _, allowed := range r.Methods {
+		if allowed == "*" || allowed == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Rule) String() string {
+	return strings.Join(r.Methods, ",") + " " + r.Path
+}
+
+// Policy decides which requests are forwarded to the Docker engine.
+type Policy struct {
+	Allow []Rule
+
+	// IsVisible restricts the visible containers, when set,
+	// based on their ID, name (with a leading slash) and labels
+	IsVisible func(id, name string, labels map[string]string) bool
+}
+
+// Proxy forwards the allowed requests to the Docker engine API.
+type Proxy struct {
+	policy  Policy
+	backend *httputil.ReverseProxy
+	client  *http.Client
+	target  *url.URL
+
+	server *http.Server
+}
+
+// NewProxy returns a proxy forwarding requests to the target URL using the transport given.
+func NewProxy(target *url.URL, transport http.RoundTripper, policy Policy) *Proxy {
+	p := &Proxy{
+		policy: policy,
+		client: &http.Client{Transport: transport},
+		target: target,
+	}
+
+	p.backend = &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = target.Scheme
+			r.URL.Host = target.Host
+		},
+		Transport:      transport,
+		FlushInterval:  -1,
+		ModifyResponse: p.filterResponse,
+	}
+
+	return p
+}
+
+// NewUnixSocketTransport returns a transport connecting to the unix socket given.
+func NewUnixSocketTransport(socketPath string) http.RoundTripper {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}
+}
+
+// ListenAndServe serves the proxy on a new unix socket at the path given.
+func (p *Proxy) ListenAndServe(socketPath string, mode os.FileMode) error {
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(socketPath, mode); err != nil {
+		listener.Close()
+		return err
+	}
+
+	p.server = &http.Server{Handler: p}
+
+	go func() {
+		if err := p.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Println("The Docker API proxy on", socketPath, "has stopped:", err)
+		}
+	}()
+
+	return nil
+}
+
+// Close stops serving the proxy.
+func (p *Proxy) Close() error {
+	if p.server != nil {
+		return p.server.Close()
+	}
+
+	return nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// forward the same path that was checked
+	r.URL.Path = path.Clean(r.URL.Path)
+	r.URL.RawPath = ""
+
+	requestPath := stripVersion(r.URL.Path)
+
+	if !p.isAllowed(r.Method, requestPath) {
+		writeError(w, http.StatusForbidden, fmt.Sprintf(
+			"%s %s is not allowed by the Podlike Docker API proxy", r.Method, requestPath))
+		return
+	}
+
+	if p.policy.IsVisible != nil {
+		if containerRef := getContainerReference(requestPath); containerRef != "" {
+			if visible, err := p.isContainerVisible(containerRef); err != nil {
+				writeError(w, http.StatusBadGateway, err.Error())
+				return
+			} else if !visible {
+				writeError(w, http.StatusNotFound, "No such container: "+containerRef)
+				return
+			}
+		}
+	}
+
+	p.backend.ServeHTTP(w, r)
+}
+
+func (p *Proxy) isAllowed(method, requestPath string) bool {
+	for _, rule := range alwaysAllowed {
+		if rule.matches(method, requestPath) {
+			return true
+		}
+	}
+
+	for _, rule := range p.policy.Allow {
+		if rule.matches(method, requestPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func stripVersion(requestPath string) string {
+	return versionPrefix.ReplaceAllString(path.Clean(requestPath), "/")
+}
+
+// getContainerReference returns the ID or name of the container
+// for container specific endpoints, like /containers/{id}/json
+func getContainerReference(requestPath string) string {
+	parts := strings.Split(strings.TrimPrefix(requestPath, "/"), "/")
+
+	if len(parts) >= 2 && parts[0] == "containers" && parts[1] != "json" && parts[1] != "create" && parts[1] != "prune" {
+		return parts[1]
+	}
+
+	return ""
+}
+
+func (p *Proxy) isContainerVisible(ref string) (bool, error) {
+	response, err := p.client.Get(p.target.String() + "/containers/" + url.PathEscape(ref) + "/json")
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return false, nil
+	} else if response.StatusCode != http.StatusOK {
+		return false, errors.New(fmt.Sprintf("failed to inspect container %s : HTTP %d", ref, response.StatusCode))
+	}
+
+	var inspected struct {
+		ID     string `json:"Id"`
+		Name   string
+		Config struct {
+			Labels map[string]string
+		}
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&inspected); err != nil {
+		return false, err
+	}
+
+	return p.policy.IsVisible(inspected.ID, inspected.Name, inspected.Config.Labels), nil
+}
+
+// filterResponse hides the containers not visible from the container listings and the event stream.
+func (p *Proxy) filterResponse(response *http.Response) error {
+	if p.policy.IsVisible == nil || response.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	switch stripVersion(response.Request.URL.Path) {
+	case "/containers/json":
+		return p.filterContainerList(response)
+
+	case "/events":
+		reader, writer := io.Pipe()
+		go p.filterEvents(response.Body, writer)
+
+		response.Body = reader
+
+	}
+
+	return nil
+}
+
+func (p *Proxy) filterContainerList(response *http.Response) error {
+	data, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	var containers []map[string]interface{}
+
+	if err := json.Unmarshal(data, &containers); err != nil {
+		return err
+	}
+
+	visible := make([]map[string]interface{}, 0, len(containers))
+
+	for _, item := range containers {
+		id, _ := item["Id"].(string)
+		names, _ := item["Names"].([]interface{})
+		rawLabels, _ := item["Labels"].(map[string]interface{})
+
+		labels := make(map[string]string, len(rawLabels))
+		for key, value := range rawLabels {
+			labels[key], _ = value.(string)
+		}
+
+		for _, name := range names {
+			if asString, ok := name.(string); ok && p.policy.IsVisible(id, asString, labels) {
+				visible = append(visible, item)
+				break
+			}
+		}
+	}
+
+	filtered, err := json.Marshal(visible)
+	if err != nil {
+		return err
+	}
+
+	response.Body = ioutil.NopCloser(bytes.NewReader(filtered))
+	response.ContentLength = int64(len(filtered))
+	response.Header.Set("Content-Length", strconv.Itoa(len(filtered)))
+
+	return nil
+}
+
+func (p *Proxy) filterEvents(source io.ReadCloser, target *io.PipeWriter) {
+	defer source.Close()
+
+	reader := bufio.NewReader(source)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+
+		if len(line) > 0 && p.isEventVisible(line) {
+			if _, writeErr := target.Write(line); writeErr != nil {
+				target.CloseWithError(writeErr)
+				return
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				target.Close()
+			} else {
+				target.CloseWithError(err)
+			}
+
+			return
+		}
+	}
+}
+
+func (p *Proxy) isEventVisible(line []byte) bool {
+	var event struct {
+		Type  string
+		Actor struct {
+			ID         string
+			Attributes map[string]string
+		}
+	}
+
+	if err := json.Unmarshal(line, &event); err != nil || event.Type != "container" {
+		return err == nil
+	}
+
+	// the attributes of container events include the labels of the container
+	return p.policy.IsVisible(event.Actor.ID, "/"+event.Actor.Attributes["name"], event.Actor.Attributes)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,230 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newStubBackend(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := stripVersion(r.URL.Path)
+
+		switch {
+		case path == "/containers/json":
+			fmt.Fprint(w, `[
+				{"Id": "c1", "Names": ["/pod"]},
+				{"Id": "c2", "Names": ["/pod.podlike.app"]},
+				{"Id": "c3", "Names": ["/other"]}
+			]`)
+
+		case path == "/containers/c2/json" || path == "/containers/pod.podlike.app/json":
+			fmt.Fprint(w, `{"Id": "c2", "Name": "/pod.podlike.app"}`)
+
+		case path == "/containers/c3/json":
+			fmt.Fprint(w, `{"Id": "c3", "Name": "/other"}`)
+
+		case path == "/events":
+			fmt.Fprintln(w, `{"Type": "container", "Action": "start", "Actor": {"ID": "c2", "Attributes": {"name": "pod.podlike.app"}}}`)
+			fmt.Fprintln(w, `{"Type": "container", "Action": "start", "Actor": {"ID": "c3", "Attributes": {"name": "other"}}}`)
+			fmt.Fprintln(w, `{"Type": "network", "Action": "connect", "Actor": {"ID": "n1"}}`)
+
+		default:
+			fmt.Fprintf(w, `{"Method": "%s", "Path": "%s"}`, r.Method, r.URL.Path)
+
+		}
+	}))
+}
+
+func newTestProxy(t *testing.T, podOnly bool, rules ...string) (*httptest.Server, func()) {
+	backend := newStubBackend(t)
+
+	target, _ := url.Parse(backend.URL)
+
+	policy := Policy{}
+
+	for _, item := range rules {
+		rule, err := ParseRule(item)
+		if err != nil {
+			t.Fatal("Failed to parse the rule:", item, err)
+		}
+
+		policy.Allow = append(policy.Allow, rule)
+	}
+
+	if podOnly {
+		policy.IsVisible = func(id, name string, labels map[string]string) bool {
+			return name == "/pod" || strings.HasPrefix(name, "/pod.podlike.")
+		}
+	}
+
+	server := httptest.NewServer(NewProxy(target, http.DefaultTransport, policy))
+
+	return server, func() {
+		server.Close()
+		backend.Close()
+	}
+}
+
+func request(t *testing.T, method, url string) (int, string) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("Request failed:", err)
+	}
+	defer response.Body.Close()
+
+	body, _ := ioutil.ReadAll(response.Body)
+
+	return response.StatusCode, string(body)
+}
+
+func TestProxy_Allowlist(t *testing.T) {
+	server, closer := newTestProxy(t, false, "GET /containers/json", "GET,HEAD /containers/*/json", "* /events")
+	defer closer()
+
+	for _, item := range []struct {
+		Method string
+		Path   string
+		Status int
+	}{
+		{"GET", "/_ping", 200},
+		{"GET", "/v1.37/version", 200},
+		{"GET", "/containers/json", 200},
+		{"GET", "/v1.37/containers/json", 200},
+		{"GET", "/containers/c3/json", 200},
+		{"HEAD", "/containers/c3/json", 200},
+		{"POST", "/events", 200},
+		{"POST", "/containers/json", 403},
+		{"POST", "/containers/create", 403},
+		{"DELETE", "/containers/c3", 403},
+		{"POST", "/containers/c3/exec", 403},
+		{"GET", "/containers/c3/logs", 403},
+		{"POST", "/containers/json/../../containers/c3/stop", 403},
+		{"GET", "/images/json", 403},
+	} {
+		if status, body := request(t, item.Method, server.URL+item.Path); status != item.Status {
+			t.Errorf("Unexpected status for %s %s : %d (%s)", item.Method, item.Path, status, body)
+		}
+	}
+}
+
+func TestProxy_ForwardsCleanPath(t *testing.T) {
+	server, closer := newTestProxy(t, false, "GET /containers/*/json")
+	defer closer()
+
+	status, body := request(t, "GET", server.URL+"/v1.37/containers/c3/../c3/json")
+	if status != 200 || !strings.Contains(body, `"Id": "c3"`) {
+		t.Error("Unexpected response:", status, body)
+	}
+}
+
+func TestProxy_PodOnlyContainers(t *testing.T) {
+	server, closer := newTestProxy(t, true, "GET /containers/json", "GET /containers/*/json")
+	defer closer()
+
+	status, body := request(t, "GET", server.URL+"/containers/json")
+	if status != 200 {
+		t.Fatal("Unexpected status:", status, body)
+	}
+
+	var containers []struct{ Id string }
+
+	if err := json.Unmarshal([]byte(body), &containers); err != nil {
+		t.Fatal("Invalid response:", body, err)
+	}
+
+	if len(containers) != 2 || containers[0].Id != "c1" || containers[1].Id != "c2" {
+		t.Error("Unexpected containers:", containers)
+	}
+
+	if status, body := request(t, "GET", server.URL+"/containers/pod.podlike.app/json"); status != 200 {
+		t.Error("Unexpected status for a pod container:", status, body)
+	}
+
+	if status, body := request(t, "GET", server.URL+"/containers/c3/json"); status != 404 {
+		t.Error("Unexpected status for another container:", status, body)
+	}
+}
+
+func TestProxy_PodOnlyEvents(t *testing.T) {
+	server, closer := newTestProxy(t, true, "GET /events")
+	defer closer()
+
+	status, body := request(t, "GET", server.URL+"/events")
+	if status != 200 {
+		t.Fatal("Unexpected status:", status, body)
+	}
+
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+
+	if len(lines) != 2 || !strings.Contains(lines[0], `"c2"`) || !strings.Contains(lines[1], `"network"`) {
+		t.Error("Unexpected events:", lines)
+	}
+}
+
+func TestProxy_UnixSocket(t *testing.T) {
+	backend := newStubBackend(t)
+	defer backend.Close()
+
+	dir, err := ioutil.TempDir("", "podlike-proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	target, _ := url.Parse(backend.URL)
+	socketPath := filepath.Join(dir, "app.sock")
+
+	p := NewProxy(target, http.DefaultTransport, Policy{})
+	if err := p.ListenAndServe(socketPath, 0660); err != nil {
+		t.Fatal("Failed to serve:", err)
+	}
+	defer p.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", socketPath)
+		},
+	}}
+
+	response, err := client.Get("http://docker/_ping")
+	if err != nil {
+		t.Fatal("Request failed:", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != 200 {
+		t.Error("Unexpected status:", response.StatusCode)
+	}
+
+	if fi, err := os.Stat(socketPath); err != nil || fi.Mode().Perm() != 0660 {
+		t.Error("Unexpected socket file:", fi, err)
+	}
+}
+
+func TestProxy_InvalidRules(t *testing.T) {
+	for _, definition := range []string{
+		"/containers/json",
+		"GET containers/json",
+		"GET /containers/[json",
+		"GET /a /b",
+	} {
+		if _, err := ParseRule(definition); err == nil {
+			t.Error("Expected to fail:", definition)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+)
+
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+)
+
+var manifestTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v1+prettyjws",
+}
+
+// Credential is either a username and password, or an identity (refresh) token.
+type Credential struct {
+	Username      string
+	Password      string
+	IdentityToken string
+}
+
+// Credentials returns the credential for a registry domain, if there is one.
+type Credentials func(domain string) (Credential, bool)
+
+// Client talks to the distribution (v2) API of image registries.
+type Client struct {
+	HTTPClient  *http.Client
+	Credentials Credentials
+}
+
+func NewClient(credentials Credentials) *Client {
+	return &Client{
+		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
+		Credentials: credentials,
+	}
+}
+
+// GetDigest returns the digest of the manifest the image reference points to.
+// For references with a digest, this verifies that the manifest exists.
+func (c *Client) GetDigest(image string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+
+	manifest := "latest"
+
+	if canonical, ok := named.(reference.Canonical); ok {
+		manifest = canonical.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		manifest = tagged.Tag()
+	}
+
+	domain := reference.Domain(named)
+
+	target := url.URL{
+		Scheme: getScheme(domain),
+		Host:   getRegistryHost(domain),
+		Path:   "/v2/" + reference.Path(named) + "/manifests/" + manifest,
+	}
+
+	response, err := c.request("HEAD", target.String(), domain)
+	if err != nil {
+		return "", err
+	}
+	response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		if digest := response.Header.Get("Docker-Content-Digest"); digest != "" {
+			return digest, nil
+		}
+	}
+
+	// some registries only return the digest for GET requests
+	response, err = c.request("GET", target.String(), domain)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", errors.New(fmt.Sprintf(
+			"failed to get the manifest of %s : HTTP %d", named.String(), response.StatusCode))
+	}
+
+	if digest := response.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, response.Body); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (c *Client) request(method, target, domain string) (*http.Response, error) {
+	response, err := c.send(method, target, "")
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+
+	response.Body.Close()
+
+	authorization, err := c.authorize(response.Header.Get("WWW-Authenticate"), domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.send(method, target, authorization)
+}
+
+func (c *Client) send(method, target, authorization string) (*http.Response, error) {
+	request, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	return c.HTTPClient.Do(request)
+}
+
+// authorize returns the Authorization header value for the challenge,
+// using basic authentication, or fetching a bearer token.
+func (c *Client) authorize(challenge, domain string) (string, error) {
+	var (
+		credential     Credential
+		hasCredentials bool
+	)
+
+	if c.Credentials != nil {
+		credential, hasCredentials = c.Credentials(domain)
+	}
+
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCredentials || credential.Username == "" {
+			return "", errors.New("the registry requires credentials for " + domain)
+		}
+
+		request, _ := http.NewRequest("GET", "/", nil)
+		request.SetBasicAuth(credential.Username, credential.Password)
+
+		return request.Header.Get("Authorization"), nil
+
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || params["realm"] == "" {
+			return "", errors.New("invalid authentication realm: " + params["realm"])
+		}
+
+		query := url.Values{}
+		for _, key := range []string{"service", "scope"} {
+			if value, ok := params[key]; ok {
+				query.Set(key, value)
+			}
+		}
+
+		var request *http.Request
+
+		if hasCredentials && credential.IdentityToken != "" {
+			// exchange the identity token for an access token with OAuth2
+			query.Set("grant_type", "refresh_token")
+			query.Set("refresh_token", credential.IdentityToken)
+			query.Set("client_id", "podlike")
+
+			request, err = http.NewRequest("POST", realm.String(), strings.NewReader(query.Encode()))
+			if err != nil {
+				return "", err
+			}
+
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		} else {
+			realmQuery := realm.Query()
+			for key := range query {
+				realmQuery.Set(key, query.Get(key))
+			}
+			realm.RawQuery = realmQuery.Encode()
+
+			request, err = http.NewRequest("GET", realm.String(), nil)
+			if err != nil {
+				return "", err
+			}
+
+			if hasCredentials {
+				request.SetBasicAuth(credential.Username, credential.Password)
+			}
+		}
+
+		response, err := c.HTTPClient.Do(request)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(response.Body)
+			return "", errors.New(fmt.Sprintf(
+				"failed to get a token for %s : HTTP %d %s", domain, response.StatusCode, body))
+		}
+
+		var token struct {
+			Token       string
+			AccessToken string `json:"access_token"`
+		}
+
+		if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+			return "", err
+		}
+
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+
+		return "Bearer " + token.Token, nil
+
+	default:
+		return "", errors.New(fmt.Sprintf("unsupported authentication challenge from %s : %s", domain, challenge))
+	}
+}
+
+// parseChallenge parses WWW-Authenticate headers, like:
+//
+//	Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+
+	rest := parts[1]
+
+	for rest != "" {
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+
+		var value string
+
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+
+		params[key] = value
+		rest = strings.TrimLeft(rest, ", ")
+	}
+
+	return parts[0], params
+}
+
+func getRegistryHost(domain string) string {
+	if domain == dockerHubDomain {
+		return dockerHubRegistry
+	}
+
+	return domain
+}
+
+// getScheme returns http for local registries, like Docker does, and https otherwise.
+func getScheme(domain string) string {
+	host := domain
+	if h, _, err := net.SplitHostPort(domain); err == nil {
+		host = h
+	}
+
+	if host == "localhost" {
+		return "http"
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return "http"
+	}
+
+	return "https"
+}
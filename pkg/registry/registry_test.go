@@ -0,0 +1,215 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// newStubRegistry serves the manifest of test/app:1.0 and the digest above
+func newStubRegistry(t *testing.T, authorized func(r *http.Request) bool, challenge func(url string) string) *httptest.Server {
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.Method == "POST" {
+				r.ParseForm()
+
+				if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != "r3fr3sh" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				fmt.Fprint(w, `{"access_token": "t0k3n"}`)
+				return
+			}
+
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if r.URL.Query().Get("scope") != "repository:test/app:pull" {
+				t.Error("Unexpected scope:", r.URL.Query().Get("scope"))
+			}
+
+			fmt.Fprint(w, `{"token": "t0k3n"}`)
+			return
+		}
+
+		if !authorized(r) {
+			w.Header().Set("WWW-Authenticate", challenge(server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if !strings.Contains(r.Header.Get("Accept"), "application/vnd.docker.distribution.manifest.v2+json") {
+			t.Error("Unexpected Accept header:", r.Header.Get("Accept"))
+		}
+
+		switch r.URL.Path {
+		case "/v2/test/app/manifests/1.0", "/v2/test/app/manifests/" + testDigest:
+			w.Header().Set("Docker-Content-Digest", testDigest)
+
+		case "/v2/test/nodigest/manifests/latest":
+			if r.Method == "GET" {
+				fmt.Fprint(w, `{"schemaVersion": 2}`)
+			}
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server
+}
+
+func imageOn(server *httptest.Server, name string) string {
+	return strings.TrimPrefix(server.URL, "http://") + "/" + name
+}
+
+func TestGetDigest_Anonymous(t *testing.T) {
+	server := newStubRegistry(t, func(r *http.Request) bool { return true }, nil)
+	defer server.Close()
+
+	client := NewClient(nil)
+
+	for _, image := range []string{"test/app:1.0", "test/app@" + testDigest} {
+		digest, err := client.GetDigest(imageOn(server, image))
+		if err != nil {
+			t.Fatal("Failed to get the digest:", err)
+		}
+
+		if digest != testDigest {
+			t.Error("Unexpected digest:", image, digest)
+		}
+	}
+
+	if _, err := client.GetDigest(imageOn(server, "test/app:2.0")); err == nil {
+		t.Error("Expected to fail for a missing tag")
+	}
+}
+
+func TestGetDigest_FromContent(t *testing.T) {
+	server := newStubRegistry(t, func(r *http.Request) bool { return true }, nil)
+	defer server.Close()
+
+	digest, err := NewClient(nil).GetDigest(imageOn(server, "test/nodigest"))
+	if err != nil {
+		t.Fatal("Failed to get the digest:", err)
+	}
+
+	// sha256 of {"schemaVersion": 2}
+	if !strings.HasPrefix(digest, "sha256:") || len(digest) != 71 {
+		t.Error("Unexpected digest:", digest)
+	}
+}
+
+func TestGetDigest_BearerToken(t *testing.T) {
+	server := newStubRegistry(t,
+		func(r *http.Request) bool {
+			return r.Header.Get("Authorization") == "Bearer t0k3n"
+		},
+		func(url string) string {
+			return `Bearer realm="` + url + `/token",service="stub",scope="repository:test/app:pull"`
+		})
+	defer server.Close()
+
+	credentials := func(domain string) (Credential, bool) {
+		if domain != strings.TrimPrefix(server.URL, "http://") {
+			t.Error("Unexpected domain:", domain)
+		}
+
+		return Credential{Username: "user", Password: "secret"}, true
+	}
+
+	digest, err := NewClient(credentials).GetDigest(imageOn(server, "test/app:1.0"))
+	if err != nil {
+		t.Fatal("Failed to get the digest:", err)
+	}
+
+	if digest != testDigest {
+		t.Error("Unexpected digest:", digest)
+	}
+
+	if _, err := NewClient(nil).GetDigest(imageOn(server, "test/app:1.0")); err == nil {
+		t.Error("Expected to fail without credentials")
+	}
+}
+
+func TestGetDigest_IdentityToken(t *testing.T) {
+	server := newStubRegistry(t,
+		func(r *http.Request) bool {
+			return r.Header.Get("Authorization") == "Bearer t0k3n"
+		},
+		func(url string) string {
+			return `Bearer realm="` + url + `/token",service="stub",scope="repository:test/app:pull"`
+		})
+	defer server.Close()
+
+	credentials := func(domain string) (Credential, bool) {
+		return Credential{IdentityToken: "r3fr3sh"}, true
+	}
+
+	if digest, err := NewClient(credentials).GetDigest(imageOn(server, "test/app:1.0")); err != nil || digest != testDigest {
+		t.Error("Unexpected result:", digest, err)
+	}
+}
+
+func TestGetDigest_BasicAuth(t *testing.T) {
+	server := newStubRegistry(t,
+		func(r *http.Request) bool {
+			user, pass, ok := r.BasicAuth()
+			return ok && user == "user" && pass == "secret"
+		},
+		func(url string) string {
+			return `Basic realm="stub"`
+		})
+	defer server.Close()
+
+	credentials := func(domain string) (Credential, bool) {
+		return Credential{Username: "user", Password: "secret"}, true
+	}
+
+	if digest, err := NewClient(credentials).GetDigest(imageOn(server, "test/app:1.0")); err != nil || digest != testDigest {
+		t.Error("Unexpected result:", digest, err)
+	}
+
+	if _, err := NewClient(nil).GetDigest(imageOn(server, "test/app:1.0")); err == nil {
+		t.Error("Expected to fail without credentials")
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(
+		`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`)
+
+	if scheme != "Bearer" {
+		t.Error("Unexpected scheme:", scheme)
+	}
+
+	if params["realm"] != "https://auth.docker.io/token" ||
+		params["service"] != "registry.docker.io" ||
+		params["scope"] != "repository:library/nginx:pull" {
+
+		t.Error("Unexpected parameters:", params)
+	}
+}
+
+func TestGetScheme(t *testing.T) {
+	for domain, expected := range map[string]string{
+		"localhost:5000":       "http",
+		"127.0.0.1:5000":       "http",
+		"[::1]:5000":           "http",
+		"docker.io":            "https",
+		"registry.example.com": "https",
+	} {
+		if scheme := getScheme(domain); scheme != expected {
+			t.Error("Unexpected scheme for", domain, ":", scheme)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/docker/cli/cli/compose/loader"
 	"github.com/docker/cli/cli/compose/types"
 	"github.com/pkg/errors"
+	"github.com/rycus86/podlike/pkg/definition"
 	"gopkg.in/yaml.v2"
 	"os"
 )
@@ -18,6 +19,25 @@ func convertToYaml(v interface{}) string {
 	return string(output)
 }
 
+// The size in bytes above which the generated component definitions are compressed,
+// to keep the labels of the controller manageable.
+var CompressThreshold = 4096
+
+// Returns the definition gzip compressed and base64 encoded,
+// if it's larger than `CompressThreshold`, otherwise as it is.
+func compressDefinition(value string) string {
+	if CompressThreshold <= 0 || len(value) <= CompressThreshold {
+		return value
+	}
+
+	compressed, err := definition.Compress(value)
+	if err != nil {
+		panic(fmt.Sprintf("failed to compress a definition : %s\n%s", err.Error(), value))
+	}
+
+	return compressed
+}
+
 func convertToServices(configuration map[string]interface{}, workingDir string) []types.ServiceConfig {
 	if services, err := loader.LoadServices(
 		configuration, workingDir, os.LookupEnv); err != nil {
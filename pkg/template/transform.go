@@ -20,14 +20,14 @@ func Transform(inputFiles ...string) string {
 		podController := executePodTemplates(&config)
 
 		mcName, mainComponent := executeTransformers(&config)
-		podController.Labels["pod.component."+mcName] = mainComponent
+		podController.Labels["pod.component."+mcName] = compressDefinition(mainComponent)
 
 		for cName, component := range executeTemplates(&config) {
-			podController.Labels["pod.component."+cName] = component
+			podController.Labels["pod.component."+cName] = compressDefinition(component)
 		}
 
 		if initConfig := executeInitTemplates(&config); initConfig != "" {
-			podController.Labels["pod.init.components"] = initConfig
+			podController.Labels["pod.init.components"] = compressDefinition(initConfig)
 		}
 
 		for cName, cp := range executeCopyTemplates(&config) {
@@ -6,6 +6,7 @@ import (
 	"github.com/docker/cli/cli/compose/types"
 	"github.com/rycus86/podlike/pkg/component"
 	"github.com/rycus86/podlike/pkg/convert"
+	"github.com/rycus86/podlike/pkg/definition"
 	"gopkg.in/yaml.v2"
 	"os"
 	"strings"
@@ -71,6 +72,43 @@ func TestTransform_Templates(t *testing.T) {
 		})
 }
 
+func TestTransform_Compressed(t *testing.T) {
+	plain := Transform("testdata/stack-with-templates.yml")
+
+	defer func(threshold int) { CompressThreshold = threshold }(CompressThreshold)
+	CompressThreshold = 10
+
+	compressed := Transform("testdata/stack-with-templates.yml")
+
+	var plainStack, compressedStack struct {
+		Services map[string]map[string]interface{}
+	}
+
+	if err := yaml.Unmarshal([]byte(plain), &plainStack); err != nil {
+		t.Fatal("Invalid YAML:", err)
+	}
+	if err := yaml.Unmarshal([]byte(compressed), &compressedStack); err != nil {
+		t.Fatal("Invalid YAML:", err)
+	}
+
+	plainLabels := plainStack.Services["simple"]["labels"].(map[interface{}]interface{})
+	compressedLabels := compressedStack.Services["simple"]["labels"].(map[interface{}]interface{})
+
+	for _, key := range []string{"pod.component.app", "pod.component.sidecar", "pod.component.logger"} {
+		value, ok := compressedLabels[key].(string)
+		if !ok || !strings.HasPrefix(value, definition.CompressedPrefix) {
+			t.Error("The label is not compressed:", key, compressedLabels[key])
+			continue
+		}
+
+		if resolved, err := definition.Resolve(value); err != nil {
+			t.Error("Failed to resolve the label:", key, err)
+		} else if resolved != plainLabels[key] {
+			t.Error("Unexpected definition for", key, ":", resolved)
+		}
+	}
+}
+
 func TestTransform_PerServiceConfigs(t *testing.T) {
 	output := Transform("testdata/stack-with-per-service-config.yml")
 	verifyTemplatedComponent(t, output, "example", "app",
@@ -1,21 +1,38 @@
 package volume
 
 type Volume struct {
-	Type     string
-	Source   string
-	Target   string
-	ReadOnly bool `mapstructure:"read_only"`
-	Mode     string
+	Type        string
+	Source      string
+	Target      string
+	ReadOnly    bool `mapstructure:"read_only"`
+	Mode        string
+	Consistency string
 
-	Bind struct {
-		Propagation string
-	}
+	Bind   BindOptions
+	Volume VolumeOptions
+	Tmpfs  TmpfsOptions
+}
+
+type BindOptions struct {
+	Propagation    string
+	CreateHostPath *bool  `mapstructure:"create_host_path"`
+	SELinux        string `mapstructure:"selinux"`
+}
 
-	Volume struct {
-		NoCopy bool `mapstructure:"nocopy"`
-	}
+type VolumeOptions struct {
+	NoCopy     bool `mapstructure:"nocopy"`
+	Subpath    string
+	Driver     string
+	DriverOpts map[string]string `mapstructure:"driver_opts"`
+	Labels     map[string]string
+}
+
+type TmpfsOptions struct {
+	Size string
+	Mode uint32
+}
 
-	Tmpfs struct {
-		Size string
-	}
+type PodVolume struct {
+	Medium string
+	Size   string
 }
@@ -1,16 +1,41 @@
 package volume
 
 import (
+	"errors"
+	"fmt"
 	"github.com/docker/docker/api/types/mount"
 	"strings"
 )
 
+var (
+	propagationModes = map[string]bool{
+		string(mount.PropagationRPrivate): true,
+		string(mount.PropagationPrivate):  true,
+		string(mount.PropagationRShared):  true,
+		string(mount.PropagationShared):   true,
+		string(mount.PropagationRSlave):   true,
+		string(mount.PropagationSlave):    true,
+	}
+
+	consistencyModes = map[string]bool{
+		string(mount.ConsistencyFull):      true,
+		string(mount.ConsistencyCached):    true,
+		string(mount.ConsistencyDelegated): true,
+		string(mount.ConsistencyDefault):   true,
+	}
+
+	selinuxModes = map[string]bool{
+		"z": true,
+		"Z": true,
+	}
+)
+
 func (v *Volume) GetMountType() mount.Type {
 	if v.Type != "" {
 		return mount.Type(v.Type)
 	}
 
-	if strings.HasPrefix(v.Source, "/") {
+	if strings.HasPrefix(v.Source, "/") || isWindowsPath(v.Source) {
 		return mount.TypeBind
 	}
 
@@ -22,7 +47,7 @@ func (v *Volume) IsReadOnly() bool {
 		return true
 	}
 
-	for _, mode := range strings.Split(v.Mode, ",") {
+	for _, mode := range v.getModes() {
 		if mode == "ro" {
 			return true
 		}
@@ -30,3 +55,332 @@ func (v *Volume) IsReadOnly() bool {
 
 	return false
 }
+
+func (v *Volume) GetPropagation() string {
+	if v.Bind.Propagation != "" {
+		return v.Bind.Propagation
+	}
+
+	return v.findMode(propagationModes)
+}
+
+func (v *Volume) GetConsistency() string {
+	if v.Consistency != "" {
+		return v.Consistency
+	}
+
+	return v.findMode(consistencyModes)
+}
+
+func (v *Volume) GetSELinuxLabel() string {
+	if v.Bind.SELinux != "" {
+		return v.Bind.SELinux
+	}
+
+	return v.findMode(selinuxModes)
+}
+
+func (v *Volume) IsNoCopy() bool {
+	if v.Volume.NoCopy {
+		return true
+	}
+
+	for _, mode := range v.getModes() {
+		if mode == "nocopy" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NeedsBindSyntax returns true for bind mounts that can only be expressed
+// in the `source:target:options` format of the Docker API, because the
+// host path needs to be created if missing, or SELinux relabeling is requested.
+// Paths with colons in them can't be expressed in that format, so these use mounts.
+func (v *Volume) NeedsBindSyntax() bool {
+	if v.GetMountType() != mount.TypeBind || !v.canUseBindSyntax() {
+		return false
+	}
+
+	if v.Bind.CreateHostPath != nil && *v.Bind.CreateHostPath {
+		return true
+	}
+
+	return v.GetSELinuxLabel() != ""
+}
+
+// canUseBindSyntax returns false if the source or the target has a colon in it,
+// other than the one after the drive letter of a Windows path, because the engine
+// would split the `source:target:options` format at those.
+func (v *Volume) canUseBindSyntax() bool {
+	for _, path := range []string{v.Source, v.Target} {
+		if isWindowsPath(path) {
+			path = path[2:]
+		}
+
+		if strings.Contains(path, ":") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AsBind returns the volume in the `source:target:options` format.
+func (v *Volume) AsBind() string {
+	var options []string
+
+	if v.IsReadOnly() {
+		options = append(options, "ro")
+	}
+
+	for _, option := range []string{v.GetSELinuxLabel(), v.GetPropagation(), v.GetConsistency()} {
+		if option != "" {
+			options = append(options, option)
+		}
+	}
+
+	if len(options) > 0 {
+		return v.Source + ":" + v.Target + ":" + strings.Join(options, ",")
+	} else {
+		return v.Source + ":" + v.Target
+	}
+}
+
+// Validate checks the volume for invalid or unsupported combinations of options.
+func (v *Volume) Validate() error {
+	mountType := v.GetMountType()
+
+	switch mountType {
+	case mount.TypeBind, mount.TypeVolume, mount.TypeTmpfs, mount.TypeNamedPipe:
+	default:
+		return v.newError("type", "unknown volume type: %s", v.Type)
+	}
+
+	if v.Target == "" {
+		return v.newError("target", "the target is missing")
+	}
+
+	if mountType == mount.TypeBind && v.Source == "" {
+		return v.newError("source", "bind mounts need a source")
+	}
+
+	if mountType == mount.TypeTmpfs && v.Source != "" {
+		return v.newError("source", "tmpfs mounts do not support a source")
+	}
+
+	if err := v.validateModes(mountType); err != nil {
+		return err
+	}
+
+	if v.Consistency != "" && !consistencyModes[v.Consistency] {
+		return v.newError("consistency", "invalid consistency: %s", v.Consistency)
+	}
+
+	if mountType != mount.TypeBind {
+		if v.Bind.Propagation != "" || v.Bind.CreateHostPath != nil || v.Bind.SELinux != "" {
+			return v.newError("bind", "bind options are only supported for bind mounts")
+		}
+	} else {
+		if v.Bind.Propagation != "" && !propagationModes[v.Bind.Propagation] {
+			return v.newError("bind.propagation", "invalid propagation: %s", v.Bind.Propagation)
+		}
+
+		if v.Bind.SELinux != "" && !selinuxModes[v.Bind.SELinux] {
+			return v.newError("bind.selinux", "invalid SELinux relabeling, expected z or Z: %s", v.Bind.SELinux)
+		}
+
+		if v.GetSELinuxLabel() != "" && v.Bind.CreateHostPath != nil && !*v.Bind.CreateHostPath {
+			return v.newError("bind.selinux",
+				"SELinux relabeling is not supported with bind.create_host_path disabled")
+		}
+
+		if v.GetSELinuxLabel() != "" && !v.canUseBindSyntax() {
+			return v.newError("bind.selinux",
+				"SELinux relabeling is not supported for paths with colons in them")
+		}
+	}
+
+	if mountType != mount.TypeVolume {
+		if v.Volume.NoCopy || v.Volume.Subpath != "" || v.Volume.Driver != "" ||
+			v.Volume.DriverOpts != nil || v.Volume.Labels != nil {
+
+			return v.newError("volume", "volume options are only supported for volume mounts")
+		}
+	} else {
+		if v.Volume.Subpath != "" {
+			return v.newError("volume.subpath", "subpaths are not supported by the Docker API version used")
+		}
+
+		if v.Volume.DriverOpts != nil && v.Volume.Driver == "" {
+			return v.newError("volume.driver_opts", "driver options need a volume driver")
+		}
+
+		if v.Source == "" && (v.Volume.Driver != "" || v.Volume.Labels != nil) {
+			return v.newError("volume", "driver and labels are only supported for named volumes")
+		}
+	}
+
+	if mountType != mount.TypeTmpfs {
+		if v.Tmpfs.Size != "" || v.Tmpfs.Mode != 0 {
+			return v.newError("tmpfs", "tmpfs options are only supported for tmpfs mounts")
+		}
+	} else if v.Tmpfs.Mode > 07777 {
+		return v.newError("tmpfs.mode", "invalid mode: %o", v.Tmpfs.Mode)
+	}
+
+	return nil
+}
+
+func (v *Volume) validateModes(mountType mount.Type) error {
+	var readWrite, propagation, selinux, consistency int
+
+	for _, mode := range v.getModes() {
+		switch {
+		case mode == "ro" || mode == "rw":
+			readWrite++
+
+		case mode == "nocopy":
+			if mountType != mount.TypeVolume {
+				return v.newError("mode", "nocopy is only supported for volume mounts")
+			}
+
+		case propagationModes[mode]:
+			if mountType != mount.TypeBind {
+				return v.newError("mode", "propagation is only supported for bind mounts: %s", mode)
+			}
+
+			propagation++
+
+		case selinuxModes[mode]:
+			if mountType != mount.TypeBind {
+				return v.newError("mode", "SELinux relabeling is only supported for bind mounts: %s", mode)
+			}
+
+			selinux++
+
+		case consistencyModes[mode]:
+			consistency++
+
+		default:
+			return v.newError("mode", "unknown mode: %s", mode)
+		}
+	}
+
+	if readWrite > 1 || propagation > 1 || selinux > 1 || consistency > 1 {
+		return v.newError("mode", "conflicting modes: %s", v.Mode)
+	}
+
+	return nil
+}
+
+func (v *Volume) getModes() []string {
+	if v.Mode == "" {
+		return nil
+	}
+
+	return strings.Split(v.Mode, ",")
+}
+
+func (v *Volume) findMode(modes map[string]bool) string {
+	for _, mode := range v.getModes() {
+		if modes[mode] {
+			return mode
+		}
+	}
+
+	return ""
+}
+
+func (v *Volume) newError(field string, format string, args ...interface{}) error {
+	return errors.New(fmt.Sprintf("invalid volume %s (%s): %s", v.Target, field, fmt.Sprintf(format, args...)))
+}
+
+// ParseShortSyntax parses the `[source:]target[:mode]` format of volumes,
+// supporting Windows style paths with drive letters, and sources with colons in them.
+func ParseShortSyntax(definition string) (*Volume, error) {
+	parts := splitShortSyntax(definition)
+
+	v := Volume{}
+
+	switch len(parts) {
+	case 1:
+		v.Target = parts[0]
+
+	case 2:
+		v.Source = parts[0]
+		v.Target = parts[1]
+
+	default:
+		last := parts[len(parts)-1]
+
+		if isModeList(last) {
+			v.Source = strings.Join(parts[:len(parts)-2], ":")
+			v.Target = parts[len(parts)-2]
+			v.Mode = last
+		} else {
+			v.Source = strings.Join(parts[:len(parts)-1], ":")
+			v.Target = last
+		}
+
+	}
+
+	if v.Target == "" {
+		return nil, errors.New(fmt.Sprintf("invalid volume definition: %s", definition))
+	}
+
+	if v.GetMountType() == mount.TypeBind {
+		// Compose creates the host path for the short syntax
+		createHostPath := true
+		v.Bind.CreateHostPath = &createHostPath
+	}
+
+	return &v, nil
+}
+
+func splitShortSyntax(definition string) []string {
+	var (
+		raw    = strings.Split(definition, ":")
+		merged = make([]string, 0, len(raw))
+	)
+
+	for idx := 0; idx < len(raw); idx++ {
+		part := raw[idx]
+
+		// merge drive letters, like C:\data or c:/data, at the start of the source,
+		// or at the start of the target, unless the source is a Unix style path
+		if len(part) == 1 && idx+1 < len(raw) &&
+			(strings.HasPrefix(raw[idx+1], "\\") || strings.HasPrefix(raw[idx+1], "/")) &&
+			isLetter(part[0]) && (idx == 0 || (len(merged) == 1 && !strings.HasPrefix(merged[0], "/"))) {
+
+			merged = append(merged, part+":"+raw[idx+1])
+			idx++
+			continue
+		}
+
+		merged = append(merged, part)
+	}
+
+	return merged
+}
+
+func isModeList(value string) bool {
+	for _, mode := range strings.Split(value, ",") {
+		if mode != "ro" && mode != "rw" && mode != "nocopy" &&
+			!propagationModes[mode] && !consistencyModes[mode] && !selinuxModes[mode] {
+
+			return false
+		}
+	}
+
+	return true
+}
+
+func isWindowsPath(path string) bool {
+	return len(path) > 2 && isLetter(path[0]) && path[1] == ':' && (path[2] == '\\' || path[2] == '/')
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}